@@ -0,0 +1,93 @@
+// Command apikey generates an API key and stores its salted hash for the scraper service.
+//
+// Usage:
+//
+//	apikey -id <id> [-owner <owner>] [-expires <date>] [-rpm n] [-burst n] [-concurrent n] [-robots] [-file keys.json]
+//
+// The key is printed once on stdout. With -file its hash is added to a key file read through
+// SCRAPER_API_KEYS_FILE; otherwise the JSON record is printed for adding to a file or secret.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/auth"
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/ratelimit"
+)
+
+func main() {
+	os.Exit(run())
+}
+
+func run() int {
+	flags := flag.NewFlagSet("apikey", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: apikey -id <id> [flags]\n\n")
+		fmt.Fprintf(flags.Output(), "Generates an API key and its salted hash.\n\n")
+		flags.PrintDefaults()
+	}
+
+	id := flags.String("id", "", "unique key ID, shown in logs (required)")
+	owner := flags.String("owner", "", "who the key is issued to")
+	expires := flags.String("expires", "", "expiry as a date (2006-01-02) or RFC 3339 time; never by default")
+	file := flags.String("file", "", "key file to add the key to")
+	rpm := flags.Int("rpm", 0, "requests per minute for this key, -1 for no limit; service default when 0")
+	burst := flags.Int("burst", 0, "requests this key may make at once; service default when 0")
+	concurrent := flags.Int("concurrent", 0, "scrapes this key may have in flight, -1 for no limit; service default when 0")
+	robots := flags.Bool("robots", false, "only fetch pages robots.txt allows for scrapes made with this key")
+
+	if err := flags.Parse(os.Args[1:]); err != nil {
+		return 2
+	}
+	if *id == "" || flags.NArg() > 0 {
+		flags.Usage()
+		return 2
+	}
+
+	var expiresAt *time.Time
+	if *expires != "" {
+		parsed, err := parseTime(*expires)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "apikey: invalid -expires: %v\n", err)
+			return 2
+		}
+		expiresAt = &parsed
+	}
+
+	apiKey, key, err := auth.NewKey(*id, *owner, expiresAt)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "apikey: %v\n", err)
+		return 1
+	}
+	if *rpm != 0 || *burst != 0 || *concurrent != 0 {
+		key.Limits = &ratelimit.Limits{RequestsPerMinute: *rpm, Burst: *burst, MaxConcurrent: *concurrent}
+	}
+	key.RespectRobots = *robots
+
+	if *file != "" {
+		if err := (auth.FileProvider{Path: *file}).Add(key); err != nil {
+			fmt.Fprintf(os.Stderr, "apikey: adding key to %s: %v\n", *file, err)
+			return 1
+		}
+		fmt.Fprintf(os.Stderr, "Added key %q to %s; running services pick it up on their next reload or SIGHUP\n", key.ID, *file)
+	} else {
+		record, _ := json.MarshalIndent(key, "", "  ")
+		fmt.Fprintf(os.Stderr, "Add this record to the \"keys\" list of your key file or secret:\n%s\n", record)
+	}
+
+	// The key itself is shown only here; it cannot be recovered from the stored hash
+	fmt.Println(apiKey)
+	return 0
+}
+
+// parseTime accepts a date or an RFC 3339 time
+func parseTime(value string) (time.Time, error) {
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/auth"
+)
+
+const (
+	defaultKeyReloadSeconds = 60
+	defaultSecretsDir       = "/secrets" // Where Cloud Run mounts Secret Manager volumes by convention
+)
+
+// anonymousKey identifies every caller when authentication is disabled
+var anonymousKey = &auth.Key{ID: "anonymous"}
+
+// keyProvider selects the API key provider from the environment, in order of preference:
+// a secret (SCRAPER_API_KEY_SECRET), a key file (SCRAPER_API_KEYS_FILE), then plaintext keys (SCRAPER_API_KEYS)
+func keyProvider() auth.Provider {
+	if secret := os.Getenv("SCRAPER_API_KEY_SECRET"); secret != "" {
+		dir := os.Getenv("SCRAPER_SECRETS_DIR")
+		if dir == "" {
+			dir = defaultSecretsDir
+		}
+		return auth.SecretProvider{Source: auth.DirSecretSource{Dir: dir}, Secret: secret}
+	}
+	if path := os.Getenv("SCRAPER_API_KEYS_FILE"); path != "" {
+		return auth.FileProvider{Path: path}
+	}
+	if os.Getenv("SCRAPER_API_KEYS") != "" {
+		slog.Warn("API keys are read in plaintext from SCRAPER_API_KEYS; prefer hashed keys in SCRAPER_API_KEYS_FILE or a secret")
+	}
+	return auth.EnvProvider{Var: "SCRAPER_API_KEYS"}
+}
+
+// loadKeyStore creates the API key store and loads its keys
+// It returns nil when SCRAPER_AUTH_DISABLED=true; otherwise requests are rejected until keys load
+func loadKeyStore() *auth.Store {
+	if os.Getenv("SCRAPER_AUTH_DISABLED") == "true" {
+		slog.Warn("API key authentication is disabled (SCRAPER_AUTH_DISABLED=true); every request is allowed")
+		return nil
+	}
+
+	provider := keyProvider()
+	store := auth.NewStore(provider)
+	if err := store.Reload(context.Background()); err != nil {
+		slog.Error("failed to load API keys, rejecting all requests until they load", "error", err)
+	} else if store.Count() == 0 {
+		slog.Error("no API keys configured, rejecting all requests", "provider", provider.Name())
+	} else {
+		slog.Info("loaded API keys", "provider", provider.Name(), "count", store.Count())
+	}
+	return store
+}
+
+// watchKeys starts reloading the API keys periodically and on SIGHUP, until ctx is done
+func (h *CloudRunHandler) watchKeys(ctx context.Context) {
+	if h.keys == nil {
+		return
+	}
+
+	// Registered before returning so an early SIGHUP does not terminate the process
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	interval := time.Duration(envInt("SCRAPER_API_KEYS_RELOAD_SECONDS", defaultKeyReloadSeconds)) * time.Second
+	go func() {
+		defer signal.Stop(hup)
+		h.keys.Run(ctx, interval, hup)
+	}()
+}
+
+// apiKeyFromRequest returns the API key from the X-API-Key header, the Authorization: Bearer header
+// or, unless disabled with SCRAPER_ALLOW_QUERY_KEY=false, the key query parameter
+// X-API-Key comes first so the key can be sent alongside a Cloud Run IAM identity token
+func (h *CloudRunHandler) apiKeyFromRequest(r *http.Request) string {
+	if apiKey := strings.TrimSpace(r.Header.Get("X-API-Key")); apiKey != "" {
+		return apiKey
+	}
+	if scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " "); ok && strings.EqualFold(scheme, "Bearer") {
+		if token = strings.TrimSpace(token); token != "" {
+			return token
+		}
+	}
+	if h.allowQueryKey {
+		// Kept for existing clients; query strings end up in proxy logs and browser history
+		return r.URL.Query().Get("key")
+	}
+	return ""
+}
+
+// requireAPIKey authenticates the request's API key, writing a 401 response when it is not accepted
+func (h *CloudRunHandler) requireAPIKey(w http.ResponseWriter, r *http.Request) (*auth.Key, bool) {
+	if h.keys == nil {
+		return anonymousKey, true
+	}
+
+	key, err := h.keys.Authenticate(h.apiKeyFromRequest(r))
+	if err != nil {
+		if errors.Is(err, auth.ErrNoKeys) {
+			slog.ErrorContext(r.Context(), "rejecting request: no API keys are loaded")
+		}
+		w.Header().Set("WWW-Authenticate", `Bearer realm="scraper"`)
+		h.errorResponse(w, http.StatusUnauthorized, unauthorizedMessage(err))
+		return nil, false
+	}
+	return key, true
+}
+
+// unauthorizedMessage describes a rejected key without revealing whether other keys exist
+func unauthorizedMessage(err error) string {
+	switch {
+	case errors.Is(err, auth.ErrKeyExpired):
+		return "API key has expired"
+	case errors.Is(err, auth.ErrKeyDisabled):
+		return "API key is disabled"
+	}
+	return "Invalid or missing API key"
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/config"
+)
+
+func TestAPIKeyFromRequest(t *testing.T) {
+	tests := []struct {
+		name          string
+		apiKeyHeader  string
+		authorization string
+		query         string
+		allowQueryKey bool
+		want          string
+	}{
+		{"X-API-Key header", "header-key", "", "", true, "header-key"},
+		{"X-API-Key before Bearer", "header-key", "Bearer bearer-key", "?key=query-key", true, "header-key"},
+		{"Bearer before query", "", "Bearer bearer-key", "?key=query-key", true, "bearer-key"},
+		{"Bearer scheme is case-insensitive", "", "bearer  bearer-key ", "", true, "bearer-key"},
+		{"other schemes are ignored", "", "Basic dXNlcjpwYXNz", "?key=query-key", true, "query-key"},
+		{"empty Bearer token", "", "Bearer ", "?key=query-key", true, "query-key"},
+		{"blank X-API-Key", "  ", "Bearer bearer-key", "", true, "bearer-key"},
+		{"query parameter", "", "", "?key=query-key", true, "query-key"},
+		{"query parameter disabled", "", "", "?key=query-key", false, ""},
+		{"headers still work without query keys", "", "Bearer bearer-key", "?key=query-key", false, "bearer-key"},
+		{"no key", "", "", "", true, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("POST", "/v1/extract"+tt.query, nil)
+			if tt.apiKeyHeader != "" {
+				r.Header.Set("X-API-Key", tt.apiKeyHeader)
+			}
+			if tt.authorization != "" {
+				r.Header.Set("Authorization", tt.authorization)
+			}
+			h := &CloudRunHandler{allowQueryKey: tt.allowQueryKey}
+			if got := h.apiKeyFromRequest(r); got != tt.want {
+				t.Errorf("apiKeyFromRequest = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAllowQueryKeyEnv(t *testing.T) {
+	tests := map[string]bool{"": true, "true": true, "false": false}
+	for value, want := range tests {
+		t.Run("SCRAPER_ALLOW_QUERY_KEY="+value, func(t *testing.T) {
+			t.Setenv("SCRAPER_AUTH_DISABLED", "true")
+			t.Setenv("SCRAPER_ALLOW_QUERY_KEY", value)
+			h := NewCloudRunHandler(config.DefaultSettings())
+			defer h.jobs.Close(context.Background())
+
+			if h.allowQueryKey != want {
+				t.Errorf("allowQueryKey = %v, want %v", h.allowQueryKey, want)
+			}
+			r := httptest.NewRequest("GET", "/?key=query-key", nil)
+			if got := h.apiKeyFromRequest(r); (got == "query-key") != want {
+				t.Errorf("apiKeyFromRequest = %q with query keys allowed=%v", got, want)
+			}
+		})
+	}
+}
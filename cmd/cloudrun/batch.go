@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/auth"
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/models"
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/scraper"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// Batch limits, overridable with BATCH_MAX_URLS and BATCH_MAX_CONCURRENCY
+const (
+	defaultBatchMaxURLs        = 50
+	defaultBatchMaxConcurrency = 4
+)
+
+// BatchRequest is the JSON body accepted by POST /v1/batch
+type BatchRequest struct {
+	URLs        []string                  `json:"urls"`
+	Concurrency int                       `json:"concurrency,omitempty"`
+	Timeout     int                       `json:"timeout,omitempty"` // Milliseconds, per URL
+	Options     scraper.ExtractionOptions `json:"options"`
+}
+
+// BatchHandler serves POST /v1/batch, scraping several URLs with bounded concurrency
+func (h *CloudRunHandler) BatchHandler(w http.ResponseWriter, r *http.Request) {
+	h.setCommonHeaders(w, r, "POST,OPTIONS")
+
+	if r.Method == "OPTIONS" {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if r.Method != "POST" {
+		h.errorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	slog.InfoContext(r.Context(), "request received", "method", r.Method, "path", r.URL.Path)
+
+	key, ok := h.requireAPIKey(w, r)
+	if !ok {
+		return
+	}
+
+	req := BatchRequest{
+		Concurrency: h.batchMaxConcurrency,
+		Timeout:     defaultTimeoutMs,
+		Options:     scraper.DefaultExtractionOptions(),
+	}
+	if err := decodeJSONBody(w, r, &req); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if len(req.URLs) == 0 {
+		h.errorResponse(w, http.StatusBadRequest, "Missing \"urls\" field")
+		return
+	}
+	if len(req.URLs) > h.batchMaxURLs {
+		h.errorResponse(w, http.StatusBadRequest, fmt.Sprintf("Too many URLs: %d (max %d)", len(req.URLs), h.batchMaxURLs))
+		return
+	}
+	if err := req.Options.Validate(); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, fmt.Sprintf("Invalid options: %v", err))
+		return
+	}
+
+	// Never exceed the server-wide cap, whatever the client asks for
+	concurrency := req.Concurrency
+	if concurrency < 1 || concurrency > h.batchMaxConcurrency {
+		concurrency = h.batchMaxConcurrency
+	}
+
+	// Each URL costs one request, so a batch larger than the key's burst could never be admitted;
+	// the batch runs with as many of the key's concurrent scrape slots as are free
+	if burst := h.limiter.Resolve(key.RateLimits()).Burst; burst > 0 && len(req.URLs) > burst {
+		h.errorResponse(w, http.StatusTooManyRequests,
+			fmt.Sprintf("Batch of %d URLs exceeds this API key's burst of %d requests", len(req.URLs), burst))
+		return
+	}
+	concurrency, release, ok := h.admit(w, r, key, len(req.URLs), concurrency)
+	if !ok {
+		return
+	}
+	defer release()
+
+	response := h.runBatch(r.Context(), key, req.URLs, concurrency, req.Timeout, req.Options)
+	writeJSON(w, http.StatusOK, response)
+}
+
+// runBatch scrapes every URL for key with at most concurrency scrapes in flight
+// The whole batch shares the same overall time cap as a single request
+func (h *CloudRunHandler) runBatch(parent context.Context, key *auth.Key, urls []string, concurrency, timeoutMs int, options scraper.ExtractionOptions) models.BatchResponse {
+	ctx, cancel := context.WithTimeout(parent, time.Duration(maxTimeoutMs)*time.Millisecond)
+	defer cancel()
+
+	slog.InfoContext(ctx, "starting batch", "urls", len(urls), "concurrency", concurrency)
+	start := time.Now()
+
+	results := make([]models.BatchResult, len(urls))
+
+	g := new(errgroup.Group)
+	g.SetLimit(concurrency)
+
+	for i, targetURL := range urls {
+		i, targetURL := i, targetURL // capture loop variables
+		g.Go(func() error {
+			// Report URLs that never started because the batch ran out of time
+			if ctx.Err() != nil {
+				results[i] = models.BatchResult{
+					URL:           targetURL,
+					ScrapeOutcome: errorOutcome(http.StatusGatewayTimeout, "Batch time budget exhausted before this URL was scraped"),
+				}
+				return nil
+			}
+
+			if err := validateTargetURL(targetURL); err != nil {
+				results[i] = models.BatchResult{
+					URL:           targetURL,
+					ScrapeOutcome: errorOutcome(http.StatusBadRequest, err.Error()),
+				}
+				return nil
+			}
+
+			results[i] = models.BatchResult{
+				URL:           targetURL,
+				ScrapeOutcome: h.runScrape(ctx, key, targetURL, timeoutMs, options),
+			}
+			return nil
+		})
+	}
+	g.Wait()
+
+	response := models.BatchResponse{
+		Results:    results,
+		DurationMs: time.Since(start).Milliseconds(),
+	}
+	for _, result := range results {
+		if result.Status == http.StatusOK {
+			response.Succeeded++
+		} else {
+			response.Failed++
+		}
+	}
+
+	slog.InfoContext(ctx, "batch finished", "duration_ms", response.DurationMs, "succeeded", response.Succeeded, "failed", response.Failed)
+	return response
+}
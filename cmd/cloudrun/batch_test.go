@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/auth"
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/config"
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/models"
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/ratelimit"
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/scraper"
+)
+
+// pageFetcher serves an article titled after each URL's host, failing for hosts starting with
+// "fail", and records how many fetches were in flight at once
+type pageFetcher struct {
+	delay    time.Duration
+	fetches  atomic.Int32
+	inFlight atomic.Int32
+	peak     atomic.Int32
+}
+
+func (f *pageFetcher) Name() string {
+	return "http"
+}
+
+func (f *pageFetcher) Fetch(ctx context.Context, targetURL string) (scraper.FetchResult, error) {
+	f.fetches.Add(1)
+	n := f.inFlight.Add(1)
+	defer f.inFlight.Add(-1)
+	for {
+		peak := f.peak.Load()
+		if n <= peak || f.peak.CompareAndSwap(peak, n) {
+			break
+		}
+	}
+	time.Sleep(f.delay)
+
+	u, _ := url.Parse(targetURL)
+	if strings.HasPrefix(u.Host, "fail") {
+		return scraper.FetchResult{}, errors.New("connection refused")
+	}
+	return scraper.FetchResult{FinalURL: targetURL, HTML: fmt.Sprintf(`<html><head><title>%[1]s</title></head><body><article>
+<h1>%[1]s</h1>
+<p>This article is served by a fake fetcher so batches can be tested without a network.</p>
+<p>A second paragraph makes sure readability treats the page as a real article with enough text.</p>
+</article></body></html>`, u.Host)}, nil
+}
+
+// newBatchHandler returns a handler scraping with fetcher and rate limiting keys with limits
+func newBatchHandler(fetcher scraper.Fetcher, limits ratelimit.Limits) *CloudRunHandler {
+	s := scraper.NewScraperWithFetchers(scraper.NewArticleExtractor(), fetcher)
+	s.SetScheduler(scraper.NewHostScheduler(func() config.Politeness { return config.Politeness{} }))
+	h := &CloudRunHandler{
+		cors:                &corsPolicy{allowAll: true},
+		batchMaxURLs:        defaultBatchMaxURLs,
+		batchMaxConcurrency: defaultBatchMaxConcurrency,
+		limiter:             ratelimit.NewLimiter(limits),
+	}
+	h.scraper.Store(s)
+	return h
+}
+
+// batchURLs returns n URLs on distinct hosts, so politeness never spaces them out
+func batchURLs(n int) []string {
+	urls := make([]string, n)
+	for i := range urls {
+		urls[i] = fmt.Sprintf("https://site%d.test/article", i)
+	}
+	return urls
+}
+
+func TestBatchHandler(t *testing.T) {
+	// Refilled at one request a minute, so no token comes back while a test runs
+	limits := ratelimit.Limits{RequestsPerMinute: 1, Burst: 5, MaxConcurrent: 2}
+
+	tests := []struct {
+		name      string
+		urls      []string
+		status    int
+		fetches   int
+		remaining string // X-RateLimit-Remaining, empty when the batch is refused before charging
+	}{
+		{"charges one request per URL", batchURLs(3), http.StatusOK, 3, "2"},
+		{"whole burst", batchURLs(5), http.StatusOK, 5, "0"},
+		{"larger than the burst", batchURLs(6), http.StatusTooManyRequests, 0, ""},
+		{"larger than BATCH_MAX_URLS", batchURLs(defaultBatchMaxURLs + 1), http.StatusBadRequest, 0, ""},
+		{"no URLs", nil, http.StatusBadRequest, 0, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fetcher := &pageFetcher{}
+			h := newBatchHandler(fetcher, limits)
+			body, _ := json.Marshal(map[string][]string{"urls": tt.urls})
+			w := httptest.NewRecorder()
+			h.BatchHandler(w, httptest.NewRequest("POST", "/v1/batch", strings.NewReader(string(body))))
+
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+			if got := w.Header().Get("X-RateLimit-Remaining"); got != tt.remaining {
+				t.Errorf("X-RateLimit-Remaining = %q, want %q", got, tt.remaining)
+			}
+			if n := int(fetcher.fetches.Load()); n != tt.fetches {
+				t.Errorf("fetched %d pages, want %d", n, tt.fetches)
+			}
+			if tt.status == http.StatusTooManyRequests {
+				var resp models.ErrorResponse
+				if err := json.NewDecoder(w.Body).Decode(&resp); err != nil || resp.Code != models.CodeRateLimited {
+					t.Errorf("body code = %q (%v), want %q", resp.Code, err, models.CodeRateLimited)
+				}
+			}
+		})
+	}
+}
+
+func TestRunBatch(t *testing.T) {
+	fetcher := &pageFetcher{delay: 50 * time.Millisecond}
+	h := newBatchHandler(fetcher, ratelimit.Limits{RequestsPerMinute: -1, MaxConcurrent: -1})
+	urls := append(batchURLs(5), "ftp://site.test/file", "https://fail.test/article", "https://last.test/article")
+
+	response := h.runBatch(context.Background(), &auth.Key{ID: "acme"}, urls, 2, defaultTimeoutMs, scraper.DefaultExtractionOptions())
+
+	if peak := fetcher.peak.Load(); peak != 2 {
+		t.Errorf("%d fetches in flight at once, want the concurrency of 2", peak)
+	}
+	if len(response.Results) != len(urls) {
+		t.Fatalf("%d results for %d URLs", len(response.Results), len(urls))
+	}
+	for i, result := range response.Results {
+		if result.URL != urls[i] {
+			t.Errorf("result %d is for %s, want %s", i, result.URL, urls[i])
+		}
+	}
+
+	// Each URL reports its own outcome, in request order
+	for i, want := range map[int]int{0: http.StatusOK, 4: http.StatusOK, 5: http.StatusBadRequest, 6: http.StatusInternalServerError, 7: http.StatusOK} {
+		if got := response.Results[i].Status; got != want {
+			t.Errorf("result %d (%s): status = %d, want %d", i, urls[i], got, want)
+		}
+	}
+	if result := response.Results[7].Result; result == nil || result.Title != "last.test" {
+		t.Errorf("last result = %+v, want the page of last.test", result)
+	}
+	if response.Succeeded != 6 || response.Failed != 2 {
+		t.Errorf("succeeded %d, failed %d; want 6 and 2", response.Succeeded, response.Failed)
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/cache"
+)
+
+// Defaults for CACHE_MAX_ENTRIES and CACHE_MAX_AGE_SECONDS
+const (
+	defaultCacheMaxEntries    = 1000
+	defaultCacheMaxAgeSeconds = 3600
+)
+
+// resultCache is the scrape result cache shared by every scraper
+type resultCache struct {
+	store  cache.Store
+	maxAge time.Duration // Used when a request does not set maxAge
+}
+
+// loadResultCache builds the result cache from the environment: an in-memory LRU of
+// CACHE_MAX_ENTRIES results, backed by CACHE_DIR when set
+// The directory is bounded by CACHE_DIR_MAX_ENTRIES, CACHE_DIR_MAX_BYTES and CACHE_DIR_MAX_AGE_SECONDS,
+// since on Cloud Run it lives in memory too
+// It returns nil when CACHE_DISABLED=true
+func loadResultCache() *resultCache {
+	if os.Getenv("CACHE_DISABLED") == "true" {
+		slog.Info("result cache is disabled")
+		return nil
+	}
+
+	maxEntries := envInt("CACHE_MAX_ENTRIES", defaultCacheMaxEntries)
+	maxAge := time.Duration(envInt("CACHE_MAX_AGE_SECONDS", defaultCacheMaxAgeSeconds)) * time.Second
+	stores := []cache.Store{cache.NewLRU(maxEntries)}
+	if dir := os.Getenv("CACHE_DIR"); dir != "" {
+		limits := cache.DefaultDiskLimits()
+		limits.MaxEntries = envInt("CACHE_DIR_MAX_ENTRIES", limits.MaxEntries)
+		limits.MaxBytes = int64(envInt("CACHE_DIR_MAX_BYTES", int(limits.MaxBytes)))
+		limits.MaxAge = time.Duration(envInt("CACHE_DIR_MAX_AGE_SECONDS", int(limits.MaxAge/time.Second))) * time.Second
+		disk, err := cache.NewDiskWithLimits(dir, limits)
+		if err != nil {
+			slog.Error("on-disk result cache unavailable, caching in memory only", "dir", dir, "error", err)
+		} else {
+			stores = append(stores, disk)
+			slog.Info("on-disk result cache enabled", "dir", dir, "entries", disk.Len(),
+				"max_entries", limits.MaxEntries, "max_bytes", limits.MaxBytes, "max_age", limits.MaxAge)
+		}
+	}
+
+	slog.Info("result cache enabled", "max_entries", maxEntries, "max_age", maxAge, "dir", os.Getenv("CACHE_DIR"))
+	return &resultCache{store: cache.NewTiered(stores...), maxAge: maxAge}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/config"
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/scraper"
+)
+
+// defaultConfigPollSeconds is how often CONFIG_FILE is checked for changes
+const defaultConfigPollSeconds = 5
+
+// applySettings swaps in a scraper built from settings
+// Scrapes already running finish with the scraper they started with
+func (h *CloudRunHandler) applySettings(settings *config.Settings) {
+	s := scraper.NewScraperWithSettings(settings, h.browser)
+	if h.cache != nil {
+		s.SetCache(h.cache.store, h.cache.maxAge)
+	}
+	h.scraper.Store(s)
+}
+
+// watchConfig starts reloading path on SIGHUP and whenever it changes, until ctx is done
+// An invalid file is logged and the previous settings stay in effect
+func (h *CloudRunHandler) watchConfig(ctx context.Context, path string) {
+	// Registered before returning so an early SIGHUP does not terminate the process
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	interval := time.Duration(envInt("CONFIG_POLL_SECONDS", defaultConfigPollSeconds)) * time.Second
+	slog.Info("watching configuration file", "path", path, "poll_interval", interval)
+	go func() {
+		defer signal.Stop(hup)
+		config.NewWatcher(path, interval, h.applySettings).Run(ctx, hup)
+	}()
+}
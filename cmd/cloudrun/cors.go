@@ -0,0 +1,133 @@
+package main
+
+import (
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Defaults for the CORS_* environment variables
+const (
+	defaultCORSOrigins = "*"
+	defaultCORSHeaders = "Content-Type, Authorization, X-API-Key, X-Request-ID, Idempotency-Key, traceparent"
+)
+
+// corsExposedHeaders are the response headers browser clients may read
+const corsExposedHeaders = "X-Request-ID, Location, Retry-After, X-RateLimit-Limit, X-RateLimit-Remaining, X-RateLimit-Reset"
+
+// corsPolicy is the deployment's cross-origin policy
+type corsPolicy struct {
+	allowAll bool
+	origins  []string        // Exact origins, or patterns such as https://*.example.com
+	methods  map[string]bool // nil allows every method an endpoint supports
+	headers  string
+	maxAge   string // Seconds preflight responses may be cached; empty leaves it to the browser
+}
+
+// loadCORSPolicy reads the CORS policy from CORS_ALLOWED_ORIGINS, CORS_ALLOWED_METHODS,
+// CORS_ALLOWED_HEADERS and CORS_MAX_AGE, all optional
+func loadCORSPolicy() *corsPolicy {
+	policy := &corsPolicy{headers: defaultCORSHeaders}
+
+	origins := os.Getenv("CORS_ALLOWED_ORIGINS")
+	if strings.TrimSpace(origins) == "" {
+		origins = defaultCORSOrigins
+	}
+	for _, origin := range splitList(origins) {
+		if origin == "*" {
+			policy.allowAll = true
+		}
+		policy.origins = append(policy.origins, strings.TrimSuffix(strings.ToLower(origin), "/"))
+	}
+
+	if methods := splitList(os.Getenv("CORS_ALLOWED_METHODS")); len(methods) > 0 {
+		policy.methods = map[string]bool{"OPTIONS": true}
+		for _, method := range methods {
+			policy.methods[strings.ToUpper(method)] = true
+		}
+	}
+	if headers := splitList(os.Getenv("CORS_ALLOWED_HEADERS")); len(headers) > 0 {
+		policy.headers = strings.Join(headers, ", ")
+	}
+	if maxAge := envInt("CORS_MAX_AGE", 0); maxAge > 0 {
+		policy.maxAge = strconv.Itoa(maxAge)
+	}
+	return policy
+}
+
+// splitList splits a comma-separated list, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// allowOrigin returns the Access-Control-Allow-Origin value for a request from origin,
+// or "" when the origin is not allowed
+func (p *corsPolicy) allowOrigin(origin string) string {
+	if p.allowAll {
+		return "*"
+	}
+	if origin == "" {
+		return ""
+	}
+	lower := strings.ToLower(origin)
+	for _, allowed := range p.origins {
+		if allowed == lower || matchOriginPattern(allowed, lower) {
+			return origin
+		}
+	}
+	return ""
+}
+
+// matchOriginPattern reports whether origin matches a pattern with a leading wildcard subdomain,
+// such as https://*.example.com
+func matchOriginPattern(pattern, origin string) bool {
+	scheme, host, ok := strings.Cut(pattern, "://*.")
+	if !ok {
+		return false
+	}
+	prefix := scheme + "://"
+	return strings.HasPrefix(origin, prefix) && strings.HasSuffix(origin, "."+host) &&
+		len(origin) > len(prefix)+len(host)+1
+}
+
+// allowMethods returns the endpoint's methods that the policy allows
+func (p *corsPolicy) allowMethods(methods string) string {
+	if p.methods == nil {
+		return methods
+	}
+	var allowed []string
+	for _, method := range strings.Split(methods, ",") {
+		if p.methods[method] {
+			allowed = append(allowed, method)
+		}
+	}
+	return strings.Join(allowed, ",")
+}
+
+// setCommonHeaders sets the JSON content type and the CORS headers shared by all endpoints;
+// methods lists what the endpoint supports
+func (h *CloudRunHandler) setCommonHeaders(w http.ResponseWriter, r *http.Request, methods string) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	origin := h.cors.allowOrigin(r.Header.Get("Origin"))
+	if !h.cors.allowAll {
+		w.Header().Add("Vary", "Origin")
+	}
+	if origin == "" {
+		return
+	}
+	w.Header().Set("Access-Control-Allow-Origin", origin)
+	w.Header().Set("Access-Control-Allow-Headers", h.cors.headers)
+	w.Header().Set("Access-Control-Expose-Headers", corsExposedHeaders)
+	w.Header().Set("Access-Control-Allow-Methods", h.cors.allowMethods(methods))
+	if h.cors.maxAge != "" {
+		w.Header().Set("Access-Control-Max-Age", h.cors.maxAge)
+	}
+}
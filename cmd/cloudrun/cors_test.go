@@ -0,0 +1,51 @@
+package main
+
+import "testing"
+
+func TestAllowOrigin(t *testing.T) {
+	tests := []struct {
+		name    string
+		origins string
+		origin  string
+		want    string
+	}{
+		{"default allows every origin", "", "https://app.example.com", "*"},
+		{"wildcard", "https://app.example.com, *", "https://other.example.org", "*"},
+		{"exact origin", "https://app.example.com", "https://app.example.com", "https://app.example.com"},
+		{"exact origin echoes the request's case", "https://App.Example.com/", "https://APP.example.com", "https://APP.example.com"},
+		{"other origin", "https://app.example.com", "https://evil.example.com", ""},
+		{"no origin", "https://app.example.com", "", ""},
+		{"subdomain pattern", "https://*.example.com", "https://a.b.example.com", "https://a.b.example.com"},
+		{"pattern needs a subdomain", "https://*.example.com", "https://example.com", ""},
+		{"pattern checks the scheme", "https://*.example.com", "http://app.example.com", ""},
+		{"pattern is not a suffix match", "https://*.example.com", "https://app.notexample.com", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CORS_ALLOWED_ORIGINS", tt.origins)
+			if got := loadCORSPolicy().allowOrigin(tt.origin); got != tt.want {
+				t.Errorf("allowOrigin(%q) with %q = %q, want %q", tt.origin, tt.origins, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchOriginPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		origin  string
+		want    bool
+	}{
+		{"https://*.example.com", "https://app.example.com", true},
+		{"https://*.example.com", "https://.example.com", false},
+		{"https://*.example.com", "https://app.example.com.evil.org", false},
+		{"https://*.example.com:8443", "https://app.example.com:8443", true},
+		{"https://*.example.com:8443", "https://app.example.com", false},
+		{"https://app.example.com", "https://app.example.com", false}, // Not a pattern
+	}
+	for _, tt := range tests {
+		if got := matchOriginPattern(tt.pattern, tt.origin); got != tt.want {
+			t.Errorf("matchOriginPattern(%q, %q) = %v, want %v", tt.pattern, tt.origin, got, tt.want)
+		}
+	}
+}
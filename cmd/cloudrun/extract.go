@@ -0,0 +1,238 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/auth"
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/models"
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/scraper"
+)
+
+// Timeout limits applied to every scrape (Cloud Run has a 5 minute max)
+const (
+	defaultTimeoutMs = 300000 // Default 5 minutes, capped below
+	maxTimeoutMs     = 240000 // Cap at 4 minutes (240 seconds) to be safe
+	minTimeoutMs     = 1000
+)
+
+// maxRequestBodyBytes limits the size of JSON request bodies
+const maxRequestBodyBytes = 1 << 20
+
+// ExtractRequest is the JSON body accepted by POST /v1/extract
+type ExtractRequest struct {
+	URL     string                    `json:"url"`
+	Timeout int                       `json:"timeout,omitempty"` // Milliseconds
+	Options scraper.ExtractionOptions `json:"options"`
+}
+
+// ExtractHandler serves POST /v1/extract with per-request extraction options
+func (h *CloudRunHandler) ExtractHandler(w http.ResponseWriter, r *http.Request) {
+	h.setCommonHeaders(w, r, "POST,OPTIONS")
+
+	if r.Method == "OPTIONS" {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if r.Method != "POST" {
+		h.errorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	slog.InfoContext(r.Context(), "request received", "method", r.Method, "path", r.URL.Path)
+
+	key, ok := h.requireAPIKey(w, r)
+	if !ok {
+		return
+	}
+
+	req := h.defaultExtractRequest()
+	if err := decodeJSONBody(w, r, &req); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := validateTargetURL(req.URL); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := req.Options.Validate(); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, fmt.Sprintf("Invalid options: %v", err))
+		return
+	}
+
+	_, release, ok := h.admit(w, r, key, 1, 1)
+	if !ok {
+		return
+	}
+	defer release()
+
+	writeOutcome(w, h.runScrape(r.Context(), key, req.URL, req.Timeout, req.Options))
+}
+
+// defaultExtractRequest returns a request pre-filled with defaults
+// Fields not present in a decoded body keep these values
+func (h *CloudRunHandler) defaultExtractRequest() ExtractRequest {
+	return ExtractRequest{
+		Timeout: defaultTimeoutMs,
+		Options: scraper.DefaultExtractionOptions(),
+	}
+}
+
+// decodeJSONBody decodes a size-limited JSON request body into dst
+func decodeJSONBody(w http.ResponseWriter, r *http.Request, dst interface{}) error {
+	return decodeJSONBodyWithLimit(w, r, dst, maxRequestBodyBytes)
+}
+
+// decodeJSONBodyWithLimit decodes a JSON request body of at most limit bytes into dst
+func decodeJSONBodyWithLimit(w http.ResponseWriter, r *http.Request, dst interface{}, limit int64) error {
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, limit))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(dst); err != nil {
+		return fmt.Errorf("Invalid JSON body: %w", err)
+	}
+	return nil
+}
+
+// validateTargetURL checks that a target URL is present and absolute http(s)
+func validateTargetURL(targetURL string) error {
+	if targetURL == "" {
+		return fmt.Errorf("Missing \"url\" field")
+	}
+	parsed, err := url.Parse(targetURL)
+	if err != nil || parsed.Host == "" || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return fmt.Errorf("Invalid URL format")
+	}
+	return nil
+}
+
+// clampTimeout keeps a requested timeout within the supported range
+func clampTimeout(timeoutMs int) int {
+	if timeoutMs > maxTimeoutMs {
+		return maxTimeoutMs
+	}
+	if timeoutMs < minTimeoutMs {
+		return minTimeoutMs
+	}
+	return timeoutMs
+}
+
+// writeOutcome writes a scrape outcome as the HTTP response
+func writeOutcome(w http.ResponseWriter, outcome models.ScrapeOutcome) {
+	switch {
+	case outcome.Result != nil:
+		writeJSON(w, outcome.Status, outcome.Result)
+	case outcome.Blocked != nil:
+		writeJSON(w, outcome.Status, outcome.Blocked)
+	default:
+		writeJSON(w, outcome.Status, outcome.Error)
+	}
+}
+
+// errorOutcome builds a failed scrape outcome with the given status and message
+func errorOutcome(statusCode int, message string) models.ScrapeOutcome {
+	return models.ScrapeOutcome{
+		Status: statusCode,
+		Error:  &models.ErrorResponse{Error: message, Code: codeForStatus(statusCode)},
+	}
+}
+
+// failedOutcome builds a failed scrape outcome whose code and upstream status come from err
+func failedOutcome(statusCode int, message string, err error) models.ScrapeOutcome {
+	outcome := errorOutcome(statusCode, message)
+	outcome.Error.Code = models.ErrorCode(err)
+	outcome.Error.UpstreamStatus = models.UpstreamStatus(err)
+	return outcome
+}
+
+// withFailedScrape attaches what a failed scrape reported, its debug trace and time queued for
+// the site, to a failed outcome
+func withFailedScrape(outcome models.ScrapeOutcome, result models.ScrapeResponse) models.ScrapeOutcome {
+	if outcome.Error != nil {
+		outcome.Error.Debug = result.Debug
+		outcome.Error.QueueWaitMs = result.Metadata.QueueWaitMs
+	}
+	return outcome
+}
+
+// runScrape scrapes a single URL for key and maps the result to an HTTP status and body
+func (h *CloudRunHandler) runScrape(parent context.Context, key *auth.Key, targetURL string, timeoutMs int, options scraper.ExtractionOptions) models.ScrapeOutcome {
+	timeoutMs = clampTimeout(timeoutMs)
+	slog.InfoContext(parent, "starting scrape", "url", targetURL, "timeout_ms", timeoutMs)
+	if key.RespectRobots {
+		parent = scraper.WithRobotsCompliance(parent)
+	}
+
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(parent, time.Duration(timeoutMs)*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+
+	// Perform scraping
+	result, err := h.scraper.Load().ScrapeSmartWithOptions(ctx, targetURL, options)
+
+	duration := time.Since(start)
+
+	// Handle Cloudflare blocking
+	var cfErr *models.CloudflareBlockError
+	if errors.As(err, &cfErr) {
+		return models.ScrapeOutcome{
+			Status: http.StatusUnavailableForLegalReasons,
+			Blocked: &models.BlockedResponse{
+				Error:    "Blocked by site protection",
+				Code:     models.CodeBlocked,
+				Provider: "cloudflare",
+				Domain:   cfErr.Domain,
+				Metadata: models.Metadata{
+					URL:         targetURL,
+					ScrapedAt:   time.Now(),
+					DurationMs:  duration.Milliseconds(),
+					QueueWaitMs: result.Metadata.QueueWaitMs,
+				},
+				Debug: result.Debug,
+			},
+		}
+	}
+
+	// Handle timeout and cancellation (client gone or server shutting down)
+	switch models.ErrorCode(err) {
+	case models.CodeTimeout:
+		slog.WarnContext(ctx, "scrape timed out", "url", targetURL, "duration_ms", duration.Milliseconds())
+		return withFailedScrape(failedOutcome(http.StatusGatewayTimeout, "Scrape took too long", err), result)
+	case models.CodeCanceled:
+		slog.WarnContext(ctx, "scrape cancelled", "url", targetURL, "duration_ms", duration.Milliseconds())
+		return withFailedScrape(failedOutcome(http.StatusServiceUnavailable, "Scrape was cancelled", err), result)
+	case models.CodeDisallowedByRobots:
+		slog.InfoContext(ctx, "scrape disallowed by robots.txt", "url", targetURL, "error", err)
+		return withFailedScrape(failedOutcome(http.StatusForbidden, fmt.Sprintf("Not fetched: %s", sanitizeErrorMessage(err)), err), result)
+	}
+
+	// Handle other errors
+	if err != nil {
+		// Log full error details for debugging
+		slog.ErrorContext(ctx, "scrape failed", "url", targetURL, "error", err, "error_type", fmt.Sprintf("%T", err),
+			"timeout_ms", timeoutMs, "duration_ms", duration.Milliseconds())
+
+		// Create sanitized error message for response
+		errorMsg := sanitizeErrorMessage(err)
+		return withFailedScrape(failedOutcome(http.StatusInternalServerError, fmt.Sprintf("Failed to scrape: %s", errorMsg), err), result)
+	}
+
+	// Add metadata to successful response; cached results keep the time they were scraped
+	result.Metadata.URL = targetURL
+	if result.Metadata.ScrapedAt.IsZero() {
+		result.Metadata.ScrapedAt = time.Now()
+	}
+	result.Metadata.DurationMs = duration.Milliseconds()
+
+	return models.ScrapeOutcome{Status: http.StatusOK, Result: &result}
+}
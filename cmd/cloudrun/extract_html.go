@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/models"
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/scraper"
+)
+
+// maxHTMLRequestBodyBytes limits the size of request bodies carrying raw HTML
+const maxHTMLRequestBodyBytes = 10 << 20
+
+// ExtractHTMLRequest is the JSON body accepted by POST /v1/extract/html
+type ExtractHTMLRequest struct {
+	HTML    string                    `json:"html"`
+	URL     string                    `json:"url"` // Base URL used to resolve relative links and images
+	Options scraper.ExtractionOptions `json:"options"`
+}
+
+// ExtractHTMLHandler serves POST /v1/extract/html, extracting an article from caller-supplied HTML
+// Nothing is fetched: the HTTP and browser phases are skipped entirely
+func (h *CloudRunHandler) ExtractHTMLHandler(w http.ResponseWriter, r *http.Request) {
+	h.setCommonHeaders(w, r, "POST,OPTIONS")
+
+	if r.Method == "OPTIONS" {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if r.Method != "POST" {
+		h.errorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	slog.InfoContext(r.Context(), "request received", "method", r.Method, "path", r.URL.Path)
+
+	key, ok := h.requireAPIKey(w, r)
+	if !ok {
+		return
+	}
+
+	req := ExtractHTMLRequest{
+		Options: scraper.DefaultExtractionOptions(),
+	}
+	if err := decodeJSONBodyWithLimit(w, r, &req, maxHTMLRequestBodyBytes); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			h.errorResponse(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("Request body exceeds %d bytes", tooLarge.Limit))
+			return
+		}
+		h.errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if req.HTML == "" {
+		h.errorResponse(w, http.StatusBadRequest, "Missing \"html\" field")
+		return
+	}
+
+	if err := validateTargetURL(req.URL); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := req.Options.Validate(); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, fmt.Sprintf("Invalid options: %v", err))
+		return
+	}
+
+	_, release, ok := h.admit(w, r, key, 1, 1)
+	if !ok {
+		return
+	}
+	defer release()
+
+	writeOutcome(w, h.runExtractHTML(r.Context(), req))
+}
+
+// runExtractHTML extracts an article from supplied HTML and maps the result to an HTTP status and body
+func (h *CloudRunHandler) runExtractHTML(ctx context.Context, req ExtractHTMLRequest) models.ScrapeOutcome {
+	start := time.Now()
+
+	result, err := h.scraper.Load().ExtractFromHTML(ctx, req.HTML, req.URL, req.Options)
+
+	duration := time.Since(start)
+
+	if err != nil {
+		slog.WarnContext(ctx, "extraction from supplied HTML failed", "url", req.URL, "error", err, "duration_ms", duration.Milliseconds())
+
+		var extractErr *models.ContentExtractionError
+		if errors.As(err, &extractErr) {
+			return withFailedScrape(failedOutcome(http.StatusUnprocessableEntity, "No article content found in the supplied HTML", err), result)
+		}
+		return withFailedScrape(failedOutcome(http.StatusInternalServerError, fmt.Sprintf("Failed to extract: %s", sanitizeErrorMessage(err)), err), result)
+	}
+
+	result.Metadata.URL = req.URL
+	result.Metadata.ScrapedAt = time.Now()
+	result.Metadata.DurationMs = duration.Milliseconds()
+
+	return models.ScrapeOutcome{Status: http.StatusOK, Result: &result}
+}
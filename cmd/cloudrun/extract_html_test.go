@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/models"
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/ratelimit"
+)
+
+// relativeArticleHTML links and illustrates its article with URLs relative to the page
+const relativeArticleHTML = `<html><head><title>Relative Article</title></head><body><main><article>
+<h1>Relative Article</h1>
+<img src="/images/hero.jpg" width="800" height="450" alt="Hero">
+<p>This article was supplied as HTML, so its <a href="/about">relative links</a> resolve against the base URL.</p>
+<p>A second paragraph makes sure readability treats the page as a real article with enough text.</p>
+</article></main></body></html>`
+
+func TestExtractHTMLHandler(t *testing.T) {
+	markdown := map[string]any{"outputFormat": "markdown"}
+	tests := []struct {
+		name   string
+		body   map[string]any
+		status int
+	}{
+		{"resolves relative URLs", map[string]any{"html": relativeArticleHTML, "url": "https://news.test/2024/story", "options": markdown}, http.StatusOK},
+		{"missing html", map[string]any{"url": "https://news.test/2024/story"}, http.StatusBadRequest},
+		{"missing base URL", map[string]any{"html": relativeArticleHTML}, http.StatusBadRequest},
+		{"relative base URL", map[string]any{"html": relativeArticleHTML, "url": "/2024/story"}, http.StatusBadRequest},
+		{"non-HTTP base URL", map[string]any{"html": relativeArticleHTML, "url": "ftp://news.test/story"}, http.StatusBadRequest},
+		{"invalid options", map[string]any{"html": relativeArticleHTML, "url": "https://news.test/2024/story", "options": map[string]any{"outputFormat": "pdf"}}, http.StatusBadRequest},
+		{"oversize body", map[string]any{"html": strings.Repeat("a", maxHTMLRequestBodyBytes), "url": "https://news.test/2024/story"}, http.StatusRequestEntityTooLarge},
+		{"no content", map[string]any{"html": "<html><body><div></div></body></html>", "url": "https://news.test/empty"}, http.StatusUnprocessableEntity},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fetcher := &pageFetcher{}
+			h := newBatchHandler(fetcher, ratelimit.Limits{RequestsPerMinute: -1, MaxConcurrent: -1})
+			body, _ := json.Marshal(tt.body)
+			w := httptest.NewRecorder()
+			h.ExtractHTMLHandler(w, httptest.NewRequest("POST", "/v1/extract/html", strings.NewReader(string(body))))
+
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %.200s", w.Code, tt.status, w.Body)
+			}
+			if n := fetcher.fetches.Load(); n != 0 {
+				t.Errorf("fetched %d pages, want none", n)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+
+			var resp models.ScrapeResponse
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(resp.Content, "[relative links](https://news.test/about)") {
+				t.Errorf("content %q does not link to the resolved URL", resp.Content)
+			}
+			if len(resp.Images) != 1 || resp.Images[0].URL != "https://news.test/images/hero.jpg" {
+				t.Errorf("images = %+v, want the resolved hero image", resp.Images)
+			}
+			if resp.Metadata.URL != "https://news.test/2024/story" {
+				t.Errorf("metadata URL = %q, want the base URL", resp.Metadata.URL)
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/models"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// version is the build version, set with -ldflags "-X main.version=..."
+// When unset, the VCS revision recorded by the Go toolchain is reported instead
+var version string
+
+// Readiness check timing
+const (
+	readinessTimeout  = 20 * time.Second // Starting Chrome on a cold container is slow
+	readinessCacheTTL = 30 * time.Second // Probes within this window reuse the last result
+)
+
+// buildVersion returns version, the short VCS revision, or "dev"
+func buildVersion() string {
+	if version != "" {
+		return version
+	}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range info.Settings {
+			if setting.Key == "vcs.revision" && len(setting.Value) >= 12 {
+				return setting.Value[:12]
+			}
+		}
+	}
+	return "dev"
+}
+
+// chromeReadiness runs the Chrome check at most once per readinessCacheTTL, and never twice at once
+type chromeReadiness struct {
+	check func(ctx context.Context) error
+
+	running singleflight.Group
+	mu      sync.Mutex
+	last    *models.ChromeCheck
+}
+
+// result returns the cached check result, starting the check again when it is stale
+// Only the first probe waits for a check; later ones get the last result while it is refreshed
+func (c *chromeReadiness) result(ctx context.Context) models.ChromeCheck {
+	c.mu.Lock()
+	last := c.last
+	c.mu.Unlock()
+
+	if last != nil && time.Since(last.CheckedAt) < readinessCacheTTL {
+		return *last
+	}
+
+	// The check outlives the probe that started it, so a probe giving up does not fail the others
+	checkCtx := context.WithoutCancel(ctx)
+	done := c.running.DoChan("chrome", func() (any, error) {
+		return c.run(checkCtx), nil
+	})
+	if last != nil {
+		return *last
+	}
+	select {
+	case res := <-done:
+		return res.Val.(models.ChromeCheck)
+	case <-ctx.Done():
+		return models.ChromeCheck{Error: "Chrome check still running", CheckedAt: time.Now()}
+	}
+}
+
+// run checks Chrome and caches the result
+func (c *chromeReadiness) run(ctx context.Context) models.ChromeCheck {
+	ctx, cancel := context.WithTimeout(ctx, readinessTimeout)
+	defer cancel()
+
+	start := time.Now()
+	err := c.check(ctx)
+	result := models.ChromeCheck{
+		OK:         err == nil,
+		DurationMs: time.Since(start).Milliseconds(),
+		CheckedAt:  time.Now(),
+	}
+	if err != nil {
+		result.Error = err.Error()
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.last = &result
+	return result
+}
+
+// limits reports the limits the handler was configured with
+func (h *CloudRunHandler) limits() *models.ServiceLimits {
+	scrape := h.scraper.Load().Settings().Scrape
+	return &models.ServiceLimits{
+		DefaultTimeoutMs:    clampTimeout(defaultTimeoutMs),
+		MaxTimeoutMs:        maxTimeoutMs,
+		DefaultPageBytes:    scrape.SizeLimitBytes,
+		MaxPageBytes:        scrape.MaxSizeLimitBytes,
+		MaxRequestBodyBytes: maxRequestBodyBytes,
+		MaxHTMLBodyBytes:    maxHTMLRequestBodyBytes,
+		BatchMaxURLs:        h.batchMaxURLs,
+		BatchMaxConcurrency: h.batchMaxConcurrency,
+		JobsWorkers:         h.jobsConfig.Workers,
+		JobsMaxQueued:       h.jobsConfig.MaxQueued,
+	}
+}
+
+// HealthzHandler serves GET /healthz, reporting that the process is alive
+func (h *CloudRunHandler) HealthzHandler(w http.ResponseWriter, r *http.Request) {
+	h.setCommonHeaders(w, r, "GET")
+	if r.Method != "GET" && r.Method != "HEAD" {
+		h.errorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, models.HealthResponse{
+		Status:  "ok",
+		Version: buildVersion(),
+	})
+}
+
+// ReadyzHandler serves GET /readyz, verifying that Chrome can start and render a page
+// It responds 503 when Chrome is unavailable so traffic is not routed to a broken instance
+func (h *CloudRunHandler) ReadyzHandler(w http.ResponseWriter, r *http.Request) {
+	h.setCommonHeaders(w, r, "GET")
+	if r.Method != "GET" && r.Method != "HEAD" {
+		h.errorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	chrome := h.readiness.result(r.Context())
+	response := models.HealthResponse{
+		Status:  "ok",
+		Version: buildVersion(),
+		Chrome:  &chrome,
+		Limits:  h.limits(),
+	}
+
+	status := http.StatusOK
+	if !chrome.OK {
+		response.Status = "unavailable"
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(w, status, response)
+}
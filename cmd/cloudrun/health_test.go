@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestChromeReadinessSharesOneCheck(t *testing.T) {
+	var calls atomic.Int32
+	unblock := make(chan struct{})
+	c := &chromeReadiness{check: func(ctx context.Context) error {
+		calls.Add(1)
+		<-unblock
+		return nil
+	}}
+
+	// Probes arriving during the first check wait for it rather than starting their own
+	var wg sync.WaitGroup
+	results := make(chan bool, 5)
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results <- c.result(context.Background()).OK
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(unblock)
+	wg.Wait()
+	close(results)
+	for ok := range results {
+		if !ok {
+			t.Error("probe did not get the successful check")
+		}
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("check ran %d times, want once", n)
+	}
+
+	// A stale result is served at once while a single refresh runs
+	c.mu.Lock()
+	c.last.CheckedAt = time.Now().Add(-2 * readinessCacheTTL)
+	c.mu.Unlock()
+	refreshed := make(chan struct{})
+	c.check = func(ctx context.Context) error {
+		calls.Add(1)
+		<-refreshed
+		return errors.New("chrome gone")
+	}
+	for i := 0; i < 3; i++ {
+		if !c.result(context.Background()).OK {
+			t.Fatal("probe waited for the refresh instead of getting the last result")
+		}
+	}
+	close(refreshed)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for c.result(context.Background()).OK {
+		if time.Now().After(deadline) {
+			t.Fatal("refreshed result never served")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if n := calls.Load(); n != 2 {
+		t.Errorf("check ran %d times, want twice", n)
+	}
+}
+
+func TestChromeReadinessProbeGivesUp(t *testing.T) {
+	unblock := make(chan struct{})
+	defer close(unblock)
+	c := &chromeReadiness{check: func(ctx context.Context) error {
+		<-unblock
+		return nil
+	}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if result := c.result(ctx); result.OK || result.Error == "" {
+		t.Errorf("result = %+v, want a failure while the first check runs", result)
+	}
+}
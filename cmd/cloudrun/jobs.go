@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/jobs"
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/logging"
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/models"
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/tracing"
+)
+
+// JobRequest is the JSON body accepted by POST /v1/jobs
+// The idempotency key may also be sent in the Idempotency-Key header
+type JobRequest struct {
+	ExtractRequest
+	CallbackURL    string `json:"callbackUrl,omitempty"`
+	IdempotencyKey string `json:"idempotencyKey,omitempty"`
+}
+
+// JobsHandler serves POST /v1/jobs, queueing a scrape and returning its job immediately
+func (h *CloudRunHandler) JobsHandler(w http.ResponseWriter, r *http.Request) {
+	h.setCommonHeaders(w, r, "POST,OPTIONS")
+
+	if r.Method == "OPTIONS" {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if r.Method != "POST" {
+		h.errorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	slog.InfoContext(r.Context(), "request received", "method", r.Method, "path", r.URL.Path)
+
+	key, ok := h.requireAPIKey(w, r)
+	if !ok {
+		return
+	}
+
+	req := JobRequest{
+		ExtractRequest: h.defaultExtractRequest(),
+	}
+	if err := decodeJSONBody(w, r, &req); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if headerKey := r.Header.Get("Idempotency-Key"); headerKey != "" {
+		req.IdempotencyKey = headerKey
+	}
+
+	if err := validateTargetURL(req.URL); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if req.CallbackURL != "" {
+		if err := validateTargetURL(req.CallbackURL); err != nil {
+			h.errorResponse(w, http.StatusBadRequest, "Invalid callbackUrl format")
+			return
+		}
+		if err := h.jobs.CheckCallbackURL(r.Context(), req.CallbackURL); err != nil {
+			h.errorResponse(w, http.StatusBadRequest, fmt.Sprintf("Invalid callbackUrl: %v", err))
+			return
+		}
+	}
+	if err := req.Options.Validate(); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, fmt.Sprintf("Invalid options: %v", err))
+		return
+	}
+
+	// A replayed idempotent request reports its job without being charged again
+	requestFingerprint := fingerprint(req)
+	existing, found, err := h.jobs.Replay(key.ID, req.IdempotencyKey, requestFingerprint)
+	if errors.Is(err, jobs.ErrIdempotencyMismatch) {
+		h.errorResponse(w, http.StatusConflict, "Idempotency key was already used with a different request")
+		return
+	}
+	if found {
+		w.Header().Set("Location", "/v1/jobs/"+existing.ID)
+		writeJSON(w, http.StatusOK, existing)
+		return
+	}
+
+	// Jobs are charged against the rate limit when submitted, and hold one of the key's concurrent
+	// scrape slots from then until they finish, so queued jobs count like scrapes in flight
+	_, release, ok := h.admit(w, r, key, 1, 1)
+	if !ok {
+		return
+	}
+
+	extractReq := req.ExtractRequest
+	// Jobs run after the request returns; keep its ID and trace so the job's logs and scrape stay correlated
+	jobCtx := tracing.WithSpanContext(logging.WithRequestID(context.Background(), logging.RequestID(r.Context())),
+		tracing.SpanContextFromContext(r.Context()))
+	job, created, err := h.jobs.Submit(jobs.Spec{
+		Context:        jobCtx,
+		URL:            req.URL,
+		CallbackURL:    req.CallbackURL,
+		Owner:          key.ID,
+		IdempotencyKey: req.IdempotencyKey,
+		Fingerprint:    requestFingerprint,
+		Run: func(ctx context.Context) models.ScrapeOutcome {
+			defer release()
+			return h.runScrape(ctx, key, extractReq.URL, extractReq.Timeout, extractReq.Options)
+		},
+	})
+	if !created {
+		release()
+	}
+	switch {
+	case errors.Is(err, jobs.ErrIdempotencyMismatch):
+		h.errorResponse(w, http.StatusConflict, "Idempotency key was already used with a different request")
+		return
+	case errors.Is(err, jobs.ErrQueueFull):
+		w.Header().Set("Retry-After", "30")
+		h.errorResponse(w, http.StatusServiceUnavailable, "Job queue is full, retry later")
+		return
+	case err != nil:
+		h.errorResponse(w, http.StatusServiceUnavailable, "Job service unavailable")
+		return
+	}
+
+	w.Header().Set("Location", "/v1/jobs/"+job.ID)
+	if !created {
+		// Replayed idempotent request: report the existing job
+		writeJSON(w, http.StatusOK, job)
+		return
+	}
+	writeJSON(w, http.StatusAccepted, job)
+}
+
+// JobHandler serves GET /v1/jobs/{id}, reporting job state and, once done, its result
+func (h *CloudRunHandler) JobHandler(w http.ResponseWriter, r *http.Request) {
+	h.setCommonHeaders(w, r, "GET,OPTIONS")
+
+	if r.Method == "OPTIONS" {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if r.Method != "GET" {
+		h.errorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	key, ok := h.requireAPIKey(w, r)
+	if !ok {
+		return
+	}
+
+	id := strings.TrimPrefix(r.URL.Path, "/v1/jobs/")
+	if id == "" || strings.Contains(id, "/") {
+		h.errorResponse(w, http.StatusNotFound, "Job not found")
+		return
+	}
+
+	job, ok := h.jobs.Get(id, key.ID)
+	if !ok {
+		h.errorResponse(w, http.StatusNotFound, "Job not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, job)
+}
+
+// fingerprint identifies a job request so reused idempotency keys can be checked
+func fingerprint(req JobRequest) string {
+	req.IdempotencyKey = ""
+	data, _ := json.Marshal(req)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
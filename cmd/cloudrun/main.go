@@ -6,113 +6,109 @@ package main
 
 import (
 	"context"
-	"crypto/subtle"
 	"encoding/json"
 	"fmt"
+	"log/slog"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
 	"strconv"
 	"strings"
-	"sync"
+	"sync/atomic"
 	"time"
 
-	"extract-html-scraper/internal/models"
-	"extract-html-scraper/internal/scraper"
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/auth"
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/config"
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/jobs"
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/logging"
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/metrics"
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/models"
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/ratelimit"
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/scraper"
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/tracing"
 )
 
 // CloudRunHandler handles Google Cloud Run requests
 type CloudRunHandler struct {
-	scraper  *scraper.Scraper
-	apiKeys  []string
-	keysLock sync.RWMutex
-}
+	scraper atomic.Pointer[scraper.Scraper] // Replaced when the configuration is reloaded
+	browser *scraper.BrowserClient          // Shared by every scraper so Chrome survives reloads
+	cache   *resultCache                    // Shared by every scraper; nil when caching is disabled
+	keys    *auth.Store                     // nil when authentication is disabled
 
-func NewCloudRunHandler() *CloudRunHandler {
-	handler := &CloudRunHandler{
-		scraper: scraper.NewScraper(),
-	}
+	allowQueryKey bool // Whether the API key may be passed in the key query parameter
+	cors          *corsPolicy
 
-	// Load API keys on initialization
-	handler.loadAPIKeys()
+	batchMaxURLs        int
+	batchMaxConcurrency int
 
-	return handler
+	limiter *ratelimit.Limiter
+
+	jobs       *jobs.Manager
+	jobsConfig jobs.Config
+
+	readiness *chromeReadiness
 }
 
-// loadAPIKeys loads API keys from environment variable or Secret Manager
-func (h *CloudRunHandler) loadAPIKeys() {
-	h.keysLock.Lock()
-	defer h.keysLock.Unlock()
-
-	// Try Secret Manager first if configured
-	secretName := os.Getenv("SCRAPER_API_KEY_SECRET")
-	if secretName != "" {
-		if keys, err := loadKeysFromSecretManager(secretName); err == nil && len(keys) > 0 {
-			h.apiKeys = keys
-			fmt.Printf("Loaded %d API key(s) from Secret Manager (secret: %s)\n", len(keys), secretName)
-			return
-		}
-		fmt.Printf("Warning: Failed to load from Secret Manager, falling back to environment variable\n")
-	}
+// NewCloudRunHandler creates the handler, scraping with settings until they are reloaded
+func NewCloudRunHandler(settings *config.Settings) *CloudRunHandler {
+	browser := scraper.NewBrowserClientWithConfig(settings.Scrape, scraper.OptimizedBrowserOptions())
+	jobsConfig := loadJobsConfig()
 
-	// Fall back to environment variable
-	keysStr := os.Getenv("SCRAPER_API_KEYS")
-	if keysStr == "" {
-		fmt.Printf("Warning: No API keys configured (SCRAPER_API_KEYS not set)\n")
-		h.apiKeys = []string{}
-		return
+	handler := &CloudRunHandler{
+		browser:             browser,
+		cache:               loadResultCache(),
+		batchMaxURLs:        envInt("BATCH_MAX_URLS", defaultBatchMaxURLs),
+		batchMaxConcurrency: envInt("BATCH_MAX_CONCURRENCY", defaultBatchMaxConcurrency),
+		limiter:             ratelimit.NewLimiter(loadRateLimits()),
+		allowQueryKey:       os.Getenv("SCRAPER_ALLOW_QUERY_KEY") != "false",
+		cors:                loadCORSPolicy(),
+		jobs:                jobs.NewManager(jobsConfig),
+		jobsConfig:          jobsConfig,
+		readiness:           &chromeReadiness{check: browser.CheckChrome},
 	}
 
-	// Parse comma-separated keys
-	keys := strings.Split(keysStr, ",")
-	h.apiKeys = make([]string, 0, len(keys))
-	for _, key := range keys {
-		key = strings.TrimSpace(key)
-		if key != "" {
-			h.apiKeys = append(h.apiKeys, key)
-		}
-	}
+	handler.applySettings(settings)
 
-	fmt.Printf("Loaded %d API key(s) from environment variable\n", len(h.apiKeys))
-}
+	// Load API keys on initialization
+	handler.keys = loadKeyStore()
 
-// loadKeysFromSecretManager loads API keys from Google Secret Manager
-// Returns error if Secret Manager is not available or secret doesn't exist
-func loadKeysFromSecretManager(secretName string) ([]string, error) {
-	// For now, return error to indicate Secret Manager is not implemented
-	// This can be enhanced later with the secretmanager client library
-	// The structure is here to support future enhancement
-	return nil, fmt.Errorf("Secret Manager integration not implemented - use SCRAPER_API_KEYS environment variable")
+	return handler
 }
 
-// validateAPIKey validates the API key from the request against configured keys
-// Uses constant-time comparison to prevent timing attacks
-func (h *CloudRunHandler) validateAPIKey(requestKey string) bool {
-	h.keysLock.RLock()
-	defer h.keysLock.RUnlock()
-
-	// If no keys configured, allow all requests (development mode)
-	if len(h.apiKeys) == 0 {
-		return true
+// envInt reads a positive integer from the environment, falling back to def
+func envInt(name string, def int) int {
+	if value := os.Getenv(name); value != "" {
+		if parsed, err := strconv.Atoi(value); err == nil && parsed > 0 {
+			return parsed
+		}
+		slog.Warn("ignoring invalid environment variable", "name", name, "value", value, "default", def)
 	}
+	return def
+}
 
-	// Constant-time comparison against all valid keys
-	for _, validKey := range h.apiKeys {
-		if subtle.ConstantTimeCompare([]byte(requestKey), []byte(validKey)) == 1 {
-			return true
+// loadJobsConfig reads the async job settings from the environment
+func loadJobsConfig() jobs.Config {
+	cfg := jobs.DefaultConfig()
+	cfg.Workers = envInt("JOBS_WORKERS", cfg.Workers)
+	cfg.MaxQueued = envInt("JOBS_MAX_QUEUED", cfg.MaxQueued)
+	if value := os.Getenv("JOBS_RETENTION"); value != "" {
+		if parsed, err := time.ParseDuration(value); err == nil && parsed > 0 {
+			cfg.Retention = parsed
+		} else {
+			slog.Warn("ignoring invalid environment variable", "name", "JOBS_RETENTION", "value", value, "default", cfg.Retention)
 		}
 	}
-
-	return false
+	cfg.CallbackSecret = os.Getenv("JOBS_CALLBACK_SECRET")
+	cfg.AllowPrivateCallbacks = os.Getenv("JOBS_ALLOW_PRIVATE_CALLBACKS") == "true"
+	return cfg
 }
 
 // Handler is the main Cloud Run handler function
+// It serves the original GET /?url=&key=&timeout=&maxAge=&maxBytes= API, kept as a compatibility
+// alias for POST /v1/extract with the extraction options it always had (LegacyExtractionOptions)
 func (h *CloudRunHandler) Handler(w http.ResponseWriter, r *http.Request) {
-	// Set up CORS headers
-	w.Header().Set("Content-Type", "application/json; charset=utf-8")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-	w.Header().Set("Access-Control-Allow-Methods", "GET,OPTIONS")
+	h.setCommonHeaders(w, r, "GET,OPTIONS")
 
 	// Handle preflight OPTIONS request
 	if r.Method == "OPTIONS" {
@@ -126,13 +122,12 @@ func (h *CloudRunHandler) Handler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Log the request
-	fmt.Printf("Request received: %s %s\n", r.Method, r.URL.String())
+	// Log the request; the query string is left out because it carries the API key
+	slog.InfoContext(r.Context(), "request received", "method", r.Method, "path", r.URL.Path)
 
 	// Validate API key
-	requestKey := r.URL.Query().Get("key")
-	if !h.validateAPIKey(requestKey) {
-		h.errorResponse(w, http.StatusUnauthorized, "Invalid or missing API key")
+	key, ok := h.requireAPIKey(w, r)
+	if !ok {
 		return
 	}
 
@@ -149,88 +144,40 @@ func (h *CloudRunHandler) Handler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	fmt.Printf("Starting scrape for: %s\n", targetURL)
-
 	// Calculate timeout (Cloud Run has 5 minute max)
-	timeoutStr := r.URL.Query().Get("timeout")
-	timeoutMs := 300000 // Default 5 minutes
-	if timeoutStr != "" {
+	timeoutMs := defaultTimeoutMs
+	if timeoutStr := r.URL.Query().Get("timeout"); timeoutStr != "" {
 		if parsedTimeout, err := strconv.Atoi(timeoutStr); err == nil {
 			timeoutMs = parsedTimeout
 		}
 	}
 
-	// Cap at 4 minutes (240 seconds) to be safe
-	if timeoutMs > 240000 {
-		timeoutMs = 240000
-	}
-	if timeoutMs < 1000 {
-		timeoutMs = 1000
-	}
-
-	// Create context with timeout
-	ctx, cancel := context.WithTimeout(r.Context(), time.Duration(timeoutMs)*time.Millisecond)
-	defer cancel()
-
-	fmt.Printf("Request timeout: %dms\n", timeoutMs)
-
-	start := time.Now()
-
-	// Perform scraping
-	result, err := h.scraper.ScrapeSmartWithTimeout(ctx, targetURL, timeoutMs)
-
-	duration := time.Since(start)
-	fmt.Printf("✓ Scraped in %dms\n", duration.Milliseconds())
-
-	// Handle Cloudflare blocking
-	if cfErr, ok := err.(*models.CloudflareBlockError); ok {
-		blockedResponse := models.BlockedResponse{
-			Error:    "Blocked by site protection",
-			Provider: "cloudflare",
-			Domain:   cfErr.Domain,
-			Metadata: models.Metadata{
-				URL:        targetURL,
-				ScrapedAt:  time.Now(),
-				DurationMs: duration.Milliseconds(),
-			},
+	options := scraper.LegacyExtractionOptions()
+	options.Debug = r.URL.Query().Get("debug") == "true"
+	if maxAgeStr := r.URL.Query().Get("maxAge"); maxAgeStr != "" {
+		maxAge, err := strconv.Atoi(maxAgeStr)
+		if err != nil || maxAge < 0 {
+			h.errorResponse(w, http.StatusBadRequest, "Invalid \"maxAge\" query parameter")
+			return
 		}
-
-		w.WriteHeader(http.StatusUnavailableForLegalReasons)
-		json.NewEncoder(w).Encode(blockedResponse)
-		return
+		options.MaxAge = &maxAge
 	}
-
-	// Handle timeout
-	if err != nil && strings.Contains(err.Error(), "context deadline exceeded") {
-		fmt.Printf("Error: Scraping timeout after %dms for URL: %s\n", duration.Milliseconds(), targetURL)
-		h.errorResponse(w, http.StatusGatewayTimeout, "Scrape took too long")
-		return
+	if maxBytesStr := r.URL.Query().Get("maxBytes"); maxBytesStr != "" {
+		maxBytes, err := strconv.Atoi(maxBytesStr)
+		if err != nil || maxBytes < 0 {
+			h.errorResponse(w, http.StatusBadRequest, "Invalid \"maxBytes\" query parameter")
+			return
+		}
+		options.MaxBytes = maxBytes
 	}
 
-	// Handle other errors
-	if err != nil {
-		// Log full error details for debugging
-		fmt.Printf("Error processing request for URL: %s\n", targetURL)
-		fmt.Printf("Error type: %T\n", err)
-		fmt.Printf("Error message: %v\n", err)
-		fmt.Printf("Request timeout was: %dms, actual duration: %dms\n", timeoutMs, duration.Milliseconds())
-
-		// Create sanitized error message for response
-		errorMsg := sanitizeErrorMessage(err)
-		finalMsg := fmt.Sprintf("Failed to scrape: %s", errorMsg)
-
-		h.errorResponse(w, http.StatusInternalServerError, finalMsg)
+	_, release, ok := h.admit(w, r, key, 1, 1)
+	if !ok {
 		return
 	}
+	defer release()
 
-	// Add metadata to successful response
-	result.Metadata.URL = targetURL
-	result.Metadata.ScrapedAt = time.Now()
-	result.Metadata.DurationMs = duration.Milliseconds()
-
-	// Return successful response
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(result)
+	writeOutcome(w, h.runScrape(r.Context(), key, targetURL, timeoutMs, options))
 }
 
 // sanitizeErrorMessage sanitizes error messages for public responses
@@ -258,21 +205,26 @@ func sanitizeErrorMessage(err error) string {
 	errorMsg = strings.ReplaceAll(errorMsg, "/app/", "")
 	errorMsg = strings.ReplaceAll(errorMsg, "/tmp/", "")
 
-	// Extract key error types
-	if strings.Contains(errorMsg, "context deadline exceeded") || strings.Contains(errorMsg, "timeout") {
+	// Describe known failures by their error code
+	switch models.ErrorCode(err) {
+	case models.CodeTimeout:
 		return "timeout: request took too long"
-	}
-	if strings.Contains(errorMsg, "HTTP 403") || strings.Contains(errorMsg, "403") {
+	case models.CodeUpstreamForbidden:
 		return "access denied: site blocked the request"
-	}
-	if strings.Contains(errorMsg, "HTTP 404") || strings.Contains(errorMsg, "404") {
+	case models.CodeUpstreamNotFound:
 		return "not found: URL does not exist"
-	}
-	if strings.Contains(errorMsg, "network") || strings.Contains(errorMsg, "connection") {
+	case models.CodeUpstreamRateLimited:
+		return "rate limited: site is throttling requests"
+	case models.CodeUpstreamServerError:
+		return "upstream error: site returned a server error"
+	case models.CodeUpstreamUnreachable:
 		return "network error: could not connect to target site"
-	}
-	if strings.Contains(errorMsg, "all URLs failed") || strings.Contains(errorMsg, "all alternate URLs") {
-		return "all scraping attempts failed"
+	case models.CodeNonHTML:
+		return "not HTML: URL does not serve a web page"
+	case models.CodeDisallowedByRobots:
+		return "disallowed by the site's robots.txt"
+	case models.CodeExtractionEmpty:
+		return "no article content found"
 	}
 
 	// Truncate to 200 chars for generic errors
@@ -287,26 +239,106 @@ func sanitizeErrorMessage(err error) string {
 func (h *CloudRunHandler) errorResponse(w http.ResponseWriter, statusCode int, message string) {
 	errorResp := models.ErrorResponse{
 		Error: message,
+		Code:  codeForStatus(statusCode),
+	}
+
+	writeJSON(w, statusCode, errorResp)
+}
+
+// codeForStatus returns the error code for a failure the handlers detect themselves
+func codeForStatus(statusCode int) string {
+	switch statusCode {
+	case http.StatusBadRequest:
+		return models.CodeInvalidRequest
+	case http.StatusUnauthorized:
+		return models.CodeUnauthorized
+	case http.StatusNotFound:
+		return models.CodeNotFound
+	case http.StatusMethodNotAllowed:
+		return models.CodeMethodNotAllowed
+	case http.StatusConflict:
+		return models.CodeConflict
+	case http.StatusRequestEntityTooLarge:
+		return models.CodeRequestTooLarge
+	case http.StatusTooManyRequests:
+		return models.CodeRateLimited
+	case http.StatusUnprocessableEntity:
+		return models.CodeExtractionEmpty
+	case http.StatusUnavailableForLegalReasons:
+		return models.CodeBlocked
+	case http.StatusServiceUnavailable:
+		return models.CodeUnavailable
+	case http.StatusGatewayTimeout:
+		return models.CodeTimeout
 	}
+	return models.CodeInternal
+}
 
+// writeJSON writes a JSON body with the given status code
+func writeJSON(w http.ResponseWriter, statusCode int, body interface{}) {
 	w.WriteHeader(statusCode)
-	json.NewEncoder(w).Encode(errorResp)
+	json.NewEncoder(w).Encode(body)
 }
 
 // main function
 func main() {
-	handler := NewCloudRunHandler()
+	slog.SetDefault(logging.FromEnv())
+
+	if err := run(); err != nil {
+		slog.Error("server failed", "error", err)
+		os.Exit(1)
+	}
+}
+
+// run configures and serves until shutdown; it returns rather than exiting so its deferred
+// calls, such as flushing buffered spans, also run when startup fails
+func run() error {
+	provider, err := tracing.FromEnv(os.Stdout)
+	if err != nil {
+		return fmt.Errorf("invalid tracing configuration: %w", err)
+	}
+	if provider != nil {
+		tracing.SetProvider(provider)
+		defer provider.Shutdown(context.Background())
+	}
+
+	// CONFIG_FILE is optional; without it the built-in settings and environment overrides apply
+	configFile := os.Getenv("CONFIG_FILE")
+	settings, err := config.Load(configFile)
+	if err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	handler := NewCloudRunHandler(settings)
+	handler.watchKeys(context.Background())
+	if configFile != "" {
+		handler.watchConfig(context.Background(), configFile)
+	}
 
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "8080"
 	}
 
-	fmt.Printf("Starting server on port %s\n", port)
+	slog.Info("starting server", "port", port, "version", buildVersion())
+	http.HandleFunc("/v1/extract", handler.ExtractHandler)
+	http.HandleFunc("/v1/extract/html", handler.ExtractHTMLHandler)
+	http.HandleFunc("/v1/batch", handler.BatchHandler)
+	http.HandleFunc("/v1/jobs", handler.JobsHandler)
+	http.HandleFunc("/v1/jobs/", handler.JobHandler)
+	http.Handle("/metrics", metrics.Default.Handler())
+	http.HandleFunc("/healthz", handler.HealthzHandler)
+	http.HandleFunc("/readyz", handler.ReadyzHandler)
 	http.HandleFunc("/", handler.Handler)
 
-	if err := http.ListenAndServe(":"+port, nil); err != nil {
-		fmt.Printf("Server failed to start: %v\n", err)
-		os.Exit(1)
+	// Requests derive their context from requestCtx so shutdown can cancel the ones that outlast the grace period
+	requestCtx, cancelRequests := context.WithCancel(context.Background())
+	server := &http.Server{
+		Addr:        ":" + port,
+		Handler:     withRequestID(withTracing(http.DefaultServeMux)),
+		BaseContext: func(net.Listener) context.Context { return requestCtx },
 	}
+
+	grace := time.Duration(envInt("SHUTDOWN_GRACE_SECONDS", defaultShutdownGraceSeconds)) * time.Second
+	return runServer(server, handler, cancelRequests, grace)
 }
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/models"
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/ratelimit"
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/scraper"
+)
+
+// htmlFetcher serves the same page for every URL
+type htmlFetcher struct {
+	html string
+}
+
+func (f htmlFetcher) Name() string {
+	return "http"
+}
+
+func (f htmlFetcher) Fetch(ctx context.Context, targetURL string) (scraper.FetchResult, error) {
+	return scraper.FetchResult{HTML: f.html, FinalURL: targetURL}, nil
+}
+
+// shortArticleHTML has an article readability finds, shorter than the default minTextLength
+const shortArticleHTML = `<html><head><title>Short Story</title></head><body><article><h1>Short Story</h1>
+<p>A short article body that readability still finds, well under a hundred chars.</p>
+</article></body></html>`
+
+// commentedArticleHTML has a reader comment section inside its article
+const commentedArticleHTML = `<html><head><title>Short Story</title></head><body><article><h1>Short Story</h1>
+<p>A short article body that readability still finds, well under a hundred chars.</p>
+<div id="comments"><p>Reader comment: comment sections are part of what GET / returns.</p></div>
+</article></body></html>`
+
+func TestHandlerKeepsLegacyExtractionDefaults(t *testing.T) {
+	tests := []struct {
+		name       string
+		html       string
+		v1         bool // POST /v1/extract with default options, instead of GET /
+		strategies []string
+		comments   bool
+	}{
+		{"GET / skips the simple strategy for short content", shortArticleHTML, false, []string{"readability"}, false},
+		{"v1 runs it below minTextLength", shortArticleHTML, true, []string{"readability", "simple"}, false},
+		{"GET / keeps comment sections", commentedArticleHTML, false, []string{"readability"}, true},
+		{"v1 strips them", commentedArticleHTML, true, []string{"readability", "simple"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newBatchHandler(htmlFetcher{tt.html}, ratelimit.Limits{RequestsPerMinute: -1, MaxConcurrent: -1})
+			// Distinct URLs, so no result is shared between cases
+			targetURL := "https://legacy.test/" + url.PathEscape(tt.name)
+
+			w := httptest.NewRecorder()
+			if tt.v1 {
+				body, _ := json.Marshal(map[string]any{"url": targetURL, "options": map[string]any{"debug": true}})
+				h.ExtractHandler(w, httptest.NewRequest("POST", "/v1/extract", strings.NewReader(string(body))))
+			} else {
+				h.Handler(w, httptest.NewRequest("GET", "/?debug=true&url="+url.QueryEscape(targetURL), nil))
+			}
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", w.Code, w.Body)
+			}
+
+			var resp models.ScrapeResponse
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatal(err)
+			}
+			if resp.Debug == nil || len(resp.Debug.Phases) != 1 {
+				t.Fatalf("debug = %+v, want one fetch phase", resp.Debug)
+			}
+			var strategies []string
+			for _, s := range resp.Debug.Phases[0].Strategies {
+				strategies = append(strategies, s.Strategy)
+			}
+			if strings.Join(strategies, ",") != strings.Join(tt.strategies, ",") {
+				t.Errorf("strategies = %v, want %v", strategies, tt.strategies)
+			}
+			if got := strings.Contains(resp.Content, "Reader comment"); got != tt.comments {
+				t.Errorf("content %q: comments kept = %v, want %v", resp.Content, got, tt.comments)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/auth"
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/metrics"
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/ratelimit"
+)
+
+// Default per-key limits, overridable with RATE_LIMIT_PER_MINUTE, RATE_LIMIT_BURST and
+// RATE_LIMIT_MAX_CONCURRENT, and per key in the key file
+const (
+	defaultRateLimitPerMinute  = 60
+	defaultRateLimitBurst      = 10
+	defaultRateLimitConcurrent = 2
+)
+
+// loadRateLimits reads the default per-key limits from the environment
+func loadRateLimits() ratelimit.Limits {
+	return ratelimit.Limits{
+		RequestsPerMinute: envLimit("RATE_LIMIT_PER_MINUTE", defaultRateLimitPerMinute),
+		Burst:             envLimit("RATE_LIMIT_BURST", defaultRateLimitBurst),
+		MaxConcurrent:     envLimit("RATE_LIMIT_MAX_CONCURRENT", defaultRateLimitConcurrent),
+	}
+}
+
+// envLimit reads a positive limit, or -1 for no limit, from the environment, falling back to def
+func envLimit(name string, def int) int {
+	if value := os.Getenv(name); value != "" {
+		if parsed, err := strconv.Atoi(value); err == nil && (parsed > 0 || parsed == -1) {
+			return parsed
+		}
+		slog.Warn("ignoring invalid environment variable", "name", name, "value", value, "default", def)
+	}
+	return def
+}
+
+// admit applies the key's rate limit, charging cost requests, and reserves up to want concurrent
+// scrape slots (none when want is 0). It returns the number of slots granted and a function
+// releasing them, or writes a 429 response and returns false
+func (h *CloudRunHandler) admit(w http.ResponseWriter, r *http.Request, key *auth.Key, cost, want int) (int, func(), bool) {
+	limits := key.RateLimits()
+
+	granted, release := 0, func() {}
+	if want > 0 {
+		granted, release = h.limiter.Acquire(key.ID, limits, want)
+		if granted == 0 {
+			metrics.RateLimitRejections.Inc(key.ID, "concurrency")
+			slog.WarnContext(r.Context(), "too many concurrent scrapes", "key_id", key.ID)
+			w.Header().Set("Retry-After", "1")
+			h.errorResponse(w, http.StatusTooManyRequests,
+				fmt.Sprintf("Too many concurrent scrapes for this API key (max %d)", h.limiter.Resolve(limits).MaxConcurrent))
+			return 0, nil, false
+		}
+	}
+
+	decision := h.limiter.Allow(key.ID, limits, cost)
+	setRateLimitHeaders(w, decision)
+	if !decision.Allowed {
+		release()
+		metrics.RateLimitRejections.Inc(key.ID, "rate")
+		slog.WarnContext(r.Context(), "rate limit exceeded", "key_id", key.ID, "retry_after", decision.RetryAfter)
+		w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(decision.RetryAfter)))
+		h.errorResponse(w, http.StatusTooManyRequests, "Rate limit exceeded for this API key")
+		return 0, nil, false
+	}
+	return granted, release, true
+}
+
+// setRateLimitHeaders reports the key's bucket: its size, what is left and when it is full again
+func setRateLimitHeaders(w http.ResponseWriter, decision ratelimit.Decision) {
+	if decision.Unlimited {
+		return
+	}
+	w.Header().Set("X-RateLimit-Limit", strconv.Itoa(decision.Limit))
+	w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(decision.Remaining))
+	w.Header().Set("X-RateLimit-Reset", strconv.Itoa(ceilSeconds(decision.Reset)))
+}
+
+// ceilSeconds rounds d up to whole seconds, and at least 1 when d is positive
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/auth"
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/models"
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/ratelimit"
+)
+
+func TestAdmit(t *testing.T) {
+	// Refilled at one request a minute, so no token comes back while the test runs
+	h := &CloudRunHandler{limiter: ratelimit.NewLimiter(ratelimit.Limits{RequestsPerMinute: 1, Burst: 2, MaxConcurrent: 1})}
+	key := &auth.Key{ID: "acme"}
+	unlimited := &auth.Key{ID: "internal", Limits: &ratelimit.Limits{RequestsPerMinute: -1, MaxConcurrent: -1}}
+
+	var releases []func()
+	defer func() {
+		for _, release := range releases {
+			release()
+		}
+	}()
+
+	tests := []struct {
+		name       string
+		key        *auth.Key
+		want       int // Concurrent slots asked for
+		status     int // 0 when admitted
+		headers    map[string]string
+		retryAfter bool
+	}{
+		{"first request", key, 0, 0, map[string]string{"X-RateLimit-Limit": "2", "X-RateLimit-Remaining": "1", "X-RateLimit-Reset": "60"}, false},
+		{"takes the only slot", key, 1, 0, map[string]string{"X-RateLimit-Remaining": "0"}, false},
+		{"no slot left", key, 1, http.StatusTooManyRequests, map[string]string{"X-RateLimit-Limit": ""}, true},
+		{"bucket empty", key, 0, http.StatusTooManyRequests, map[string]string{"X-RateLimit-Limit": "2", "X-RateLimit-Remaining": "0"}, true},
+		{"unlimited key", unlimited, 5, 0, map[string]string{"X-RateLimit-Limit": ""}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest("POST", "/v1/extract", nil)
+			granted, release, ok := h.admit(w, r, tt.key, 1, tt.want)
+			if ok {
+				releases = append(releases, release)
+			}
+
+			if admitted := tt.status == 0; ok != admitted {
+				t.Fatalf("admitted = %v, want %v", ok, admitted)
+			}
+			if ok && granted != tt.want {
+				t.Errorf("granted %d slots, want %d", granted, tt.want)
+			}
+			if !ok {
+				if w.Code != tt.status {
+					t.Errorf("status = %d, want %d", w.Code, tt.status)
+				}
+				var body models.ErrorResponse
+				if err := json.NewDecoder(w.Body).Decode(&body); err != nil || body.Code != models.CodeRateLimited {
+					t.Errorf("body code = %q (%v), want %q", body.Code, err, models.CodeRateLimited)
+				}
+			}
+			for name, want := range tt.headers {
+				if got := w.Header().Get(name); got != want {
+					t.Errorf("%s = %q, want %q", name, got, want)
+				}
+			}
+			if got := w.Header().Get("Retry-After"); (got != "") != tt.retryAfter {
+				t.Errorf("Retry-After = %q, want it set: %v", got, tt.retryAfter)
+			}
+		})
+	}
+}
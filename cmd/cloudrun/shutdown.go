@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Cloud Run sends SIGKILL 10 seconds after SIGTERM, so the default grace period leaves
+// time to cancel what is still running and tear down Chrome
+const (
+	defaultShutdownGraceSeconds = 8
+	shutdownTeardownTimeout     = 2 * time.Second
+)
+
+// runServer serves until SIGTERM or SIGINT, then shuts down gracefully: it stops accepting
+// requests and jobs, lets in-flight scrapes finish within grace, cancels the rest through
+// cancelRequests and tears down every Chrome instance
+func runServer(server *http.Server, handler *CloudRunHandler, cancelRequests context.CancelFunc, grace time.Duration) error {
+	signals, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	defer stop()
+
+	// server.Shutdown stops waiting for handlers when its context expires; tracking them lets
+	// cancelled requests still send their error response before the process exits
+	var handlers sync.WaitGroup
+	next := server.Handler
+	server.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handlers.Add(1)
+		defer handlers.Done()
+		next.ServeHTTP(w, r)
+	})
+
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- server.ListenAndServe() }()
+
+	select {
+	case err := <-serveErr:
+		return err
+	case <-signals.Done():
+	}
+	stop() // A second signal kills the process immediately
+
+	slog.Info("shutting down", "grace", grace)
+	start := time.Now()
+
+	ctx, cancel := context.WithTimeout(context.Background(), grace)
+	defer cancel()
+
+	// Requests and jobs drain concurrently so both get the whole grace period
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		if err := server.Shutdown(ctx); err != nil {
+			slog.Warn("in-flight requests did not finish within grace period, cancelling them", "error", err)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		if err := handler.jobs.Shutdown(ctx); err != nil {
+			slog.Warn("running jobs did not finish within grace period, cancelling them", "error", err)
+		}
+	}()
+	wg.Wait()
+	cancelRequests()
+
+	teardownCtx, cancelTeardown := context.WithTimeout(context.Background(), shutdownTeardownTimeout)
+	defer cancelTeardown()
+	if err := handler.jobs.Close(teardownCtx); err != nil {
+		slog.Error("jobs did not stop after cancellation", "error", err)
+	}
+	if err := handler.scraper.Load().Close(teardownCtx); err != nil {
+		slog.Error("chrome teardown did not complete", "error", err)
+	}
+	if err := waitGroupContext(teardownCtx, &handlers); err != nil {
+		slog.Error("cancelled requests did not finish", "error", err)
+	}
+
+	if err := <-serveErr; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	slog.Info("shutdown complete", "duration_ms", time.Since(start).Milliseconds())
+	return nil
+}
+
+// waitGroupContext waits for wg until ctx expires
+func waitGroupContext(ctx context.Context, wg *sync.WaitGroup) error {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
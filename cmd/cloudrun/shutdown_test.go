@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/jobs"
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/ratelimit"
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/scraper"
+)
+
+// slowBrowser stands in for the Chrome fetcher: pages take delay to load, unless the request is
+// cancelled first, and it records when it was closed
+type slowBrowser struct {
+	delay    time.Duration
+	started  chan struct{}
+	finished atomic.Pointer[time.Time]
+	closed   atomic.Pointer[time.Time]
+	closes   atomic.Int32
+}
+
+func (f *slowBrowser) Name() string {
+	return "browser"
+}
+
+func (f *slowBrowser) Fetch(ctx context.Context, targetURL string) (scraper.FetchResult, error) {
+	close(f.started)
+	defer func() {
+		now := time.Now()
+		f.finished.Store(&now)
+	}()
+	select {
+	case <-time.After(f.delay):
+		return scraper.FetchResult{HTML: fakeShutdownArticleHTML, FinalURL: targetURL}, nil
+	case <-ctx.Done():
+		return scraper.FetchResult{}, ctx.Err()
+	}
+}
+
+func (f *slowBrowser) Close(ctx context.Context) error {
+	now := time.Now()
+	f.closed.Store(&now)
+	f.closes.Add(1)
+	return nil
+}
+
+const fakeShutdownArticleHTML = `<html><head><title>Drained Article</title></head><body><article>
+<h1>Drained Article</h1>
+<p>This article is served while the server shuts down, so the drain can be tested without a browser.</p>
+<p>A second paragraph makes sure readability treats the page as a real article with enough text.</p>
+</article></body></html>`
+
+// freeAddr returns a loopback address nothing listens on
+func freeAddr(t *testing.T) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	return l.Addr().String()
+}
+
+func TestRunServerShutdown(t *testing.T) {
+	tests := []struct {
+		name      string
+		delay     time.Duration
+		grace     time.Duration
+		completes bool // The in-flight scrape finishes rather than being cancelled
+	}{
+		{"in-flight request completes during the drain", 300 * time.Millisecond, 5 * time.Second, true},
+		{"in-flight request outlasting the grace period is cancelled", time.Minute, 300 * time.Millisecond, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			browser := &slowBrowser{delay: tt.delay, started: make(chan struct{})}
+			handler := newBatchHandler(browser, ratelimit.Limits{RequestsPerMinute: -1, MaxConcurrent: -1})
+			handler.jobs = jobs.NewManager(jobs.DefaultConfig())
+
+			mux := http.NewServeMux()
+			mux.HandleFunc("/v1/extract", handler.ExtractHandler)
+			requestCtx, cancelRequests := context.WithCancel(context.Background())
+			defer cancelRequests()
+			addr := freeAddr(t)
+			server := &http.Server{
+				Addr:        addr,
+				Handler:     mux,
+				BaseContext: func(net.Listener) context.Context { return requestCtx },
+			}
+
+			served := make(chan error, 1)
+			go func() { served <- runServer(server, handler, cancelRequests, tt.grace) }()
+			for deadline := time.Now().Add(5 * time.Second); ; {
+				conn, err := net.Dial("tcp", addr)
+				if err == nil {
+					conn.Close()
+					break
+				}
+				if time.Now().After(deadline) {
+					t.Fatalf("server did not start: %v", err)
+				}
+				time.Sleep(10 * time.Millisecond)
+			}
+
+			type response struct {
+				status int
+				body   string
+				err    error
+			}
+			inFlight := make(chan response, 1)
+			go func() {
+				resp, err := http.Post("http://"+addr+"/v1/extract", "application/json",
+					strings.NewReader(`{"url": "https://slow.test/article"}`))
+				if err != nil {
+					inFlight <- response{err: err}
+					return
+				}
+				defer resp.Body.Close()
+				var body strings.Builder
+				_, err = io.Copy(&body, resp.Body)
+				inFlight <- response{status: resp.StatusCode, body: body.String(), err: err}
+			}()
+			<-browser.started
+
+			signalled := time.Now()
+			if err := syscall.Kill(os.Getpid(), syscall.SIGTERM); err != nil {
+				t.Fatal(err)
+			}
+
+			// The listener closes as soon as shutdown starts, while the scrape is still running
+			refused := false
+			for deadline := time.Now().Add(250 * time.Millisecond); time.Now().Before(deadline); {
+				conn, err := net.Dial("tcp", addr)
+				if err != nil {
+					refused = true
+					break
+				}
+				conn.Close()
+				time.Sleep(10 * time.Millisecond)
+			}
+			if !refused {
+				t.Errorf("new connections were still accepted during the drain")
+			}
+
+			got := <-inFlight
+			if got.err != nil {
+				t.Fatalf("in-flight request failed without a response: %v", got.err)
+			}
+			if tt.completes && (got.status != http.StatusOK || !strings.Contains(got.body, "Drained Article")) {
+				t.Errorf("in-flight request = %d %.200s, want the article", got.status, got.body)
+			}
+			if !tt.completes && got.status == http.StatusOK {
+				t.Errorf("in-flight request outlasting the grace period succeeded: %.200s", got.body)
+			}
+
+			select {
+			case err := <-served:
+				if err != nil {
+					t.Fatalf("runServer() = %v", err)
+				}
+			case <-time.After(tt.grace + shutdownTeardownTimeout + time.Second):
+				t.Fatalf("runServer did not return")
+			}
+
+			closed := browser.closed.Load()
+			if browser.closes.Load() != 1 || closed == nil {
+				t.Fatalf("browser closed %d times, want once", browser.closes.Load())
+			}
+			if tt.completes && closed.Before(*browser.finished.Load()) {
+				t.Errorf("browser closed before the in-flight scrape finished")
+			}
+			if !tt.completes && closed.Sub(signalled) < tt.grace {
+				t.Errorf("browser closed %v after the signal, before the %v grace period", closed.Sub(signalled), tt.grace)
+			}
+		})
+	}
+}
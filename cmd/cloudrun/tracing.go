@@ -0,0 +1,42 @@
+package main
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/logging"
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/tracing"
+)
+
+// withTracing starts a server span for every request, continuing the trace from an incoming
+// W3C traceparent header
+func withTracing(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := tracing.Extract(r.Context(), r.Header)
+		ctx, span := tracing.StartKind(ctx, tracing.SpanKindServer, r.Method+" "+r.URL.Path,
+			slog.String("http.method", r.Method),
+			slog.String("http.target", r.URL.Path),
+			slog.String(logging.RequestIDKey, logging.RequestID(ctx)),
+		)
+		defer span.End()
+
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r.WithContext(ctx))
+
+		span.SetAttributes(slog.Int("http.status_code", rec.status))
+		if rec.status >= 500 {
+			span.SetStatus(tracing.StatusError, http.StatusText(rec.status))
+		}
+	})
+}
+
+// statusRecorder remembers the status code written by a handler
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
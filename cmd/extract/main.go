@@ -0,0 +1,204 @@
+// Command extract runs the article extractor locally on a URL, an HTML file or stdin.
+//
+// Usage:
+//
+//	extract [flags] <url | file | ->
+//
+// Output is written to stdout; scraper logs go to stderr with -v.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"html"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/config"
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/logging"
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/models"
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/scraper"
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/tracing"
+)
+
+// Output formats accepted by -format
+const (
+	formatJSON     = "json"
+	formatText     = "text"
+	formatMarkdown = "markdown"
+	formatHTML     = "html"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run runs the command with args, not including the program name, and returns its exit code:
+// 0 on success, 1 when the extraction fails and 2 for invalid usage or configuration
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("extract", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: extract [flags] <url | file | ->\n\n")
+		fmt.Fprintf(flags.Output(), "Extracts an article from a URL, a local HTML file, or stdin (\"-\" or no argument).\n\n")
+		flags.PrintDefaults()
+	}
+
+	mode := flags.String("mode", "auto", "fetch mode for URLs: auto, http or browser")
+	format := flags.String("format", formatJSON, "output format: json, text, markdown or html")
+	content := flags.String("content", scraper.OutputFormatText, "content format inside json output: text, markdown or html")
+	profile := flags.String("html-profile", "", "sanitization profile for html content: strict, links or tables")
+	baseURL := flags.String("base-url", "", "base URL for resolving links when reading a file or stdin")
+	timeout := flags.Duration("timeout", 2*time.Minute, "overall timeout for fetching a URL")
+	verbose := flags.Bool("v", false, "write scraper logs to stderr")
+	configFile := flags.String("config", os.Getenv("CONFIG_FILE"), "YAML or JSON configuration file, read from $CONFIG_FILE when not given")
+	maxBytes := flags.Int("max-bytes", 0, "most of a fetched page to read, up to scrape.maxSizeLimitBytes; 0 uses the configured limits")
+	debug := flags.Bool("debug", false, "explain how the result was produced: in the json output, or on stderr for other formats and failures")
+
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() > 1 {
+		flags.Usage()
+		return 2
+	}
+
+	fetchMode, err := scraper.ParseFetchMode(*mode)
+	if err != nil {
+		fmt.Fprintf(stderr, "extract: %v\n", err)
+		return 2
+	}
+
+	options := scraper.DefaultExtractionOptions()
+	switch *format {
+	case formatJSON:
+		options.OutputFormat = *content
+	case formatText, formatMarkdown, formatHTML:
+		options.OutputFormat = *format
+	default:
+		fmt.Fprintf(stderr, "extract: unknown format %q (expected json, text, markdown or html)\n", *format)
+		return 2
+	}
+	options.HTMLProfile = *profile
+	options.Debug = *debug
+	options.MaxBytes = *maxBytes
+	if err := options.Validate(); err != nil {
+		fmt.Fprintf(stderr, "extract: %v\n", err)
+		return 2
+	}
+
+	// Stdout is kept for the result; logs go to stderr with -v, honouring LOG_LEVEL and LOG_FORMAT
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	if *verbose {
+		logger = logging.New(stderr, logging.ParseLevel(os.Getenv("LOG_LEVEL")), os.Getenv("LOG_FORMAT"))
+	}
+	slog.SetDefault(logger)
+
+	// OTEL_TRACES_EXPORTER=console writes spans to stderr; otlp sends them to a collector
+	provider, err := tracing.FromEnv(stderr)
+	if err != nil {
+		fmt.Fprintf(stderr, "extract: %v\n", err)
+		return 2
+	}
+	if provider != nil {
+		tracing.SetProvider(provider)
+		defer provider.Shutdown(context.Background())
+	}
+
+	settings, err := config.Load(*configFile)
+	if err != nil {
+		fmt.Fprintf(stderr, "extract: %v\n", err)
+		return 2
+	}
+
+	input := flags.Arg(0)
+	s := scraper.NewScraperWithSettings(settings, nil)
+	defer s.Close(context.Background())
+	s.SetLogger(logger)
+
+	var result models.ScrapeResponse
+	start := time.Now()
+	if isURL(input) {
+		ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+		defer cancel()
+		result, err = s.ScrapeWithMode(ctx, input, fetchMode, options)
+		if err == nil {
+			result.Metadata.URL = input
+		}
+	} else {
+		var page []byte
+		page, err = readInput(input, stdin)
+		if err == nil {
+			result, err = s.ExtractFromHTML(context.Background(), string(page), *baseURL, options)
+			result.Metadata.URL = *baseURL
+		}
+	}
+	if *debug && (err != nil || *format != formatJSON) {
+		writeDebugTrace(stderr, result.Debug)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "extract: %v\n", err)
+		return 1
+	}
+	result.Metadata.ScrapedAt = time.Now()
+	result.Metadata.DurationMs = time.Since(start).Milliseconds()
+
+	if err := writeResult(stdout, result, *format); err != nil {
+		fmt.Fprintf(stderr, "extract: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// isURL reports whether the input argument is an http(s) URL rather than a file path
+func isURL(input string) bool {
+	return strings.HasPrefix(input, "http://") || strings.HasPrefix(input, "https://")
+}
+
+// readInput reads HTML from a file, or from stdin when path is empty or "-"
+func readInput(path string, stdin io.Reader) ([]byte, error) {
+	if path == "" || path == "-" {
+		return io.ReadAll(stdin)
+	}
+	return os.ReadFile(path)
+}
+
+// writeDebugTrace prints a debug trace as indented JSON
+func writeDebugTrace(w io.Writer, trace *models.DebugTrace) {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(trace)
+}
+
+// writeResult prints the response as JSON, or the title and content for the other formats
+func writeResult(w io.Writer, result models.ScrapeResponse, format string) error {
+	if format == formatJSON {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		encoder.SetEscapeHTML(false)
+		return encoder.Encode(result)
+	}
+
+	var b strings.Builder
+	if result.Title != "" {
+		switch format {
+		case formatMarkdown:
+			b.WriteString("# " + result.Title + "\n\n")
+		case formatHTML:
+			b.WriteString("<h1>" + html.EscapeString(result.Title) + "</h1>\n")
+		default:
+			b.WriteString(result.Title + "\n\n")
+		}
+	}
+	b.WriteString(result.Content)
+	b.WriteString("\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
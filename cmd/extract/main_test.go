@@ -0,0 +1,89 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const articleHTML = `<html><head><title>CLI Article</title></head><body><main><article>
+<h1>CLI Article</h1>
+<p>This article is read by the command-line extractor, and its <a href="/about">relative links</a> resolve against the base URL.</p>
+<p>A second paragraph makes sure readability treats the page as a real article with enough text.</p>
+</article></main></body></html>`
+
+func TestRun(t *testing.T) {
+	t.Setenv("CONFIG_FILE", "")
+	t.Setenv("OTEL_TRACES_EXPORTER", "")
+
+	dir := t.TempDir()
+	article := filepath.Join(dir, "article.html")
+	empty := filepath.Join(dir, "empty.html")
+	if err := os.WriteFile(article, []byte(articleHTML), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(empty, []byte("<html><body><div></div></body></html>"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/article" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(articleHTML))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name   string
+		args   []string
+		stdin  string
+		code   int
+		stdout string // Expected in stdout
+		stderr string // Expected in stderr
+	}{
+		{"file as json", []string{article}, "", 0, `"title": "CLI Article"`, ""},
+		{"stdin as text", []string{"-format", "text", "-"}, articleHTML, 0, "CLI Article\n\nThis article is read", ""},
+		{"stdin without an argument", []string{"-format", "text"}, articleHTML, 0, "CLI Article\n\n", ""},
+		{"markdown", []string{"-format", "markdown", article}, "", 0, "# CLI Article\n\n", ""},
+		{"markdown resolves links against the base URL", []string{"-format", "markdown", "-base-url", "https://news.test/story", article}, "", 0, "[relative links](https://news.test/about)", ""},
+		{"html", []string{"-format", "html", article}, "", 0, "<h1>CLI Article</h1>\n<article>", ""},
+		{"markdown content inside json", []string{"-content", "markdown", "-base-url", "https://news.test/story", article}, "", 0, `[relative links](https://news.test/about)`, ""},
+		{"debug trace on stderr for text", []string{"-format", "text", "-debug", article}, "", 0, "CLI Article", `"strategies"`},
+		{"URL over HTTP", []string{"-mode", "http", server.URL + "/article"}, "", 0, `"url": "` + server.URL + `/article"`, ""},
+		{"failed fetch", []string{"-mode", "http", "-timeout", "5s", server.URL + "/missing"}, "", 1, "", "extract: "},
+		{"no article", []string{empty}, "", 1, "", "extract: "},
+		{"missing file", []string{filepath.Join(dir, "missing.html")}, "", 1, "", "no such file"},
+		{"unknown flag", []string{"-verbose", article}, "", 2, "", "Usage: extract"},
+		{"two inputs", []string{article, article}, "", 2, "", "Usage: extract"},
+		{"unknown fetch mode", []string{"-mode", "fast", article}, "", 2, "", "extract: "},
+		{"unknown format", []string{"-format", "pdf", article}, "", 2, "", `unknown format "pdf"`},
+		{"unknown content format", []string{"-content", "pdf", article}, "", 2, "", "extract: "},
+		{"html profile without html", []string{"-format", "text", "-html-profile", "strict", article}, "", 2, "", "extract: "},
+		{"missing config file", []string{"-config", filepath.Join(dir, "missing.yaml"), article}, "", 2, "", "extract: "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr strings.Builder
+			code := run(tt.args, strings.NewReader(tt.stdin), &stdout, &stderr)
+
+			if code != tt.code {
+				t.Fatalf("exit code = %d, want %d; stderr: %s", code, tt.code, stderr.String())
+			}
+			if !strings.Contains(stdout.String(), tt.stdout) {
+				t.Errorf("stdout %q does not contain %q", stdout.String(), tt.stdout)
+			}
+			if !strings.Contains(stderr.String(), tt.stderr) {
+				t.Errorf("stderr %q does not contain %q", stderr.String(), tt.stderr)
+			}
+			if tt.code != 0 && stdout.Len() != 0 {
+				t.Errorf("failed run wrote %q to stdout", stdout.String())
+			}
+		})
+	}
+}
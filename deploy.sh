@@ -61,7 +61,8 @@ gcloud run deploy $SERVICE_NAME \
     --cpu 2 \
     --timeout 300 \
     --concurrency 10 \
-    --max-instances 100
+    --max-instances 100 \
+    --no-cpu-throttling
 
 # Get service URL
 SERVICE_URL=$(gcloud run services describe $SERVICE_NAME --region=$REGION --format="value(status.url)")
@@ -84,13 +85,15 @@ echo "     --set-env-vars=\"SCRAPER_API_KEYS=your-key-1,your-key-2\" \\"
 echo "     --region=$REGION"
 echo ""
 echo -e "${BLUE}   Or via Secret Manager (recommended for production):${NC}"
-echo "   # Create secret:"
-echo "   echo -n 'your-key-1,your-key-2' | gcloud secrets create scraper-api-keys --data-file=-"
+echo "   # Generate hashed keys and create the secret:"
+echo "   go run ./cmd/apikey -id your-client -owner 'Your Client' -file keys.json"
+echo "   gcloud secrets create scraper-api-keys --data-file=keys.json"
 echo ""
-echo "   # Grant access and set environment variable:"
+echo "   # Grant access, mount the secret and name it:"
 echo "   gcloud run services update $SERVICE_NAME \\"
-echo "     --set-secrets=\"SCRAPER_API_KEY_SECRET=scraper-api-keys:latest\" \\"
+echo "     --set-secrets=\"/secrets/scraper-api-keys=scraper-api-keys:latest\" \\"
+echo "     --update-env-vars=\"SCRAPER_API_KEY_SECRET=scraper-api-keys\" \\"
 echo "     --region=$REGION"
 echo ""
 echo -e "${BLUE}2. Test the service:${NC}"
-echo "curl \"$SERVICE_URL?url=https://example.com&key=YOUR_API_KEY\""
+echo "curl -H \"X-API-Key: YOUR_API_KEY\" \"$SERVICE_URL?url=https://example.com\""
@@ -0,0 +1,222 @@
+// Package auth authenticates API keys against salted hashes loaded from a pluggable provider.
+// Keys carry an owner, creation and expiry times and can be disabled; the store reloads them
+// periodically or on demand so keys can be rotated without a redeploy.
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/ratelimit"
+)
+
+// Errors returned by Store.Authenticate
+var (
+	ErrMissingKey  = errors.New("missing API key")
+	ErrInvalidKey  = errors.New("invalid API key")
+	ErrKeyExpired  = errors.New("API key has expired")
+	ErrKeyDisabled = errors.New("API key is disabled")
+	ErrNoKeys      = errors.New("no API keys are configured")
+)
+
+// Key is a stored API key; the key itself is never kept, only its salted hash
+type Key struct {
+	ID        string     `json:"id"`              // Unique, safe to log
+	Owner     string     `json:"owner,omitempty"` // Who the key was issued to
+	Salt      string     `json:"salt"`            // Hex encoded
+	Hash      string     `json:"hash"`            // Hex encoded SHA-256 of salt followed by the key
+	CreatedAt time.Time  `json:"createdAt"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+	Disabled  bool       `json:"disabled,omitempty"`
+
+	Limits        *ratelimit.Limits `json:"limits,omitempty"`        // Overrides the service's default limits for this key
+	RespectRobots bool              `json:"respectRobots,omitempty"` // Scrapes made with this key honor robots.txt
+}
+
+// RateLimits returns the key's own limits; zero fields mean the service defaults
+func (k *Key) RateLimits() ratelimit.Limits {
+	if k.Limits == nil {
+		return ratelimit.Limits{}
+	}
+	return *k.Limits
+}
+
+// matches reports whether apiKey hashes to the stored hash, in constant time
+func (k *Key) matches(apiKey string) bool {
+	salt, err := hex.DecodeString(k.Salt)
+	if err != nil {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(HashKey(apiKey, salt)), []byte(k.Hash)) == 1
+}
+
+// HashKey returns the hex encoded SHA-256 of salt followed by apiKey
+func HashKey(apiKey string, salt []byte) string {
+	h := sha256.New()
+	h.Write(salt)
+	h.Write([]byte(apiKey))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// newSalt returns a random 16-byte salt
+func newSalt() ([]byte, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	return salt, nil
+}
+
+// hashedKey builds the stored form of a plaintext key
+func hashedKey(id, apiKey string) (Key, error) {
+	salt, err := newSalt()
+	if err != nil {
+		return Key{}, err
+	}
+	return Key{ID: id, Salt: hex.EncodeToString(salt), Hash: HashKey(apiKey, salt), CreatedAt: time.Now().UTC()}, nil
+}
+
+// NewKey generates a random API key and its stored form
+// The plaintext key is returned once and must be handed to its owner; only the Key is stored
+func NewKey(id, owner string, expiresAt *time.Time) (string, Key, error) {
+	secret := make([]byte, 24)
+	if _, err := rand.Read(secret); err != nil {
+		return "", Key{}, err
+	}
+	apiKey := "sk_" + hex.EncodeToString(secret)
+
+	key, err := hashedKey(id, apiKey)
+	if err != nil {
+		return "", Key{}, err
+	}
+	key.Owner = owner
+	key.ExpiresAt = expiresAt
+	return apiKey, key, nil
+}
+
+// Provider loads the current set of API keys
+type Provider interface {
+	Name() string
+	Keys(ctx context.Context) ([]Key, error)
+}
+
+// Store authenticates API keys against the keys last loaded from its provider
+// It fails closed: until keys have been loaded, and whenever the provider has none, every key is rejected
+type Store struct {
+	provider Provider
+	keys     atomic.Pointer[[]Key]
+}
+
+// NewStore creates a store for provider; call Reload to load the keys
+func NewStore(provider Provider) *Store {
+	return &Store{provider: provider}
+}
+
+// Reload loads the keys from the provider
+// On failure the previously loaded keys stay in effect
+func (s *Store) Reload(ctx context.Context) error {
+	keys, err := s.provider.Keys(ctx)
+	if err != nil {
+		return fmt.Errorf("loading API keys from %s: %w", s.provider.Name(), err)
+	}
+	if err := validateKeys(keys); err != nil {
+		return fmt.Errorf("loading API keys from %s: %w", s.provider.Name(), err)
+	}
+	s.keys.Store(&keys)
+	return nil
+}
+
+// Count returns the number of loaded keys, including expired and disabled ones
+func (s *Store) Count() int {
+	if keys := s.keys.Load(); keys != nil {
+		return len(*keys)
+	}
+	return 0
+}
+
+// Authenticate returns the stored key matching apiKey
+func (s *Store) Authenticate(apiKey string) (*Key, error) {
+	keys := s.keys.Load()
+	if keys == nil || len(*keys) == 0 {
+		return nil, ErrNoKeys
+	}
+	if apiKey == "" {
+		return nil, ErrMissingKey
+	}
+
+	// Every key is checked so the time taken does not reveal which one matched
+	var found *Key
+	for i := range *keys {
+		if (*keys)[i].matches(apiKey) && found == nil {
+			found = &(*keys)[i]
+		}
+	}
+	switch {
+	case found == nil:
+		return nil, ErrInvalidKey
+	case found.Disabled:
+		return nil, ErrKeyDisabled
+	case found.ExpiresAt != nil && !time.Now().Before(*found.ExpiresAt):
+		return nil, ErrKeyExpired
+	}
+	return found, nil
+}
+
+// Run reloads the keys every interval and whenever a value arrives on reload (typically SIGHUP),
+// until ctx is done; failed reloads are logged and the previous keys kept
+func (s *Store) Run(ctx context.Context, interval time.Duration, reload <-chan os.Signal) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-reload:
+			s.reloadAndLog(ctx)
+		case <-ticker.C:
+			s.reloadAndLog(ctx)
+		}
+	}
+}
+
+func (s *Store) reloadAndLog(ctx context.Context) {
+	before := s.Count()
+	if err := s.Reload(ctx); err != nil {
+		slog.Error("API key reload failed, previous keys remain in effect", "error", err)
+		return
+	}
+	if count := s.Count(); count != before {
+		slog.Info("API keys reloaded", "provider", s.provider.Name(), "count", count)
+	}
+}
+
+// validateKeys checks that keys have unique IDs and well-formed hashes
+func validateKeys(keys []Key) error {
+	seen := make(map[string]bool, len(keys))
+	for i, key := range keys {
+		if key.ID == "" {
+			return fmt.Errorf("key %d has no id", i+1)
+		}
+		if seen[key.ID] {
+			return fmt.Errorf("duplicate key id %q", key.ID)
+		}
+		seen[key.ID] = true
+		if _, err := hex.DecodeString(key.Salt); err != nil || key.Salt == "" {
+			return fmt.Errorf("key %q: salt must be hex encoded", key.ID)
+		}
+		if hash, err := hex.DecodeString(key.Hash); err != nil || len(hash) != sha256.Size {
+			return fmt.Errorf("key %q: hash must be a hex encoded SHA-256", key.ID)
+		}
+	}
+	return nil
+}
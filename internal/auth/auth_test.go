@@ -0,0 +1,136 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// staticProvider returns fixed keys, or err
+type staticProvider struct {
+	keys []Key
+	err  error
+}
+
+func (p *staticProvider) Name() string { return "static" }
+
+func (p *staticProvider) Keys(ctx context.Context) ([]Key, error) { return p.keys, p.err }
+
+func TestAuthenticate(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+
+	active, activeKey, _ := NewKey("active", "acme", &future)
+	expired, expiredKey, _ := NewKey("expired", "acme", &past)
+	disabled, disabledKey, _ := NewKey("disabled", "acme", nil)
+	disabledKey.Disabled = true
+
+	store := NewStore(&staticProvider{keys: []Key{activeKey, expiredKey, disabledKey}})
+	if err := store.Reload(context.Background()); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+
+	tests := []struct {
+		apiKey  string
+		wantID  string
+		wantErr error
+	}{
+		{active, "active", nil},
+		{expired, "", ErrKeyExpired},
+		{disabled, "", ErrKeyDisabled},
+		{active + "x", "", ErrInvalidKey},
+		{"", "", ErrMissingKey},
+	}
+	for _, tt := range tests {
+		key, err := store.Authenticate(tt.apiKey)
+		if !errors.Is(err, tt.wantErr) {
+			t.Errorf("Authenticate(%q) error = %v, want %v", tt.apiKey, err, tt.wantErr)
+			continue
+		}
+		if tt.wantErr == nil && key.ID != tt.wantID {
+			t.Errorf("Authenticate(%q) = %q, want %q", tt.apiKey, key.ID, tt.wantID)
+		}
+	}
+}
+
+func TestStoreFailsClosed(t *testing.T) {
+	apiKey, key, _ := NewKey("k", "", nil)
+	provider := &staticProvider{err: errors.New("secret unavailable")}
+	store := NewStore(provider)
+
+	if err := store.Reload(context.Background()); err == nil {
+		t.Fatal("Reload succeeded with a failing provider")
+	}
+	if _, err := store.Authenticate(apiKey); !errors.Is(err, ErrNoKeys) {
+		t.Fatalf("Authenticate before keys loaded: error = %v, want ErrNoKeys", err)
+	}
+
+	provider.keys, provider.err = []Key{key}, nil
+	if err := store.Reload(context.Background()); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+
+	// A failed reload keeps the keys already loaded
+	provider.err = errors.New("secret unavailable")
+	if err := store.Reload(context.Background()); err == nil {
+		t.Fatal("Reload succeeded with a failing provider")
+	}
+	if _, err := store.Authenticate(apiKey); err != nil {
+		t.Fatalf("Authenticate after failed reload: %v", err)
+	}
+
+	// An empty key set rejects everyone
+	provider.keys, provider.err = []Key{}, nil
+	if err := store.Reload(context.Background()); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	if _, err := store.Authenticate(apiKey); !errors.Is(err, ErrNoKeys) {
+		t.Fatalf("Authenticate with no keys: error = %v, want ErrNoKeys", err)
+	}
+}
+
+func TestProviders(t *testing.T) {
+	dir := t.TempDir()
+	apiKey, key, _ := NewKey("file-key", "acme", nil)
+
+	file := FileProvider{Path: filepath.Join(dir, "keys.json")}
+	if err := file.Add(key); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if err := file.Add(key); err == nil {
+		t.Fatal("Add accepted a duplicate key ID")
+	}
+	if err := os.WriteFile(filepath.Join(dir, "plain"), []byte("one, two\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TEST_API_KEYS", "one,two")
+
+	tests := []struct {
+		provider Provider
+		apiKey   string
+		wantID   string
+	}{
+		{file, apiKey, "file-key"},
+		{SecretProvider{Source: DirSecretSource{Dir: dir}, Secret: "keys.json"}, apiKey, "file-key"},
+		{SecretProvider{Source: DirSecretSource{Dir: dir}, Secret: "plain"}, "two", "secret-2"},
+		{EnvProvider{Var: "TEST_API_KEYS"}, "one", "env-1"},
+	}
+	for _, tt := range tests {
+		store := NewStore(tt.provider)
+		if err := store.Reload(context.Background()); err != nil {
+			t.Errorf("%s: Reload: %v", tt.provider.Name(), err)
+			continue
+		}
+		got, err := store.Authenticate(tt.apiKey)
+		if err != nil || got.ID != tt.wantID {
+			t.Errorf("%s: Authenticate = %v, %v; want key %q", tt.provider.Name(), got, err, tt.wantID)
+		}
+	}
+
+	if _, err := (DirSecretSource{Dir: dir}).AccessSecret(context.Background(), "../keys.json"); err == nil {
+		t.Error("AccessSecret accepted a path outside its directory")
+	}
+}
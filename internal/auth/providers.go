@@ -0,0 +1,167 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// EnvProvider reads comma-separated plaintext keys from an environment variable
+// The keys are hashed when loaded; prefer FileProvider or SecretProvider, which store hashes only
+type EnvProvider struct {
+	Var string
+}
+
+// Name implements Provider
+func (p EnvProvider) Name() string {
+	return "env:" + p.Var
+}
+
+// Keys implements Provider
+func (p EnvProvider) Keys(ctx context.Context) ([]Key, error) {
+	return plaintextKeys("env", os.Getenv(p.Var))
+}
+
+// FileProvider reads keys from a local JSON file, re-reading it on every reload
+// The file holds {"keys": [...]} with one Key object per key
+type FileProvider struct {
+	Path string
+}
+
+// Name implements Provider
+func (p FileProvider) Name() string {
+	return "file:" + p.Path
+}
+
+// Keys implements Provider
+func (p FileProvider) Keys(ctx context.Context) ([]Key, error) {
+	data, err := os.ReadFile(p.Path)
+	if err != nil {
+		return nil, err
+	}
+	return parseKeyFile(data)
+}
+
+// Add appends key to the file, creating it if needed
+// The file is replaced atomically so a concurrent reload never sees it half written
+func (p FileProvider) Add(key Key) error {
+	file := keyFile{Keys: []Key{}}
+	data, err := os.ReadFile(p.Path)
+	switch {
+	case err == nil:
+		keys, err := parseKeyFile(data)
+		if err != nil {
+			return err
+		}
+		file.Keys = keys
+	case !errors.Is(err, os.ErrNotExist):
+		return err
+	}
+
+	file.Keys = append(file.Keys, key)
+	if err := validateKeys(file.Keys); err != nil {
+		return err
+	}
+	data, err = json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(p.Path), ".keys-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), p.Path)
+}
+
+// SecretSource returns the latest version of a named secret
+// A Secret Manager client can implement it; DirSecretSource is the local stand-in
+type SecretSource interface {
+	AccessSecret(ctx context.Context, name string) ([]byte, error)
+}
+
+// DirSecretSource reads secrets from files named after them in a directory, which is how
+// Cloud Run mounts Secret Manager secrets as volumes and how secrets are provided locally
+type DirSecretSource struct {
+	Dir string
+}
+
+// AccessSecret implements SecretSource
+func (s DirSecretSource) AccessSecret(ctx context.Context, name string) ([]byte, error) {
+	if name == "" || name != filepath.Base(name) {
+		return nil, fmt.Errorf("invalid secret name %q", name)
+	}
+	return os.ReadFile(filepath.Join(s.Dir, name))
+}
+
+// SecretProvider reads keys from a secret holding the same JSON as FileProvider
+// Secrets holding comma-separated plaintext keys, as written by manage-api-keys.sh, are accepted too
+type SecretProvider struct {
+	Source SecretSource
+	Secret string
+}
+
+// Name implements Provider
+func (p SecretProvider) Name() string {
+	return "secret:" + p.Secret
+}
+
+// Keys implements Provider
+func (p SecretProvider) Keys(ctx context.Context) ([]Key, error) {
+	data, err := p.Source.AccessSecret(ctx, p.Secret)
+	if err != nil {
+		return nil, err
+	}
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] != '{' {
+		return plaintextKeys("secret", string(trimmed))
+	}
+	return parseKeyFile(data)
+}
+
+// keyFile is the JSON document read by FileProvider and SecretProvider
+type keyFile struct {
+	Keys []Key `json:"keys"`
+}
+
+func parseKeyFile(data []byte) ([]Key, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	var file keyFile
+	if err := decoder.Decode(&file); err != nil {
+		return nil, fmt.Errorf("parsing key file: %w", err)
+	}
+	if file.Keys == nil {
+		return nil, errors.New("key file has no \"keys\" list")
+	}
+	return file.Keys, nil
+}
+
+// plaintextKeys hashes comma- or newline-separated keys, naming them prefix-1, prefix-2, ...
+func plaintextKeys(prefix, list string) ([]Key, error) {
+	var keys []Key
+	for _, apiKey := range strings.FieldsFunc(list, func(r rune) bool { return r == ',' || r == '\n' }) {
+		apiKey = strings.TrimSpace(apiKey)
+		if apiKey == "" {
+			continue
+		}
+		key, err := hashedKey(fmt.Sprintf("%s-%d", prefix, len(keys)+1), apiKey)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
@@ -0,0 +1,338 @@
+// Package cache stores scrape results so repeated requests for the same article can be served
+// without fetching it again. Stores are an in-memory LRU, a directory on disk, or both layered.
+package cache
+
+import (
+	"container/list"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/models"
+)
+
+// Entry is a stored scrape result and what is needed to revalidate it
+type Entry struct {
+	Key      string                `json:"key"`
+	Response models.ScrapeResponse `json:"response"`
+	StoredAt time.Time             `json:"storedAt"` // When the result was last fetched or revalidated
+
+	// Revalidation uses a conditional request to FetchURL with the validators the server sent
+	FetchURL     string `json:"fetchUrl,omitempty"`
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"lastModified,omitempty"`
+}
+
+// Age returns how long ago the entry was stored at now
+func (e Entry) Age(now time.Time) time.Duration {
+	return now.Sub(e.StoredAt)
+}
+
+// Store holds entries by key
+// Implementations are safe for concurrent use; callers must not modify the slices of entries they get
+type Store interface {
+	Get(key string) (Entry, bool)
+	Put(entry Entry)
+}
+
+// LRU is an in-memory store that evicts the least recently used entry when full
+type LRU struct {
+	mu         sync.Mutex
+	maxEntries int
+	order      *list.List // Front is the most recently used
+	items      map[string]*list.Element
+}
+
+// NewLRU creates an in-memory store holding at most maxEntries entries
+func NewLRU(maxEntries int) *LRU {
+	if maxEntries < 1 {
+		maxEntries = 1
+	}
+	return &LRU{maxEntries: maxEntries, order: list.New(), items: make(map[string]*list.Element)}
+}
+
+// Get implements Store
+func (c *LRU) Get(key string) (Entry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.items[key]
+	if !ok {
+		return Entry{}, false
+	}
+	c.order.MoveToFront(elem)
+	return elem.Value.(Entry), true
+}
+
+// Put implements Store
+func (c *LRU) Put(entry Entry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.items[entry.Key]; ok {
+		elem.Value = entry
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.items[entry.Key] = c.order.PushFront(entry)
+	for c.order.Len() > c.maxEntries {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(Entry).Key)
+	}
+}
+
+// Len returns the number of entries held
+func (c *LRU) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// Disk is a store keeping one JSON file per entry in a directory
+// Keys are used as file names, so they must be safe ones such as the hashes built by scraper
+// Files older than the age limit are deleted, and the oldest files go first when the directory
+// holds more entries or bytes than allowed
+type Disk struct {
+	dir    string
+	limits DiskLimits
+
+	mu        sync.Mutex
+	order     *list.List // Files by write time, oldest at the front
+	files     map[string]*list.Element
+	bytes     int64
+	lastSweep time.Time
+}
+
+// DiskLimits bounds a Disk store; zero fields are unlimited
+type DiskLimits struct {
+	MaxEntries int
+	MaxBytes   int64
+	MaxAge     time.Duration // Entries not written for this long are deleted
+}
+
+// DefaultDiskLimits returns the limits used by NewDisk
+func DefaultDiskLimits() DiskLimits {
+	return DiskLimits{
+		MaxEntries: 10000,
+		MaxBytes:   256 << 20,
+		MaxAge:     24 * time.Hour,
+	}
+}
+
+// diskFile is the index entry of one file of a Disk store
+type diskFile struct {
+	key     string
+	size    int64
+	written time.Time
+}
+
+// sweepInterval is how often Put looks for files past the age limit
+const sweepInterval = time.Minute
+
+// NewDisk creates a store in dir with the default limits, creating the directory if needed
+func NewDisk(dir string) (*Disk, error) {
+	return NewDiskWithLimits(dir, DefaultDiskLimits())
+}
+
+// NewDiskWithLimits creates a store in dir bounded by limits, creating the directory if needed
+// Files left in dir by an earlier run count against the limits
+func NewDiskWithLimits(dir string, limits DiskLimits) (*Disk, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("creating cache directory: %w", err)
+	}
+	d := &Disk{dir: dir, limits: limits, order: list.New(), files: make(map[string]*list.Element)}
+	if err := d.load(); err != nil {
+		return nil, fmt.Errorf("reading cache directory: %w", err)
+	}
+	d.mu.Lock()
+	d.evictLocked(time.Now())
+	d.mu.Unlock()
+	return d, nil
+}
+
+// load indexes the entries already in the directory and removes abandoned temporary files
+func (d *Disk) load() error {
+	dirEntries, err := os.ReadDir(d.dir)
+	if err != nil {
+		return err
+	}
+	var found []diskFile
+	for _, de := range dirEntries {
+		name := de.Name()
+		if de.IsDir() {
+			continue
+		}
+		if strings.HasPrefix(name, ".entry-") {
+			os.Remove(filepath.Join(d.dir, name))
+			continue
+		}
+		key, ok := strings.CutSuffix(name, ".json")
+		if !ok {
+			continue
+		}
+		info, err := de.Info()
+		if err != nil {
+			continue
+		}
+		found = append(found, diskFile{key: key, size: info.Size(), written: info.ModTime()})
+	}
+	sort.Slice(found, func(i, j int) bool { return found[i].written.Before(found[j].written) })
+	for _, f := range found {
+		d.files[f.key] = d.order.PushBack(f)
+		d.bytes += f.size
+	}
+	return nil
+}
+
+// Len returns the number of entries held
+func (d *Disk) Len() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.order.Len()
+}
+
+// path returns the file holding key
+func (d *Disk) path(key string) string {
+	return filepath.Join(d.dir, filepath.Base(key)+".json")
+}
+
+// Get implements Store; unreadable and expired entries are treated as missing
+func (d *Disk) Get(key string) (Entry, bool) {
+	if d.expired(key, time.Now()) {
+		return Entry{}, false
+	}
+	data, err := os.ReadFile(d.path(key))
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			slog.Warn("reading cache entry failed", "cache_key", key, "error", err)
+		}
+		return Entry{}, false
+	}
+	var entry Entry
+	if err := json.Unmarshal(data, &entry); err != nil || entry.Key != key {
+		slog.Warn("ignoring corrupt cache entry", "path", d.path(key), "error", err)
+		return Entry{}, false
+	}
+	return entry, true
+}
+
+// Put implements Store, replacing the file atomically; failures are logged since the cache is best effort
+func (d *Disk) Put(entry Entry) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		slog.Warn("encoding cache entry failed", "cache_key", entry.Key, "error", err)
+		return
+	}
+	tmp, err := os.CreateTemp(d.dir, ".entry-*")
+	if err != nil {
+		slog.Warn("writing cache entry failed", "cache_key", entry.Key, "error", err)
+		return
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), d.path(entry.Key))
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		slog.Warn("writing cache entry failed", "cache_key", entry.Key, "error", err)
+		return
+	}
+
+	now := time.Now()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.forgetLocked(entry.Key)
+	d.files[entry.Key] = d.order.PushBack(diskFile{key: entry.Key, size: int64(len(data)), written: now})
+	d.bytes += int64(len(data))
+	d.evictLocked(now)
+}
+
+// expired reports whether key is past the age limit, deleting its file if so
+func (d *Disk) expired(key string, now time.Time) bool {
+	if d.limits.MaxAge <= 0 {
+		return false
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	elem, ok := d.files[key]
+	if !ok || now.Sub(elem.Value.(diskFile).written) < d.limits.MaxAge {
+		return false
+	}
+	d.removeLocked(elem)
+	return true
+}
+
+// evictLocked deletes the oldest files while the store is over its entry or byte limit, and,
+// at most once per sweepInterval, every file past the age limit
+func (d *Disk) evictLocked(now time.Time) {
+	for d.order.Len() > 0 && (d.limits.MaxEntries > 0 && d.order.Len() > d.limits.MaxEntries ||
+		d.limits.MaxBytes > 0 && d.bytes > d.limits.MaxBytes) {
+		d.removeLocked(d.order.Front())
+	}
+	if d.limits.MaxAge <= 0 || now.Sub(d.lastSweep) < sweepInterval {
+		return
+	}
+	d.lastSweep = now
+	for d.order.Len() > 0 && now.Sub(d.order.Front().Value.(diskFile).written) >= d.limits.MaxAge {
+		d.removeLocked(d.order.Front())
+	}
+}
+
+// removeLocked deletes the file of elem and drops it from the index
+func (d *Disk) removeLocked(elem *list.Element) {
+	f := elem.Value.(diskFile)
+	if err := os.Remove(d.path(f.key)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		slog.Warn("removing cache entry failed", "cache_key", f.key, "error", err)
+	}
+	d.forgetLocked(f.key)
+}
+
+// forgetLocked drops key from the index without touching its file
+func (d *Disk) forgetLocked(key string) {
+	if elem, ok := d.files[key]; ok {
+		d.bytes -= elem.Value.(diskFile).size
+		d.order.Remove(elem)
+		delete(d.files, key)
+	}
+}
+
+// tiered checks its stores in order, copying entries found in a later store into the earlier ones
+type tiered []Store
+
+// NewTiered layers stores, fastest first, such as an LRU in front of a Disk
+func NewTiered(stores ...Store) Store {
+	if len(stores) == 1 {
+		return stores[0]
+	}
+	return tiered(stores)
+}
+
+// Get implements Store
+func (t tiered) Get(key string) (Entry, bool) {
+	for i, store := range t {
+		if entry, ok := store.Get(key); ok {
+			for _, faster := range t[:i] {
+				faster.Put(entry)
+			}
+			return entry, true
+		}
+	}
+	return Entry{}, false
+}
+
+// Put implements Store
+func (t tiered) Put(entry Entry) {
+	for _, store := range t {
+		store.Put(entry)
+	}
+}
@@ -0,0 +1,100 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/models"
+)
+
+func entry(key string) Entry {
+	return Entry{Key: key, Response: models.ScrapeResponse{Title: "title " + key}, StoredAt: time.Unix(1_700_000_000, 0).UTC()}
+}
+
+func TestLRUEvictsLeastRecentlyUsed(t *testing.T) {
+	lru := NewLRU(2)
+	lru.Put(entry("a"))
+	lru.Put(entry("b"))
+	lru.Get("a") // b is now the least recently used
+	lru.Put(entry("c"))
+
+	if _, ok := lru.Get("b"); ok {
+		t.Error("least recently used entry was not evicted")
+	}
+	for _, key := range []string{"a", "c"} {
+		if got, ok := lru.Get(key); !ok || got.Response.Title != "title "+key {
+			t.Errorf("Get(%q) = %+v, %v", key, got, ok)
+		}
+	}
+	if lru.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", lru.Len())
+	}
+}
+
+func TestTieredPromotesDiskEntries(t *testing.T) {
+	dir := t.TempDir()
+	disk, err := NewDisk(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	disk.Put(entry("k"))
+
+	// A new process sees entries written to disk by the previous one
+	memory := NewLRU(10)
+	store := NewTiered(memory, disk)
+	got, ok := store.Get("k")
+	if !ok || got.Response.Title != "title k" || !got.StoredAt.Equal(entry("k").StoredAt) {
+		t.Fatalf("Get from disk = %+v, %v", got, ok)
+	}
+	if _, ok := memory.Get("k"); !ok {
+		t.Error("disk entry was not copied into memory")
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "bad.json"), []byte("{"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := store.Get("bad"); ok {
+		t.Error("corrupt entry was returned")
+	}
+}
+
+func TestDiskEvictsOldestAndExpiredEntries(t *testing.T) {
+	dir := t.TempDir()
+	disk, err := NewDiskWithLimits(dir, DiskLimits{MaxEntries: 2, MaxAge: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"a", "b", "c"} {
+		disk.Put(entry(key))
+	}
+	if _, ok := disk.Get("a"); ok {
+		t.Error("oldest entry was not evicted")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "a.json")); !os.IsNotExist(err) {
+		t.Errorf("evicted entry's file is still there: %v", err)
+	}
+	if disk.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", disk.Len())
+	}
+
+	if !disk.expired("b", time.Now().Add(2*time.Hour)) {
+		t.Error("entry past the age limit was not expired")
+	}
+	if _, ok := disk.Get("b"); ok {
+		t.Error("expired entry was returned")
+	}
+
+	// A restart counts the files already there, and a lower limit trims them
+	reopened, err := NewDiskWithLimits(dir, DiskLimits{MaxBytes: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reopened.Len() != 0 {
+		t.Errorf("Len() after reopening over the byte limit = %d, want 0", reopened.Len())
+	}
+	if files, _ := filepath.Glob(filepath.Join(dir, "*.json")); len(files) != 0 {
+		t.Errorf("files left over the byte limit: %v", files)
+	}
+}
@@ -11,23 +11,24 @@ import (
 
 // ImageConfig contains configuration for image extraction
 type ImageConfig struct {
-	MinShortSide   int
-	MinArea        int
-	MinAspect      float64
-	MaxAspect      float64
-	RatioWhitelist []float64
-	RatioTol       float64
-	AdSizes        map[string]bool
-	BadHintRegex   string
+	MinShortSide   int       `json:"minShortSide"`
+	MinArea        int       `json:"minArea"`
+	MinAspect      float64   `json:"minAspect"`
+	MaxAspect      float64   `json:"maxAspect"`
+	RatioWhitelist []float64 `json:"ratioWhitelist"`
+	RatioTol       float64   `json:"ratioTol"`
+	AdSizes        AdSizeSet `json:"adSizes"`
+	BadHintRegex   string    `json:"badHintRegex"`
 }
 
 // ScrapeConfig contains general scraping configuration
 type ScrapeConfig struct {
-	UserAgent      string
-	TimeoutMs      int
-	SizeLimitBytes int
-	MaxRetries     int
-	ChromeMajor    int
+	UserAgent         string `json:"userAgent"`
+	TimeoutMs         int    `json:"timeoutMs"`
+	SizeLimitBytes    int    `json:"sizeLimitBytes"`    // Reading a page may stop past this once its article and structured data are in
+	MaxSizeLimitBytes int    `json:"maxSizeLimitBytes"` // Fetched HTML beyond this is truncated; also caps a request's maxBytes
+	MaxRetries        int    `json:"maxRetries"`
+	ChromeMajor       int    `json:"chromeMajor"`
 }
 
 // DefaultImageConfig returns the default image extraction configuration
@@ -51,33 +52,55 @@ func DefaultImageConfig() ImageConfig {
 }
 
 // DefaultScrapeConfig returns the default scraping configuration
+// CHROME_MAJOR and SCRAPE_USER_AGENT override the built-in values
 func DefaultScrapeConfig() ScrapeConfig {
-	chromeMajor := 133
+	chromeMajor := defaultChromeMajor
 	if env := os.Getenv("CHROME_MAJOR"); env != "" {
 		if parsed, err := strconv.Atoi(env); err == nil {
 			chromeMajor = parsed
 		}
 	}
 
-	userAgent := os.Getenv("SCRAPE_USER_AGENT")
-	if userAgent == "" {
-		userAgent = fmt.Sprintf("Mozilla/5.0 (Windows NT 10; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/%d.0.6943.126 Safari/537.36", chromeMajor)
+	cfg := BaseScrapeConfig()
+	cfg.ChromeMajor = chromeMajor
+	cfg.UserAgent = os.Getenv("SCRAPE_USER_AGENT")
+	if cfg.UserAgent == "" {
+		cfg.UserAgent = chromeUserAgent(chromeMajor)
 	}
+	return cfg
+}
+
+// defaultChromeMajor is the Chrome version advertised when CHROME_MAJOR is not set
+const defaultChromeMajor = 133
 
+// BaseScrapeConfig returns the built-in scraping configuration without environment overrides
+func BaseScrapeConfig() ScrapeConfig {
 	return ScrapeConfig{
-		UserAgent:      userAgent,
-		TimeoutMs:      15000,
-		SizeLimitBytes: 6_000_000,
-		MaxRetries:     2,
-		ChromeMajor:    chromeMajor,
+		UserAgent:         chromeUserAgent(defaultChromeMajor),
+		TimeoutMs:         15000,
+		SizeLimitBytes:    6_000_000,
+		MaxSizeLimitBytes: 24_000_000,
+		MaxRetries:        2,
+		ChromeMajor:       defaultChromeMajor,
 	}
 }
 
+// chromeUserAgent returns a desktop Chrome user agent for the given major version
+func chromeUserAgent(chromeMajor int) string {
+	return fmt.Sprintf("Mozilla/5.0 (Windows NT 10; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/%d.0.6943.126 Safari/537.36", chromeMajor)
+}
+
 // CompileRegexes pre-compiles regex patterns for better performance
 func CompileRegexes() map[string]*regexp.Regexp {
-	config := DefaultImageConfig()
+	return CompileImageRegexes(DefaultImageConfig())
+}
 
-	badHintRegex, _ := regexp.Compile("(?i)" + config.BadHintRegex)
+// CompileImageRegexes pre-compiles regex patterns using the bad hint pattern from config
+func CompileImageRegexes(config ImageConfig) map[string]*regexp.Regexp {
+	badHintRegex, err := regexp.Compile("(?i)" + config.BadHintRegex)
+	if err != nil {
+		badHintRegex = regexp.MustCompile("(?i)" + DefaultImageConfig().BadHintRegex)
+	}
 
 	return map[string]*regexp.Regexp{
 		"badHint":           badHintRegex,
@@ -0,0 +1,285 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/andybalholm/cascadia"
+	"gopkg.in/yaml.v3"
+)
+
+// Settings is the complete tunable configuration of the scraper
+// It is loaded from a YAML or JSON file by Load; fields missing from the file keep their defaults
+type Settings struct {
+	Scrape           ScrapeConfig `json:"scrape"`
+	Image            ImageConfig  `json:"image"`
+	Timeouts         Timeouts     `json:"timeouts"`
+	Politeness       Politeness   `json:"politeness"`
+	Robots           Robots       `json:"robots"`
+	ContentSelectors []string     `json:"contentSelectors"` // Tried in order to find the article container
+	BlockedDomains   []string     `json:"blockedDomains"`   // Requests from the browser to URLs containing these are blocked
+}
+
+// Timeouts caps how long each fetcher may run within a request's budget
+type Timeouts struct {
+	HTTPMs    int `json:"httpMs"`
+	BrowserMs int `json:"browserMs"`
+}
+
+// HTTP returns the HTTP fetcher timeout
+func (t Timeouts) HTTP() time.Duration {
+	return time.Duration(t.HTTPMs) * time.Millisecond
+}
+
+// Browser returns the browser fetcher timeout
+func (t Timeouts) Browser() time.Duration {
+	return time.Duration(t.BrowserMs) * time.Millisecond
+}
+
+// Politeness limits how hard each site is hit, across the HTTP and browser phases
+// Hosts are grouped by registrable domain, so www., amp. and m. subdomains share limits
+type Politeness struct {
+	MinIntervalMs        int `json:"minIntervalMs"`        // Between the starts of two fetches from the same domain; 0 disables
+	MaxConcurrentPerHost int `json:"maxConcurrentPerHost"` // Fetches in flight per domain; 0 is unlimited
+}
+
+// MinInterval returns the minimum interval between fetches from the same domain
+func (p Politeness) MinInterval() time.Duration {
+	return time.Duration(p.MinIntervalMs) * time.Millisecond
+}
+
+// Robots configures robots.txt compliance
+// API keys can require compliance even when it is not enabled for the whole deployment
+type Robots struct {
+	Enabled   bool   `json:"enabled"`   // Honor robots.txt for every request
+	UserAgent string `json:"userAgent"` // Product token matched against User-agent lines
+}
+
+// AdSizeSet is a set of "WIDTHxHEIGHT" image sizes
+// In configuration files it is written as a list of sizes
+type AdSizeSet map[string]bool
+
+// MarshalJSON writes the set as a sorted list
+func (s AdSizeSet) MarshalJSON() ([]byte, error) {
+	sizes := make([]string, 0, len(s))
+	for size, ok := range s {
+		if ok {
+			sizes = append(sizes, size)
+		}
+	}
+	sort.Strings(sizes)
+	return json.Marshal(sizes)
+}
+
+// UnmarshalJSON reads a list of sizes, or an object mapping sizes to booleans, replacing the set
+func (s *AdSizeSet) UnmarshalJSON(data []byte) error {
+	var sizes []string
+	if err := json.Unmarshal(data, &sizes); err == nil {
+		*s = make(AdSizeSet, len(sizes))
+		for _, size := range sizes {
+			(*s)[size] = true
+		}
+		return nil
+	}
+	var set map[string]bool
+	if err := json.Unmarshal(data, &set); err != nil {
+		return fmt.Errorf("adSizes must be a list of sizes such as \"728x90\"")
+	}
+	*s = set
+	return nil
+}
+
+// DefaultSettings returns the built-in configuration without environment overrides
+func DefaultSettings() *Settings {
+	return &Settings{
+		Scrape: BaseScrapeConfig(),
+		Image:  DefaultImageConfig(),
+		Timeouts: Timeouts{
+			HTTPMs:    12000, // SCMP blocks HTTP anyway
+			BrowserMs: 60000, // SCMP needs more time
+		},
+		Politeness: Politeness{
+			MinIntervalMs:        250,
+			MaxConcurrentPerHost: 2,
+		},
+		Robots: Robots{
+			UserAgent: "extract-html-scraper",
+		},
+		ContentSelectors: []string{
+			"[data-module='ArticleBody']", "[data-qa='article-body']", ".article__body", ".story__content-body",
+			"article", "main", "[role='main']", ".content", ".post-content", ".entry-content",
+			".article-content", ".story-content",
+		},
+		BlockedDomains: []string{
+			"doubleclick", "googlesyndication", "google-analytics", "facebook.com/tr", "taboola",
+			"outbrain", "scorecardresearch", "chartbeat", "amazon-adsystem",
+		},
+	}
+}
+
+// envOverrides lists the environment variables that take precedence over the configuration file
+var envOverrides = []struct {
+	name  string
+	apply func(s *Settings, value string) error
+}{
+	{"CHROME_MAJOR", func(s *Settings, v string) error { return setInt(&s.Scrape.ChromeMajor, v) }},
+	{"SCRAPE_USER_AGENT", func(s *Settings, v string) error { s.Scrape.UserAgent = v; return nil }},
+	{"SCRAPE_TIMEOUT_MS", func(s *Settings, v string) error { return setInt(&s.Scrape.TimeoutMs, v) }},
+	{"SCRAPE_SIZE_LIMIT_BYTES", func(s *Settings, v string) error { return setInt(&s.Scrape.SizeLimitBytes, v) }},
+	{"SCRAPE_MAX_SIZE_LIMIT_BYTES", func(s *Settings, v string) error { return setInt(&s.Scrape.MaxSizeLimitBytes, v) }},
+	{"SCRAPE_MAX_RETRIES", func(s *Settings, v string) error { return setInt(&s.Scrape.MaxRetries, v) }},
+	{"HTTP_TIMEOUT_MS", func(s *Settings, v string) error { return setInt(&s.Timeouts.HTTPMs, v) }},
+	{"BROWSER_TIMEOUT_MS", func(s *Settings, v string) error { return setInt(&s.Timeouts.BrowserMs, v) }},
+	{"HOST_MIN_INTERVAL_MS", func(s *Settings, v string) error { return setInt(&s.Politeness.MinIntervalMs, v) }},
+	{"HOST_MAX_CONCURRENT", func(s *Settings, v string) error { return setInt(&s.Politeness.MaxConcurrentPerHost, v) }},
+	{"ROBOTS_COMPLIANCE", func(s *Settings, v string) error { return setBool(&s.Robots.Enabled, v) }},
+	{"ROBOTS_USER_AGENT", func(s *Settings, v string) error { s.Robots.UserAgent = v; return nil }},
+}
+
+func setInt(dst *int, value string) error {
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		return fmt.Errorf("%q is not an integer", value)
+	}
+	*dst = parsed
+	return nil
+}
+
+func setBool(dst *bool, value string) error {
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return fmt.Errorf("%q is not true or false", value)
+	}
+	*dst = parsed
+	return nil
+}
+
+// Load reads the configuration file at path on top of the defaults, applies environment
+// overrides and validates the result
+// An empty path loads the defaults and environment overrides only
+// Files ending in .json are parsed as JSON; anything else as YAML
+func Load(path string) (*Settings, error) {
+	settings := DefaultSettings()
+	// Left empty so that a user agent is derived from the final Chrome version unless one is configured
+	settings.Scrape.UserAgent = ""
+
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading config: %w", err)
+		}
+		if err := decode(path, data, settings); err != nil {
+			return nil, fmt.Errorf("config %s: %w", path, err)
+		}
+	}
+
+	for _, override := range envOverrides {
+		if value := os.Getenv(override.name); value != "" {
+			if err := override.apply(settings, value); err != nil {
+				return nil, fmt.Errorf("environment variable %s: %w", override.name, err)
+			}
+		}
+	}
+	if settings.Scrape.UserAgent == "" {
+		settings.Scrape.UserAgent = chromeUserAgent(settings.Scrape.ChromeMajor)
+	}
+
+	if err := settings.Validate(); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+// decode parses data as JSON or YAML into settings, rejecting unknown fields
+func decode(path string, data []byte, settings *Settings) error {
+	// YAML is converted to JSON so both formats share the field names, custom types and checks of Settings
+	if !strings.HasSuffix(strings.ToLower(path), ".json") {
+		var tree interface{}
+		if err := yaml.Unmarshal(data, &tree); err != nil {
+			return errors.New(strings.TrimPrefix(err.Error(), "yaml: "))
+		}
+		if tree == nil {
+			return nil
+		}
+		if _, ok := tree.(map[string]interface{}); !ok {
+			return fmt.Errorf("top level must be a mapping")
+		}
+		var err error
+		if data, err = json.Marshal(tree); err != nil {
+			return err
+		}
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(string(data)))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(settings); err != nil {
+		// Drop the decoder's "json: " prefix, which is misleading for YAML files
+		return errors.New(strings.TrimPrefix(err.Error(), "json: "))
+	}
+	return nil
+}
+
+// robotsTokenPattern matches the product tokens robots.txt groups are addressed to
+var robotsTokenPattern = regexp.MustCompile(`^[A-Za-z_-]+$`)
+
+// adSizePattern matches the "WIDTHxHEIGHT" keys of ImageConfig.AdSizes
+var adSizePattern = regexp.MustCompile(`^\d+x\d+$`)
+
+// Validate reports every invalid setting
+func (s *Settings) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(s.Scrape.UserAgent != "", "scrape.userAgent must not be empty")
+	check(s.Scrape.TimeoutMs > 0, "scrape.timeoutMs must be positive")
+	check(s.Scrape.SizeLimitBytes > 0, "scrape.sizeLimitBytes must be positive")
+	check(s.Scrape.MaxSizeLimitBytes >= s.Scrape.SizeLimitBytes, "scrape.maxSizeLimitBytes must not be below scrape.sizeLimitBytes")
+	check(s.Scrape.MaxRetries >= 0, "scrape.maxRetries must not be negative")
+	check(s.Scrape.ChromeMajor > 0, "scrape.chromeMajor must be positive")
+
+	check(s.Image.MinShortSide >= 0, "image.minShortSide must not be negative")
+	check(s.Image.MinArea >= 0, "image.minArea must not be negative")
+	check(s.Image.MinAspect > 0 && s.Image.MinAspect < s.Image.MaxAspect, "image.minAspect must be positive and below image.maxAspect")
+	check(s.Image.RatioTol >= 0, "image.ratioTol must not be negative")
+	for _, ratio := range s.Image.RatioWhitelist {
+		check(ratio > 0, "image.ratioWhitelist: %v is not a positive ratio", ratio)
+	}
+	for size := range s.Image.AdSizes {
+		check(adSizePattern.MatchString(size), "image.adSizes: %q is not of the form WIDTHxHEIGHT", size)
+	}
+	if _, err := regexp.Compile("(?i)" + s.Image.BadHintRegex); err != nil {
+		errs = append(errs, fmt.Errorf("image.badHintRegex: %w", err))
+	}
+
+	check(s.Timeouts.HTTPMs > 0, "timeouts.httpMs must be positive")
+	check(s.Timeouts.BrowserMs > 0, "timeouts.browserMs must be positive")
+	check(s.Politeness.MinIntervalMs >= 0, "politeness.minIntervalMs must not be negative")
+	check(s.Politeness.MaxConcurrentPerHost >= 0, "politeness.maxConcurrentPerHost must not be negative")
+	check(robotsTokenPattern.MatchString(s.Robots.UserAgent), "robots.userAgent must be a product token of letters, \"-\" and \"_\"")
+
+	check(len(s.ContentSelectors) > 0, "contentSelectors must not be empty")
+	for _, selector := range s.ContentSelectors {
+		if _, err := cascadia.Compile(selector); err != nil {
+			errs = append(errs, fmt.Errorf("contentSelectors: %q: %w", selector, err))
+		}
+	}
+	for _, domain := range s.BlockedDomains {
+		check(strings.TrimSpace(domain) != "", "blockedDomains must not contain empty entries")
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid config: %w", errors.Join(errs...))
+	}
+	return nil
+}
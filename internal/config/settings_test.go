@@ -0,0 +1,158 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeConfig(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadYAML(t *testing.T) {
+	path := writeConfig(t, "scraper.yaml", `
+# Fetching
+scrape:
+  chromeMajor: 140
+  maxRetries: 0   # fail fast
+timeouts: {httpMs: 8000}
+politeness:
+  maxConcurrentPerHost: 1
+image:
+  ratioWhitelist: [1.5, 1.777]
+  adSizes:
+    - 728x90
+    - "300x250"
+contentSelectors:
+- "[data-qa='story']"
+- article
+blockedDomains: ['it''s-ads', "#tracker"]
+`)
+
+	settings, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	if settings.Scrape.ChromeMajor != 140 || settings.Scrape.MaxRetries != 0 {
+		t.Errorf("scrape = %+v, want chromeMajor 140 and maxRetries 0", settings.Scrape)
+	}
+	if !strings.Contains(settings.Scrape.UserAgent, "Chrome/140.") {
+		t.Errorf("user agent %q was not derived from chromeMajor", settings.Scrape.UserAgent)
+	}
+	if settings.Scrape.TimeoutMs != BaseScrapeConfig().TimeoutMs {
+		t.Errorf("timeoutMs = %d, want the default", settings.Scrape.TimeoutMs)
+	}
+	if settings.Timeouts.HTTP() != 8*time.Second || settings.Timeouts.BrowserMs != DefaultSettings().Timeouts.BrowserMs {
+		t.Errorf("timeouts = %+v", settings.Timeouts)
+	}
+	if settings.Politeness.MaxConcurrentPerHost != 1 || settings.Politeness.MinInterval() != 250*time.Millisecond {
+		t.Errorf("politeness = %+v, want maxConcurrentPerHost 1 and the default interval", settings.Politeness)
+	}
+	if !reflect.DeepEqual(settings.Image.RatioWhitelist, []float64{1.5, 1.777}) {
+		t.Errorf("ratioWhitelist = %v", settings.Image.RatioWhitelist)
+	}
+	if !reflect.DeepEqual(settings.Image.AdSizes, AdSizeSet{"728x90": true, "300x250": true}) {
+		t.Errorf("adSizes = %v, want the two configured sizes only", settings.Image.AdSizes)
+	}
+	if !reflect.DeepEqual(settings.ContentSelectors, []string{"[data-qa='story']", "article"}) {
+		t.Errorf("contentSelectors = %q", settings.ContentSelectors)
+	}
+	if !reflect.DeepEqual(settings.BlockedDomains, []string{"it's-ads", "#tracker"}) {
+		t.Errorf("blockedDomains = %q", settings.BlockedDomains)
+	}
+}
+
+func TestLoadJSONWithEnvOverrides(t *testing.T) {
+	path := writeConfig(t, "scraper.json", `{"scrape": {"userAgent": "from-file", "maxRetries": 1}, "timeouts": {"browserMs": 30000}}`)
+	t.Setenv("SCRAPE_USER_AGENT", "from-env")
+	t.Setenv("BROWSER_TIMEOUT_MS", "45000")
+	t.Setenv("ROBOTS_COMPLIANCE", "true")
+
+	settings, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if settings.Scrape.UserAgent != "from-env" || settings.Scrape.MaxRetries != 1 || settings.Timeouts.BrowserMs != 45000 {
+		t.Errorf("settings = %+v %+v, want environment to override the file", settings.Scrape, settings.Timeouts)
+	}
+	if !settings.Robots.Enabled || settings.Robots.UserAgent != "extract-html-scraper" {
+		t.Errorf("robots = %+v, want compliance enabled with the default token", settings.Robots)
+	}
+}
+
+func TestLoadRejectsInvalidConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		want    []string
+	}{
+		{"unknown field", "c.yaml", "scrape:\n  timeoutMillis: 5\n", []string{"timeoutMillis"}},
+		{"bad values", "c.yaml", "scrape:\n  timeoutMs: 0\n  maxSizeLimitBytes: 1000\npoliteness:\n  minIntervalMs: -1\nimage:\n  badHintRegex: \"(\"\n  adSizes: [big]\ncontentSelectors: [\"div[\"]\n",
+			[]string{"scrape.timeoutMs", "scrape.maxSizeLimitBytes", "politeness.minIntervalMs", "image.badHintRegex", "image.adSizes", "contentSelectors"}},
+		{"bad indentation", "c.yaml", "scrape:\n  timeoutMs: 5\n    maxRetries: 1\n", []string{"line 3"}},
+		{"wrong type", "c.json", `{"timeouts": {"httpMs": "fast"}}`, []string{"httpMs"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(writeConfig(t, tt.file, tt.content))
+			if err == nil {
+				t.Fatal("Load succeeded, want an error")
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error %q does not mention %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadRejectsInvalidEnv(t *testing.T) {
+	t.Setenv("CHROME_MAJOR", "latest")
+	if _, err := Load(""); err == nil || !strings.Contains(err.Error(), "CHROME_MAJOR") {
+		t.Fatalf("Load error = %v, want one naming CHROME_MAJOR", err)
+	}
+}
+
+func TestWatcherReloadKeepsPreviousSettingsWhenInvalid(t *testing.T) {
+	path := writeConfig(t, "scraper.yaml", "timeouts:\n  httpMs: 1000\n")
+	var applied []*Settings
+	w := NewWatcher(path, time.Hour, func(s *Settings) { applied = append(applied, s) })
+
+	if w.changed() {
+		t.Fatal("unchanged file reported as changed")
+	}
+	if err := os.WriteFile(path, []byte("timeouts:\n  httpMs: 2000\n  extra: 1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Reload(); err == nil {
+		t.Fatal("Reload accepted an invalid file")
+	}
+	if len(applied) != 0 {
+		t.Fatal("invalid settings were applied")
+	}
+
+	if err := os.WriteFile(path, []byte("timeouts:\n  httpMs: 2500\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if !w.changed() {
+		t.Fatal("rewritten file not reported as changed")
+	}
+	if err := w.Reload(); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	if len(applied) != 1 || applied[0].Timeouts.HTTPMs != 2500 {
+		t.Fatalf("applied = %v, want one reload with httpMs 2500", applied)
+	}
+}
@@ -0,0 +1,90 @@
+package config
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// Watcher reloads a configuration file when it changes on disk or when asked to
+// Invalid files are logged and ignored, leaving the previous settings in effect
+type Watcher struct {
+	path     string
+	interval time.Duration
+	apply    func(*Settings)
+
+	mu      sync.Mutex // Serializes reloads
+	modTime time.Time
+	size    int64
+}
+
+// NewWatcher creates a watcher that passes each valid reload of path to apply
+// The file's current state is taken as already applied
+func NewWatcher(path string, interval time.Duration, apply func(*Settings)) *Watcher {
+	w := &Watcher{path: path, interval: interval, apply: apply}
+	if info, err := os.Stat(path); err == nil {
+		w.modTime, w.size = info.ModTime(), info.Size()
+	}
+	return w
+}
+
+// Run checks the file every interval and reloads it whenever it changed or a value arrives on reload
+// (typically SIGHUP), until ctx is done
+func (w *Watcher) Run(ctx context.Context, reload <-chan os.Signal) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case sig := <-reload:
+			slog.Info("reloading configuration", "path", w.path, "signal", sig.String())
+			w.reload()
+		case <-ticker.C:
+			if w.changed() {
+				slog.Info("configuration file changed, reloading", "path", w.path)
+				w.reload()
+			}
+		}
+	}
+}
+
+// changed reports whether the file's modification time or size differs from the last load
+// A missing file, as seen briefly while it is replaced, is not a change
+func (w *Watcher) changed() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	info, err := os.Stat(w.path)
+	if err != nil {
+		return false
+	}
+	return !info.ModTime().Equal(w.modTime) || info.Size() != w.size
+}
+
+func (w *Watcher) reload() {
+	if err := w.Reload(); err != nil {
+		slog.Error("ignoring invalid configuration, previous settings remain in effect", "path", w.path, "error", err)
+		return
+	}
+	slog.Info("configuration reloaded", "path", w.path)
+}
+
+// Reload loads and validates the file and, if it is valid, passes the settings to apply
+func (w *Watcher) Reload() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	info, statErr := os.Stat(w.path)
+	settings, err := Load(w.path)
+	// The file is not re-read until it changes again, even when it was invalid
+	if statErr == nil {
+		w.modTime, w.size = info.ModTime(), info.Size()
+	}
+	if err != nil {
+		return err
+	}
+	w.apply(settings)
+	return nil
+}
@@ -0,0 +1,170 @@
+package jobs
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"syscall"
+	"time"
+
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/models"
+)
+
+// Callback delivery settings
+const (
+	callbackMaxAttempts = 3
+	callbackTimeout     = 10 * time.Second
+)
+
+// ErrCallbackDestination is returned for callback URLs reaching loopback, link-local, private or
+// other internal addresses, such as the metadata server at 169.254.169.254
+var ErrCallbackDestination = errors.New("callback URL must resolve to public addresses only")
+
+// internalPrefixes are ranges not covered by the netip predicates that are not reachable on the
+// internet: "this network", carrier-grade NAT and benchmarking addresses
+var internalPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+}
+
+// publicAddress reports whether addr may receive callbacks
+func publicAddress(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return false
+	}
+	for _, prefix := range internalPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// checkCallbackURL returns an error unless rawURL is an http(s) URL whose host resolves only
+// to public addresses
+func checkCallbackURL(ctx context.Context, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Hostname() == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return fmt.Errorf("invalid callback URL")
+	}
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", u.Hostname())
+	if err != nil {
+		return fmt.Errorf("callback host %s cannot be resolved", u.Hostname())
+	}
+	for _, addr := range addrs {
+		if !publicAddress(addr) {
+			return ErrCallbackDestination
+		}
+	}
+	return nil
+}
+
+// callbackSender posts finished jobs to their callback URLs
+type callbackSender struct {
+	client *http.Client
+	secret string
+}
+
+// newCallbackSender creates a sender whose connections are refused unless they reach a public
+// address, checked when dialing so DNS changes and redirects after submission cannot bypass it
+func newCallbackSender(secret string, allowPrivate bool) *callbackSender {
+	dialer := &net.Dialer{Timeout: callbackTimeout}
+	if !allowPrivate {
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil || !publicAddress(addrPort.Addr()) {
+				return ErrCallbackDestination
+			}
+			return nil
+		}
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dialer.DialContext
+	transport.Proxy = nil // A proxy would be the address dialed, hiding the callback host from the check
+
+	return &callbackSender{
+		client: &http.Client{Timeout: callbackTimeout, Transport: transport},
+		secret: secret,
+	}
+}
+
+// deliver posts the job as JSON, retrying with backoff on network errors and 5xx responses
+// Returns the number of attempts made
+func (c *callbackSender) deliver(ctx context.Context, job models.Job) (int, error) {
+	body, err := json.Marshal(job)
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode job: %w", err)
+	}
+
+	var lastErr error
+	for attempt := 1; attempt <= callbackMaxAttempts; attempt++ {
+		if attempt > 1 {
+			// Exponential backoff: 1s, 2s
+			backoff := time.Duration(1<<uint(attempt-2)) * time.Second
+			select {
+			case <-ctx.Done():
+				return attempt - 1, fmt.Errorf("callback canceled: %w", ctx.Err())
+			case <-time.After(backoff):
+			}
+		}
+
+		retry, err := c.post(ctx, job, body)
+		if err == nil {
+			return attempt, nil
+		}
+		lastErr = err
+		if !retry {
+			return attempt, err
+		}
+	}
+
+	return callbackMaxAttempts, lastErr
+}
+
+// post performs a single delivery attempt and reports whether a failure is worth retrying
+func (c *callbackSender) post(ctx context.Context, job models.Job, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", job.Callback.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("failed to create callback request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Job-ID", job.ID)
+	if c.secret != "" {
+		req.Header.Set("X-Signature-256", "sha256="+sign(c.secret, body))
+	}
+
+	resp, err := c.client.Do(req)
+	if errors.Is(err, ErrCallbackDestination) {
+		return false, ErrCallbackDestination
+	}
+	if err != nil {
+		return true, fmt.Errorf("callback request failed: %w", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests {
+		return true, fmt.Errorf("callback returned HTTP %d", resp.StatusCode)
+	}
+	if resp.StatusCode >= 300 {
+		return false, fmt.Errorf("callback returned HTTP %d", resp.StatusCode)
+	}
+	return false, nil
+}
+
+// sign computes the hex HMAC-SHA256 of body, letting receivers verify the sender
+func sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
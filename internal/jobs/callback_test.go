@@ -0,0 +1,60 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/models"
+)
+
+func TestCheckCallbackURLRejectsInternalDestinations(t *testing.T) {
+	tests := map[string]bool{ // URL: whether it may receive callbacks
+		"https://93.184.216.34/hook":                        true,
+		"https://[2606:2800:220:1:248:1893:25c8:1946]/hook": true,
+		"http://127.0.0.1:8080/hook":                        false,
+		"http://localhost/hook":                             false,
+		"http://169.254.169.254/computeMetadata/v1/":        false,
+		"http://10.1.2.3/hook":                              false,
+		"http://192.168.0.10/hook":                          false,
+		"http://100.64.0.1/hook":                            false,
+		"http://0.0.0.0/hook":                               false,
+		"http://[::1]/hook":                                 false,
+		"http://[fd00::1]/hook":                             false,
+		"http://[::ffff:127.0.0.1]/hook":                    false,
+	}
+	for callbackURL, allowed := range tests {
+		err := checkCallbackURL(context.Background(), callbackURL)
+		if allowed && err != nil {
+			t.Errorf("%s: %v, want it allowed", callbackURL, err)
+		}
+		if !allowed && !errors.Is(err, ErrCallbackDestination) {
+			t.Errorf("%s: %v, want ErrCallbackDestination", callbackURL, err)
+		}
+	}
+
+	if err := checkCallbackURL(context.Background(), "ftp://93.184.216.34/hook"); err == nil {
+		t.Error("ftp callback URL allowed")
+	}
+}
+
+func TestCallbackDeliveryRefusesInternalAddresses(t *testing.T) {
+	received := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received++
+	}))
+	defer server.Close()
+	job := models.Job{ID: "job-1", Callback: &models.JobCallback{URL: server.URL}}
+
+	attempts, err := newCallbackSender("", false).deliver(context.Background(), job)
+	if !errors.Is(err, ErrCallbackDestination) || attempts != 1 || received != 0 {
+		t.Fatalf("delivery to loopback: attempts %d, err %v, received %d; want one refused attempt", attempts, err, received)
+	}
+
+	attempts, err = newCallbackSender("", true).deliver(context.Background(), job)
+	if err != nil || attempts != 1 || received != 1 {
+		t.Fatalf("delivery with private callbacks allowed: attempts %d, err %v, received %d", attempts, err, received)
+	}
+}
@@ -0,0 +1,409 @@
+// Package jobs runs scrapes asynchronously on a bounded worker pool.
+// Jobs are kept in memory for polling, can be deduplicated with idempotency keys,
+// and optionally deliver their final state to a callback URL.
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/models"
+)
+
+// Errors returned by Manager.Submit
+var (
+	ErrQueueFull           = errors.New("job queue is full")
+	ErrClosed              = errors.New("job manager is shut down")
+	ErrIdempotencyMismatch = errors.New("idempotency key was already used with a different request")
+)
+
+// RunFunc performs the scrape for a job and returns its outcome
+type RunFunc func(ctx context.Context) models.ScrapeOutcome
+
+// Spec describes a job to submit
+type Spec struct {
+	Context        context.Context // Values, such as the request ID, for the job's logs and run; its cancellation is ignored
+	URL            string
+	CallbackURL    string
+	Owner          string // Jobs are only visible to, and deduplicated within, their owner
+	IdempotencyKey string
+	Fingerprint    string  // Identifies the request so a reused idempotency key can be detected
+	Run            RunFunc // Responsible for bounding its own duration
+}
+
+// Config contains settings for the job manager
+type Config struct {
+	Workers        int
+	MaxQueued      int
+	Retention      time.Duration // How long finished jobs are kept for polling
+	CallbackSecret string        // Signs callback bodies when set
+
+	// AllowPrivateCallbacks lets callbacks reach loopback, link-local and private addresses,
+	// for local development; otherwise they must resolve to public addresses
+	AllowPrivateCallbacks bool
+}
+
+// DefaultConfig returns the default job manager configuration
+func DefaultConfig() Config {
+	return Config{
+		Workers:   4,
+		MaxQueued: 100,
+		Retention: time.Hour,
+	}
+}
+
+type entry struct {
+	ctx         context.Context
+	job         models.Job
+	owner       string
+	fingerprint string
+	idemKey     string
+	run         RunFunc
+}
+
+// Manager owns the job store and the worker pool
+type Manager struct {
+	config    Config
+	mu        sync.RWMutex
+	jobs      map[string]*entry
+	idem      map[string]string // owner + idempotency key -> job ID
+	queue     chan string
+	ctx       context.Context
+	cancel    context.CancelFunc
+	stop      chan struct{} // Closed when the manager stops accepting and starting jobs
+	wg        sync.WaitGroup
+	closed    bool
+	callbacks *callbackSender
+}
+
+// NewManager creates a job manager and starts its workers
+func NewManager(cfg Config) *Manager {
+	if cfg.Workers < 1 {
+		cfg.Workers = 1
+	}
+	if cfg.MaxQueued < 1 {
+		cfg.MaxQueued = 1
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	m := &Manager{
+		config:    cfg,
+		jobs:      make(map[string]*entry),
+		idem:      make(map[string]string),
+		queue:     make(chan string, cfg.MaxQueued),
+		ctx:       ctx,
+		cancel:    cancel,
+		stop:      make(chan struct{}),
+		callbacks: newCallbackSender(cfg.CallbackSecret, cfg.AllowPrivateCallbacks),
+	}
+
+	for i := 0; i < cfg.Workers; i++ {
+		m.wg.Add(1)
+		go m.worker()
+	}
+
+	m.wg.Add(1)
+	go m.janitor()
+
+	return m
+}
+
+// Submit queues a new job, or returns the existing job for a reused idempotency key
+// The boolean result is true when a new job was created
+func (m *Manager) Submit(spec Spec) (models.Job, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.closed {
+		return models.Job{}, false, ErrClosed
+	}
+
+	idemKey := ""
+	if spec.IdempotencyKey != "" {
+		idemKey = spec.Owner + "\x00" + spec.IdempotencyKey
+		if existing, err := m.replayLocked(idemKey, spec.Fingerprint); existing != nil || err != nil {
+			if err != nil {
+				return models.Job{}, false, err
+			}
+			return copyJob(existing.job), false, nil
+		}
+	}
+
+	ctx := context.Background()
+	if spec.Context != nil {
+		ctx = context.WithoutCancel(spec.Context)
+	}
+	e := &entry{
+		ctx: ctx,
+		job: models.Job{
+			ID:        newJobID(),
+			State:     models.JobQueued,
+			URL:       spec.URL,
+			CreatedAt: time.Now(),
+		},
+		owner:       spec.Owner,
+		fingerprint: spec.Fingerprint,
+		idemKey:     idemKey,
+		run:         spec.Run,
+	}
+	if spec.CallbackURL != "" {
+		e.job.Callback = &models.JobCallback{URL: spec.CallbackURL}
+	}
+
+	select {
+	case m.queue <- e.job.ID:
+	default:
+		return models.Job{}, false, ErrQueueFull
+	}
+
+	m.jobs[e.job.ID] = e
+	if idemKey != "" {
+		m.idem[idemKey] = e.job.ID
+	}
+
+	slog.InfoContext(ctx, "job queued", "job_id", e.job.ID, "url", spec.URL)
+	return copyJob(e.job), true, nil
+}
+
+// Replay returns the job owner already submitted with idempotencyKey, if any, so a replayed
+// request can be answered before it is admitted; a different fingerprint is ErrIdempotencyMismatch
+func (m *Manager) Replay(owner, idempotencyKey, fingerprint string) (models.Job, bool, error) {
+	if idempotencyKey == "" {
+		return models.Job{}, false, nil
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	existing, err := m.replayLocked(owner+"\x00"+idempotencyKey, fingerprint)
+	if existing == nil {
+		return models.Job{}, false, err
+	}
+	return copyJob(existing.job), true, nil
+}
+
+// replayLocked returns the job stored under idemKey, or ErrIdempotencyMismatch when it was
+// submitted with a different fingerprint
+func (m *Manager) replayLocked(idemKey, fingerprint string) (*entry, error) {
+	existing, ok := m.jobs[m.idem[idemKey]]
+	if !ok {
+		return nil, nil
+	}
+	if existing.fingerprint != fingerprint {
+		return nil, ErrIdempotencyMismatch
+	}
+	return existing, nil
+}
+
+// CheckCallbackURL returns an error, safe to show to the submitter, unless rawURL is an http(s)
+// URL whose host resolves only to public addresses, or AllowPrivateCallbacks is set
+// Delivery checks the addresses again when connecting
+func (m *Manager) CheckCallbackURL(ctx context.Context, rawURL string) error {
+	if m.config.AllowPrivateCallbacks {
+		return nil
+	}
+	return checkCallbackURL(ctx, rawURL)
+}
+
+// Get returns a snapshot of a job if it exists and belongs to owner
+func (m *Manager) Get(id, owner string) (models.Job, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	e, ok := m.jobs[id]
+	if !ok || e.owner != owner {
+		return models.Job{}, false
+	}
+	return copyJob(e.job), true
+}
+
+// Close stops accepting jobs, cancels running ones and waits for the workers to exit
+func (m *Manager) Close(ctx context.Context) error {
+	m.stopIntake()
+	m.cancel()
+	return m.wait(ctx)
+}
+
+// Shutdown stops accepting and starting jobs, and lets running jobs finish and deliver
+// their callbacks until ctx expires, when they are cancelled
+// Jobs still queued are never started; they are lost with the process like the rest of the store
+func (m *Manager) Shutdown(ctx context.Context) error {
+	m.stopIntake()
+	if err := m.wait(ctx); err != nil {
+		m.cancel()
+		return err
+	}
+	m.cancel()
+	return nil
+}
+
+// stopIntake makes Submit fail with ErrClosed and stops workers from starting queued jobs
+func (m *Manager) stopIntake() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.closed {
+		m.closed = true
+		close(m.stop)
+	}
+}
+
+// wait blocks until the workers and janitor exit or ctx expires
+func (m *Manager) wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		m.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// worker runs queued jobs until the manager is closed
+func (m *Manager) worker() {
+	defer m.wg.Done()
+
+	for {
+		// Check stop first so a shutdown is not raced by a queued job
+		select {
+		case <-m.stop:
+			return
+		default:
+		}
+
+		select {
+		case <-m.stop:
+			return
+		case id := <-m.queue:
+			m.runJob(id)
+		}
+	}
+}
+
+// runJob executes a single job and delivers its callback
+func (m *Manager) runJob(id string) {
+	m.mu.Lock()
+	e, ok := m.jobs[id]
+	if !ok {
+		m.mu.Unlock()
+		return
+	}
+	startedAt := time.Now()
+	e.job.State = models.JobRunning
+	e.job.StartedAt = &startedAt
+	run := e.run
+	m.mu.Unlock()
+
+	slog.InfoContext(e.ctx, "job started", "job_id", id, "url", e.job.URL)
+
+	// The job keeps its own values but stops with the manager
+	ctx, cancel := context.WithCancel(e.ctx)
+	stop := context.AfterFunc(m.ctx, cancel)
+	outcome := run(ctx)
+	stop()
+	cancel()
+
+	m.mu.Lock()
+	completedAt := time.Now()
+	e.job.CompletedAt = &completedAt
+	e.job.ScrapeOutcome = &outcome
+	e.job.State = models.JobFailed
+	if outcome.Status == http.StatusOK {
+		e.job.State = models.JobSucceeded
+	}
+	e.run = nil // Release the closure and anything it captured
+	job := copyJob(e.job)
+	m.mu.Unlock()
+
+	slog.InfoContext(e.ctx, "job finished", "job_id", id, "url", job.URL, "state", job.State,
+		"status", outcome.Status, "duration", completedAt.Sub(startedAt))
+
+	if job.Callback != nil {
+		m.deliverCallback(e, job)
+	}
+}
+
+// deliverCallback posts the finished job to its callback URL and records the attempt
+func (m *Manager) deliverCallback(e *entry, job models.Job) {
+	attempts, err := m.callbacks.deliver(m.ctx, job)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e.job.Callback.Attempts = attempts
+	if err != nil {
+		e.job.Callback.Error = err.Error()
+		slog.WarnContext(e.ctx, "job callback failed", "job_id", job.ID, "url", job.URL, "attempts", attempts, "error", err)
+		return
+	}
+	deliveredAt := time.Now()
+	e.job.Callback.DeliveredAt = &deliveredAt
+	e.job.Callback.Error = ""
+	slog.InfoContext(e.ctx, "job callback delivered", "job_id", job.ID, "url", job.URL, "attempts", attempts)
+}
+
+// janitor periodically removes finished jobs older than the retention period
+func (m *Manager) janitor() {
+	defer m.wg.Done()
+
+	interval := m.config.Retention / 4
+	if interval < time.Minute {
+		interval = time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.stop:
+			return
+		case <-ticker.C:
+			m.removeExpired(time.Now().Add(-m.config.Retention))
+		}
+	}
+}
+
+// removeExpired deletes jobs that completed before cutoff
+func (m *Manager) removeExpired(cutoff time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for id, e := range m.jobs {
+		if e.job.CompletedAt != nil && e.job.CompletedAt.Before(cutoff) {
+			delete(m.jobs, id)
+			if e.idemKey != "" {
+				delete(m.idem, e.idemKey)
+			}
+		}
+	}
+}
+
+// copyJob returns a copy of a job that does not share mutable pointers with the store
+func copyJob(job models.Job) models.Job {
+	if job.Callback != nil {
+		callback := *job.Callback
+		job.Callback = &callback
+	}
+	return job
+}
+
+// newJobID generates a random job identifier
+func newJobID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		// crypto/rand never fails on supported platforms; fall back to time
+		return fmt.Sprintf("job-%d", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,229 @@
+package jobs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/logging"
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/models"
+)
+
+func TestSubmitDeduplicatesIdempotencyKeys(t *testing.T) {
+	m := NewManager(DefaultConfig())
+	defer m.Close(context.Background())
+
+	runs := make(chan struct{}, 10)
+	run := func(ctx context.Context) models.ScrapeOutcome {
+		runs <- struct{}{}
+		return models.ScrapeOutcome{Status: http.StatusOK, Result: &models.ScrapeResponse{Title: "done"}}
+	}
+
+	spec := Spec{URL: "https://example.com", Owner: "a", IdempotencyKey: "k1", Fingerprint: "f1", Run: run}
+	first, created, err := m.Submit(spec)
+	if err != nil || !created {
+		t.Fatalf("expected new job, got created=%v err=%v", created, err)
+	}
+
+	second, created, err := m.Submit(spec)
+	if err != nil || created || second.ID != first.ID {
+		t.Fatalf("expected replay of job %s, got %s created=%v err=%v", first.ID, second.ID, created, err)
+	}
+
+	if replayed, found, err := m.Replay("a", "k1", "f1"); err != nil || !found || replayed.ID != first.ID {
+		t.Fatalf("Replay = %s found=%v err=%v, want job %s", replayed.ID, found, err, first.ID)
+	}
+	if _, found, err := m.Replay("a", "k2", "f1"); err != nil || found {
+		t.Fatalf("Replay of an unused key: found=%v err=%v", found, err)
+	}
+
+	spec.Fingerprint = "f2"
+	if _, _, err := m.Submit(spec); !errors.Is(err, ErrIdempotencyMismatch) {
+		t.Fatalf("expected ErrIdempotencyMismatch, got %v", err)
+	}
+	if _, _, err := m.Replay("a", "k1", "f2"); !errors.Is(err, ErrIdempotencyMismatch) {
+		t.Fatalf("Replay: expected ErrIdempotencyMismatch, got %v", err)
+	}
+
+	// The same key from another owner is a different job
+	spec.Owner = "b"
+	third, created, err := m.Submit(spec)
+	if err != nil || !created || third.ID == first.ID {
+		t.Fatalf("expected a separate job for another owner, got created=%v err=%v", created, err)
+	}
+
+	deadline := time.After(2 * time.Second)
+	for {
+		job, ok := m.Get(first.ID, "a")
+		if !ok {
+			t.Fatalf("job %s not found", first.ID)
+		}
+		if job.State == models.JobSucceeded {
+			if job.Result == nil || job.Result.Title != "done" {
+				t.Fatalf("expected result to be stored, got %+v", job.ScrapeOutcome)
+			}
+			break
+		}
+		select {
+		case <-deadline:
+			t.Fatalf("job did not finish, state=%s", job.State)
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+
+	if _, ok := m.Get(first.ID, "b"); ok {
+		t.Fatalf("job must not be visible to another owner")
+	}
+	if len(runs) > 2 {
+		t.Fatalf("expected at most 2 runs, got %d", len(runs))
+	}
+}
+
+func TestShutdownDrainsRunningJobs(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Workers = 1
+	m := NewManager(cfg)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	run := func(ctx context.Context) models.ScrapeOutcome {
+		close(started)
+		select {
+		case <-release:
+			return models.ScrapeOutcome{Status: http.StatusOK}
+		case <-ctx.Done():
+			return models.ScrapeOutcome{Status: http.StatusGatewayTimeout}
+		}
+	}
+
+	running, _, err := m.Submit(Spec{URL: "https://example.com/1", Owner: "a", Run: run})
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-started
+	queued, _, err := m.Submit(Spec{URL: "https://example.com/2", Owner: "a", Run: run})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- m.Shutdown(context.Background()) }()
+
+	// New jobs are refused while running ones drain
+	deadline := time.After(2 * time.Second)
+	for {
+		if _, _, err := m.Submit(Spec{URL: "https://example.com/3", Owner: "a", Run: run}); errors.Is(err, ErrClosed) {
+			break
+		}
+		select {
+		case <-deadline:
+			t.Fatal("Submit still accepts jobs during shutdown")
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatalf("Shutdown returned %v", err)
+	}
+	if job, _ := m.Get(running.ID, "a"); job.State != models.JobSucceeded {
+		t.Fatalf("expected running job to finish, got %s", job.State)
+	}
+	if job, _ := m.Get(queued.ID, "a"); job.State != models.JobQueued {
+		t.Fatalf("expected queued job not to start, got %s", job.State)
+	}
+}
+
+func TestShutdownCancelsJobsAfterGracePeriod(t *testing.T) {
+	m := NewManager(DefaultConfig())
+
+	started := make(chan struct{})
+	run := func(ctx context.Context) models.ScrapeOutcome {
+		close(started)
+		<-ctx.Done()
+		return models.ScrapeOutcome{Status: http.StatusGatewayTimeout}
+	}
+	job, _, err := m.Submit(Spec{URL: "https://example.com", Owner: "a", Run: run})
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := m.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected DeadlineExceeded, got %v", err)
+	}
+	if err := m.Close(context.Background()); err != nil {
+		t.Fatalf("Close returned %v", err)
+	}
+	if got, _ := m.Get(job.ID, "a"); got.State != models.JobFailed {
+		t.Fatalf("expected cancelled job to fail, got %s", got.State)
+	}
+}
+
+func TestJobLogsCarryTheSubmittingRequestID(t *testing.T) {
+	var buf syncBuffer
+	previous := slog.Default()
+	slog.SetDefault(logging.New(&buf, slog.LevelInfo, "json"))
+	defer slog.SetDefault(previous)
+
+	m := NewManager(DefaultConfig())
+	ctx := logging.WithRequestID(context.Background(), "req-123")
+	ran := make(chan string, 1)
+	job, _, err := m.Submit(Spec{
+		Context: ctx,
+		URL:     "https://example.com",
+		Run: func(ctx context.Context) models.ScrapeOutcome {
+			ran <- logging.RequestID(ctx)
+			return models.ScrapeOutcome{Status: http.StatusOK}
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id := <-ran; id != "req-123" {
+		t.Errorf("run context request ID = %q, want req-123", id)
+	}
+	m.Close(context.Background())
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	for _, msg := range []string{"job queued", "job started", "job finished"} {
+		found := false
+		for _, line := range lines {
+			if strings.Contains(line, `"msg":"`+msg+`"`) {
+				found = true
+				if !strings.Contains(line, `"request_id":"req-123"`) || !strings.Contains(line, `"job_id":"`+job.ID+`"`) {
+					t.Errorf("%s: log line lacks the request or job ID: %s", msg, line)
+				}
+			}
+		}
+		if !found {
+			t.Errorf("no %q log line in:\n%s", msg, buf.String())
+		}
+	}
+}
+
+// syncBuffer is a bytes.Buffer safe for the concurrent writes of the job workers
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
@@ -0,0 +1,67 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestNewAddsRequestIDFromContext(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, slog.LevelInfo, "json").With("component", "test")
+
+	logger.DebugContext(context.Background(), "hidden")
+	logger.InfoContext(WithRequestID(context.Background(), "req-1"), "visible", "n", 1)
+
+	var record map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("expected exactly one JSON record, got %q: %v", buf.String(), err)
+	}
+	if record[RequestIDKey] != "req-1" || record["component"] != "test" || record["msg"] != "visible" {
+		t.Errorf("unexpected record %v", record)
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	cases := map[string]slog.Level{
+		"debug":   slog.LevelDebug,
+		" WARN ":  slog.LevelWarn,
+		"error":   slog.LevelError,
+		"":        slog.LevelInfo,
+		"verbose": slog.LevelInfo,
+	}
+	for value, want := range cases {
+		if got := ParseLevel(value); got != want {
+			t.Errorf("ParseLevel(%q) = %v, want %v", value, got, want)
+		}
+	}
+}
+
+func TestNewRedactsAPIKeys(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, slog.LevelInfo, "text")
+
+	secret := "sk_0123456789abcdef0123456789abcdef"
+	logger.Info("request /?url=https://example.com&key=plain-secret",
+		"target", "https://example.com/?a=1&api_key=plain-secret#top",
+		"error", errors.New(`Get "https://example.com/?token=plain-secret": EOF`),
+		"header", "Bearer plain-secret",
+		"authorization", "Basic plain-secret",
+		"generated", "found "+secret,
+		"path", "/v1/extract",
+	)
+
+	out := buf.String()
+	if strings.Contains(out, "plain-secret") || strings.Contains(out, secret) {
+		t.Fatalf("secret left in log line: %s", out)
+	}
+	for _, want := range []string{"url=https://example.com&key=REDACTED", "api_key=REDACTED#top", "Bearer REDACTED", "path=/v1/extract"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("log line missing %q: %s", want, out)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package logging
+
+import (
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+// Redacted replaces secrets removed from log output
+const Redacted = "REDACTED"
+
+// secretAttrs are attribute names whose whole value is a secret
+var secretAttrs = map[string]bool{
+	"key":           true,
+	"api_key":       true,
+	"apikey":        true,
+	"x-api-key":     true,
+	"authorization": true,
+	"token":         true,
+	"secret":        true,
+}
+
+// secretPatterns match secrets inside larger strings such as URLs and error messages,
+// keeping the text before the secret itself in the first group
+var secretPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)([?&;](?:key|api_key|apikey|api-key|access_token|token)=)[^&#\s"']+`),
+	regexp.MustCompile(`(?i)(\bbearer\s+)[^\s"',]+`),
+	regexp.MustCompile(`(?i)(x-api-key:\s*)[^\s"',]+`),
+	regexp.MustCompile(`(\bsk_)[0-9a-f]{16,}`), // Keys generated by cmd/apikey
+}
+
+// Redact replaces API keys and bearer tokens in s with Redacted
+func Redact(s string) string {
+	if !strings.ContainsAny(s, "=:_ ") {
+		return s
+	}
+	for _, pattern := range secretPatterns {
+		s = pattern.ReplaceAllString(s, "${1}"+Redacted)
+	}
+	return s
+}
+
+// RedactAttr removes secrets from an attribute; it suits slog.HandlerOptions.ReplaceAttr
+func RedactAttr(groups []string, a slog.Attr) slog.Attr {
+	if secretAttrs[strings.ToLower(a.Key)] {
+		return slog.String(a.Key, Redacted)
+	}
+	var text string
+	switch a.Value.Kind() {
+	case slog.KindString:
+		text = a.Value.String()
+	case slog.KindAny:
+		// Errors and URLs render as text that may quote a request URL
+		switch v := a.Value.Any().(type) {
+		case error:
+			text = v.Error()
+		case fmt.Stringer:
+			text = v.String()
+		default:
+			return a
+		}
+	default:
+		return a
+	}
+	if redacted := Redact(text); redacted != text {
+		return slog.String(a.Key, redacted)
+	}
+	return a
+}
@@ -0,0 +1,111 @@
+package metrics
+
+import (
+	"math"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+)
+
+// scrape fetches r's metrics in the text format and parses them
+func scrape(t *testing.T, r *Registry) map[string]*dto.MetricFamily {
+	t.Helper()
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/metrics", nil)
+	req.Header.Set("Accept", "text/plain")
+	r.Handler().ServeHTTP(w, req)
+
+	if format := expfmt.ResponseFormat(w.Result().Header); format.FormatType() != expfmt.TypeTextPlain {
+		t.Fatalf("served %q, want the text format", format)
+	}
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(w.Body)
+	if err != nil {
+		t.Fatalf("invalid exposition: %v", err)
+	}
+	return families
+}
+
+// labels returns the label pairs of m as a map
+func labels(m *dto.Metric) map[string]string {
+	out := make(map[string]string)
+	for _, pair := range m.GetLabel() {
+		out[pair.GetName()] = pair.GetValue()
+	}
+	return out
+}
+
+func TestRegistryExposition(t *testing.T) {
+	r := NewRegistry()
+	fetches := r.NewCounterVec("test_fetch_total", "Fetches.", "fetcher", "outcome")
+	durations := r.NewHistogramVec("test_duration_seconds", "Durations.", []float64{1, 0.5}, "fetcher")
+
+	fetches.Inc("http", "success")
+	fetches.Add(2, "browser", `bad"quote`)
+	fetches.Add(-1, "http", "success")
+	fetches.Inc("archive") // The missing outcome is empty
+	durations.Observe(0.2, "http")
+	durations.Observe(0.7, "http")
+	durations.Observe(3, "http")
+
+	families := scrape(t, r)
+
+	counter := families["test_fetch_total"]
+	if counter.GetType() != dto.MetricType_COUNTER || counter.GetHelp() != "Fetches." {
+		t.Fatalf("test_fetch_total = %v, want a counter with its help", counter)
+	}
+	counts := make(map[string]float64)
+	for _, m := range counter.GetMetric() {
+		l := labels(m)
+		counts[l["fetcher"]+"/"+l["outcome"]] = m.GetCounter().GetValue()
+	}
+	want := map[string]float64{"http/success": 1, `browser/bad"quote`: 2, "archive/": 1}
+	if len(counts) != len(want) {
+		t.Errorf("counter series = %v, want %v", counts, want)
+	}
+	for series, value := range want {
+		if counts[series] != value {
+			t.Errorf("test_fetch_total{%s} = %v, want %v", series, counts[series], value)
+		}
+	}
+
+	histogram := families["test_duration_seconds"]
+	if histogram.GetType() != dto.MetricType_HISTOGRAM || len(histogram.GetMetric()) != 1 {
+		t.Fatalf("test_duration_seconds = %v, want one histogram", histogram)
+	}
+	h := histogram.GetMetric()[0].GetHistogram()
+	if h.GetSampleCount() != 3 || h.GetSampleSum() != 3.9 {
+		t.Errorf("count %d sum %v, want 3 and 3.9", h.GetSampleCount(), h.GetSampleSum())
+	}
+	var bounds []float64
+	var cumulative []uint64
+	for _, b := range h.GetBucket() {
+		bounds = append(bounds, b.GetUpperBound())
+		cumulative = append(cumulative, b.GetCumulativeCount())
+	}
+	if !slices.Equal(bounds, []float64{0.5, 1, math.Inf(1)}) || !slices.Equal(cumulative, []uint64{1, 2, 3}) {
+		t.Errorf("buckets %v with cumulative counts %v, want [0.5 1 +Inf] and [1 2 3]", bounds, cumulative)
+	}
+
+	if got := fetches.Value("http", "success"); got != 1 {
+		t.Errorf("Value() = %v, want 1", got)
+	}
+	if got := durations.Count("http"); got != 3 {
+		t.Errorf("Count() = %v, want 3", got)
+	}
+}
+
+func TestPipelineMetricsExposition(t *testing.T) {
+	FetchTotal.Inc("1", "http", OutcomeSuccess)
+	FetchDuration.Observe(0.3, "1", "http", OutcomeSuccess)
+
+	families := scrape(t, Default)
+	for _, name := range []string{"scraper_fetch_total", "scraper_fetch_duration_seconds"} {
+		if _, ok := families[name]; !ok {
+			t.Errorf("%s is not exposed", name)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package metrics
+
+// Default is the registry holding the scraping pipeline metrics
+var Default = NewRegistry()
+
+// Fetch outcomes recorded by FetchTotal and FetchDuration
+const (
+	OutcomeSuccess       = "success"        // The fetcher's page yielded an article
+	OutcomeFetchError    = "fetch_error"    // The fetcher returned an error
+	OutcomeExtractFailed = "extract_failed" // The page was fetched but no article could be extracted
+	OutcomeSkipped       = "skipped"        // Not enough time budget was left to try the fetcher
+)
+
+// durationBuckets cover fast HTTP fetches up to the 5 minute Cloud Run limit, in seconds
+var durationBuckets = []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 20, 30, 60, 120, 300}
+
+// qualityBuckets cover the 0-100 content quality score plus the structured data bonus
+var qualityBuckets = []float64{10, 20, 30, 40, 50, 60, 70, 80, 90, 100, 110}
+
+// Pipeline metrics
+var (
+	// FetchTotal counts fetch attempts by phase (1-based position in the fetcher order), fetcher and outcome
+	FetchTotal = Default.NewCounterVec("scraper_fetch_total",
+		"Fetch attempts by phase, fetcher and outcome.", "phase", "fetcher", "outcome")
+
+	// FetchDuration observes the time spent in each fetch attempt
+	FetchDuration = Default.NewHistogramVec("scraper_fetch_duration_seconds",
+		"Time spent fetching a page, by phase, fetcher and outcome.", durationBuckets, "phase", "fetcher", "outcome")
+
+	// AlternateURLHits counts pages served from an AMP, mobile or other alternate URL instead of the original
+	AlternateURLHits = Default.NewCounterVec("scraper_alternate_url_hits_total",
+		"Pages successfully fetched from an alternate URL, by fetcher.", "fetcher")
+
+	// ExtractionStrategyTotal counts which extraction strategy produced the selected result
+	ExtractionStrategyTotal = Default.NewCounterVec("scraper_extraction_strategy_total",
+		"Extractions by winning strategy.", "strategy")
+
+	// QualityScore observes the quality score of selected extraction results
+	QualityScore = Default.NewHistogramVec("scraper_quality_score",
+		"Content quality score of selected extraction results, by strategy.", qualityBuckets, "strategy")
+
+	// BlockTotal counts pages blocked by site protection, by domain and fetcher
+	// Domains are unbounded; the set stays small in practice because only blocking sites appear
+	BlockTotal = Default.NewCounterVec("scraper_block_detections_total",
+		"Fetches blocked by site protection, by domain and fetcher.", "domain", "fetcher")
+
+	// HostQueueWait observes how long fetches queued behind other fetches of the same site
+	HostQueueWait = Default.NewHistogramVec("scraper_host_queue_wait_seconds",
+		"Time fetches waited for the per-site politeness limits, by fetcher.", durationBuckets, "fetcher")
+
+	// CacheTotal counts scrape requests by cache result ("hit", "revalidated", "refreshed" or "miss")
+	CacheTotal = Default.NewCounterVec("scraper_cache_total",
+		"Scrape requests by cache result.", "result")
+
+	// RateLimitRejections counts requests refused with 429, by API key ID and reason ("rate" or "concurrency")
+	RateLimitRejections = Default.NewCounterVec("scraper_rate_limit_rejections_total",
+		"Requests rejected by per-key limits, by key and reason.", "key", "reason")
+)
@@ -1,7 +1,38 @@
 // Package models defines typed errors for better error handling and context.
 package models
 
-import "fmt"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+)
+
+// Error codes returned in ErrorResponse.Code so clients can branch without parsing messages
+const (
+	CodeInvalidRequest      = "invalid_request"
+	CodeInvalidURL          = "invalid_url"
+	CodeUnauthorized        = "unauthorized"
+	CodeNotFound            = "not_found"
+	CodeMethodNotAllowed    = "method_not_allowed"
+	CodeConflict            = "conflict"
+	CodeRequestTooLarge     = "request_too_large"
+	CodeRateLimited         = "rate_limited"
+	CodeUnavailable         = "unavailable"
+	CodeCanceled            = "canceled"
+	CodeTimeout             = "timeout"
+	CodeBlocked             = "blocked"
+	CodeDisallowedByRobots  = "disallowed_by_robots"
+	CodeUpstreamForbidden   = "upstream_forbidden"
+	CodeUpstreamNotFound    = "upstream_not_found"
+	CodeUpstreamRateLimited = "upstream_rate_limited"
+	CodeUpstreamServerError = "upstream_server_error"
+	CodeUpstreamError       = "upstream_error"
+	CodeUpstreamUnreachable = "upstream_unreachable"
+	CodeNonHTML             = "non_html"
+	CodeExtractionEmpty     = "extraction_empty"
+	CodeInternal            = "internal"
+)
 
 // CloudflareBlockError represents a Cloudflare blocking error
 type CloudflareBlockError struct {
@@ -13,6 +44,23 @@ func (e *CloudflareBlockError) Error() string {
 	return fmt.Sprintf("blocked by Cloudflare on domain %s: %v", e.Domain, e.Err)
 }
 
+func (e *CloudflareBlockError) Unwrap() error { return e.Err }
+
+// RobotsDisallowedError reports that robots.txt does not allow UserAgent to fetch URL
+// Reason says why when it is not a Disallow rule, such as robots.txt being unreachable
+type RobotsDisallowedError struct {
+	URL       string
+	UserAgent string
+	Reason    string
+}
+
+func (e *RobotsDisallowedError) Error() string {
+	if e.Reason != "" {
+		return fmt.Sprintf("robots.txt disallows %s for %s: %s", e.URL, e.UserAgent, e.Reason)
+	}
+	return fmt.Sprintf("robots.txt disallows %s for %s", e.URL, e.UserAgent)
+}
+
 // TimeoutError represents a timeout error
 type TimeoutError struct {
 	Operation string
@@ -24,6 +72,8 @@ func (e *TimeoutError) Error() string {
 	return fmt.Sprintf("timeout during %s after %s: %v", e.Operation, e.Timeout, e.Err)
 }
 
+func (e *TimeoutError) Unwrap() error { return e.Err }
+
 // InvalidURLError represents an invalid URL error
 type InvalidURLError struct {
 	URL string
@@ -34,6 +84,8 @@ func (e *InvalidURLError) Error() string {
 	return fmt.Sprintf("invalid URL %s: %v", e.URL, e.Err)
 }
 
+func (e *InvalidURLError) Unwrap() error { return e.Err }
+
 // HTTPError represents an HTTP-related error
 type HTTPError struct {
 	StatusCode int
@@ -45,6 +97,18 @@ func (e *HTTPError) Error() string {
 	return fmt.Sprintf("HTTP %d for URL %s: %v", e.StatusCode, e.URL, e.Err)
 }
 
+func (e *HTTPError) Unwrap() error { return e.Err }
+
+// NonHTMLError represents a response whose content type is not HTML
+type NonHTMLError struct {
+	URL         string
+	ContentType string
+}
+
+func (e *NonHTMLError) Error() string {
+	return fmt.Sprintf("non-HTML content-type %q for URL %s", e.ContentType, e.URL)
+}
+
 // ContentExtractionError represents an error during content extraction
 type ContentExtractionError struct {
 	Step string
@@ -54,3 +118,88 @@ type ContentExtractionError struct {
 func (e *ContentExtractionError) Error() string {
 	return fmt.Sprintf("content extraction failed at %s: %v", e.Step, e.Err)
 }
+
+func (e *ContentExtractionError) Unwrap() error { return e.Err }
+
+// FetchFailedError reports that every fetcher failed; Errs holds each fetcher's error in order
+type FetchFailedError struct {
+	Errs []error
+}
+
+func (e *FetchFailedError) Error() string {
+	var last error
+	if len(e.Errs) > 0 {
+		last = e.Errs[len(e.Errs)-1]
+	}
+	return fmt.Sprintf("scraping failed - all %d fetcher(s) failed, last error: %v", len(e.Errs), last)
+}
+
+func (e *FetchFailedError) Unwrap() []error { return e.Errs }
+
+// ErrorCode classifies err into one of the Code constants
+// When several fetchers failed differently, the most specific cause wins: a block, then an
+// upstream HTTP status, then timeouts, content type, extraction and network failures
+func ErrorCode(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	var (
+		invalidURL *InvalidURLError
+		blocked    *CloudflareBlockError
+		robots     *RobotsDisallowedError
+		httpErr    *HTTPError
+		timeout    *TimeoutError
+		nonHTML    *NonHTMLError
+		extraction *ContentExtractionError
+		netErr     net.Error
+		opErr      *net.OpError
+		dnsErr     *net.DNSError
+	)
+	switch {
+	case errors.As(err, &invalidURL):
+		return CodeInvalidURL
+	case errors.As(err, &blocked):
+		return CodeBlocked
+	case errors.As(err, &robots):
+		return CodeDisallowedByRobots
+	case errors.Is(err, context.Canceled):
+		return CodeCanceled
+	case errors.As(err, &httpErr):
+		return upstreamStatusCode(httpErr.StatusCode)
+	case errors.As(err, &timeout), errors.Is(err, context.DeadlineExceeded),
+		errors.As(err, &netErr) && netErr.Timeout():
+		return CodeTimeout
+	case errors.As(err, &nonHTML):
+		return CodeNonHTML
+	case errors.As(err, &extraction):
+		return CodeExtractionEmpty
+	case errors.As(err, &opErr), errors.As(err, &dnsErr):
+		return CodeUpstreamUnreachable
+	}
+	return CodeInternal
+}
+
+// UpstreamStatus returns the HTTP status of the first upstream HTTP error in err, or 0
+func UpstreamStatus(err error) int {
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode
+	}
+	return 0
+}
+
+// upstreamStatusCode maps an upstream HTTP status to an error code
+func upstreamStatusCode(status int) string {
+	switch {
+	case status == 401 || status == 403:
+		return CodeUpstreamForbidden
+	case status == 404 || status == 410:
+		return CodeUpstreamNotFound
+	case status == 429:
+		return CodeUpstreamRateLimited
+	case status >= 500:
+		return CodeUpstreamServerError
+	}
+	return CodeUpstreamError
+}
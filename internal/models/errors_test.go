@@ -0,0 +1,53 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+)
+
+func TestErrorCode(t *testing.T) {
+	notFound := &HTTPError{StatusCode: 404, URL: "https://example.com", Err: errors.New("Not Found")}
+
+	tests := []struct {
+		name     string
+		err      error
+		code     string
+		upstream int
+	}{
+		{"forbidden", &HTTPError{StatusCode: 403}, CodeUpstreamForbidden, 403},
+		{"not found wrapped", fmt.Errorf("HTTP fetch failed: %w", notFound), CodeUpstreamNotFound, 404},
+		{"rate limited", &HTTPError{StatusCode: 429}, CodeUpstreamRateLimited, 429},
+		{"server error", &HTTPError{StatusCode: 503}, CodeUpstreamServerError, 503},
+		{"other status", &HTTPError{StatusCode: 418}, CodeUpstreamError, 418},
+		{"timeout", &TimeoutError{Operation: "fetch", Err: context.DeadlineExceeded}, CodeTimeout, 0},
+		{"deadline", fmt.Errorf("navigation failed: %w", context.DeadlineExceeded), CodeTimeout, 0},
+		{"canceled", fmt.Errorf("parent context expired: %w", context.Canceled), CodeCanceled, 0},
+		{"non html", &NonHTMLError{ContentType: "application/pdf"}, CodeNonHTML, 0},
+		{"extraction", &ContentExtractionError{Step: "extract", Err: errors.New("empty")}, CodeExtractionEmpty, 0},
+		{"unreachable", fmt.Errorf("request failed: %w", &net.OpError{Op: "dial", Err: errors.New("refused")}), CodeUpstreamUnreachable, 0},
+		{"invalid url", &InvalidURLError{URL: "::", Err: errors.New("bad")}, CodeInvalidURL, 0},
+		{"robots", fmt.Errorf("request failed: %w", &RobotsDisallowedError{URL: "https://example.com/private"}), CodeDisallowedByRobots, 0},
+		{"unknown", errors.New("chrome crashed"), CodeInternal, 0},
+		// A block wins over the upstream status that revealed it, which is still reported
+		{"blocked", &CloudflareBlockError{Domain: "example.com", Err: &HTTPError{StatusCode: 403}}, CodeBlocked, 403},
+		// Every fetcher's error is considered, so an HTTP status is not hidden by a later browser failure
+		{"all fetchers", &FetchFailedError{Errs: []error{notFound, errors.New("chrome crashed")}}, CodeUpstreamNotFound, 404},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code := ErrorCode(tt.err); code != tt.code {
+				t.Errorf("ErrorCode = %q, want %q", code, tt.code)
+			}
+			if status := UpstreamStatus(tt.err); status != tt.upstream {
+				t.Errorf("UpstreamStatus = %d, want %d", status, tt.upstream)
+			}
+		})
+	}
+
+	if code := ErrorCode(nil); code != "" {
+		t.Errorf("ErrorCode(nil) = %q, want empty", code)
+	}
+}
@@ -28,39 +28,189 @@ type Image struct {
 
 // ScrapeResponse represents the successful scraping result
 type ScrapeResponse struct {
-	Title       string   `json:"title,omitempty"`
-	Description string   `json:"description,omitempty"`
-	Content     string   `json:"content,omitempty"`
-	Images      []Image  `json:"images"`
-	Metadata    Metadata `json:"metadata"`
-	Author      string   `json:"author,omitempty"`
-	PublishDate string   `json:"publishDate,omitempty"`
-	Excerpt     string   `json:"excerpt,omitempty"`
-	ReadingTime int      `json:"readingTime,omitempty"`
-	Language    string   `json:"language,omitempty"`
-	TextLength  int      `json:"textLength,omitempty"`
-	Quality     Quality  `json:"quality,omitempty"`
+	Title       string      `json:"title,omitempty"`
+	Description string      `json:"description,omitempty"`
+	Content     string      `json:"content,omitempty"`
+	Images      []Image     `json:"images"`
+	Metadata    Metadata    `json:"metadata"`
+	Author      string      `json:"author,omitempty"`
+	PublishDate string      `json:"publishDate,omitempty"`
+	Excerpt     string      `json:"excerpt,omitempty"`
+	ReadingTime int         `json:"readingTime,omitempty"`
+	Language    string      `json:"language,omitempty"`
+	TextLength  int         `json:"textLength,omitempty"`
+	Quality     Quality     `json:"quality,omitempty"`
+	Debug       *DebugTrace `json:"debug,omitempty"` // Set when the request asked for debug output
 }
 
 // BlockedResponse represents when scraping is blocked
 type BlockedResponse struct {
-	Error    string   `json:"error"`
-	Provider string   `json:"provider"`
-	Domain   string   `json:"domain"`
-	Metadata Metadata `json:"metadata"`
+	Error    string      `json:"error"`
+	Code     string      `json:"code"` // Always CodeBlocked
+	Provider string      `json:"provider"`
+	Domain   string      `json:"domain"`
+	Metadata Metadata    `json:"metadata"`
+	Debug    *DebugTrace `json:"debug,omitempty"`
+}
+
+// HealthResponse is returned by the health and readiness endpoints
+type HealthResponse struct {
+	Status  string         `json:"status"` // "ok" or "unavailable"
+	Version string         `json:"version"`
+	Chrome  *ChromeCheck   `json:"chrome,omitempty"`
+	Limits  *ServiceLimits `json:"limits,omitempty"`
+}
+
+// ChromeCheck is the result of starting Chrome and rendering a test page
+type ChromeCheck struct {
+	OK         bool      `json:"ok"`
+	Error      string    `json:"error,omitempty"`
+	DurationMs int64     `json:"durationMs"`
+	CheckedAt  time.Time `json:"checkedAt"`
+}
+
+// ServiceLimits reports the limits the service is configured with
+type ServiceLimits struct {
+	DefaultTimeoutMs    int   `json:"defaultTimeoutMs"`
+	MaxTimeoutMs        int   `json:"maxTimeoutMs"`
+	DefaultPageBytes    int   `json:"defaultPageBytes"`    // Reading a page may stop past this once its article is in
+	MaxPageBytes        int   `json:"maxPageBytes"`        // Fetched HTML beyond this is truncated
+	MaxRequestBodyBytes int64 `json:"maxRequestBodyBytes"` // JSON request bodies
+	MaxHTMLBodyBytes    int64 `json:"maxHtmlBodyBytes"`    // POST /v1/extract/html bodies
+	BatchMaxURLs        int   `json:"batchMaxUrls"`
+	BatchMaxConcurrency int   `json:"batchMaxConcurrency"`
+	JobsWorkers         int   `json:"jobsWorkers"`
+	JobsMaxQueued       int   `json:"jobsMaxQueued"`
 }
 
 // ErrorResponse represents error responses
 type ErrorResponse struct {
-	Error   string `json:"error"`
-	Details string `json:"details,omitempty"`
+	Error          string      `json:"error"`
+	Code           string      `json:"code"`                     // One of the Code constants
+	UpstreamStatus int         `json:"upstreamStatus,omitempty"` // HTTP status returned by the target site, when known
+	QueueWaitMs    int64       `json:"queueWaitMs,omitempty"`    // Time spent queued behind other fetches of the same site
+	Details        string      `json:"details,omitempty"`
+	Debug          *DebugTrace `json:"debug,omitempty"`
+}
+
+// ScrapeOutcome is the result of scraping a single URL
+// Exactly one of Result, Error or Blocked is set, matching Status
+type ScrapeOutcome struct {
+	Status  int              `json:"status"`
+	Result  *ScrapeResponse  `json:"result,omitempty"`
+	Error   *ErrorResponse   `json:"error,omitempty"`
+	Blocked *BlockedResponse `json:"blocked,omitempty"`
+}
+
+// BatchResult represents the outcome for a single URL of a batch request
+type BatchResult struct {
+	URL string `json:"url"`
+	ScrapeOutcome
+}
+
+// BatchResponse represents the combined result of a batch request
+type BatchResponse struct {
+	Results    []BatchResult `json:"results"`
+	Succeeded  int           `json:"succeeded"`
+	Failed     int           `json:"failed"`
+	DurationMs int64         `json:"durationMs"`
+}
+
+// JobState represents the lifecycle state of an asynchronous job
+type JobState string
+
+// Job lifecycle states
+const (
+	JobQueued    JobState = "queued"
+	JobRunning   JobState = "running"
+	JobSucceeded JobState = "succeeded"
+	JobFailed    JobState = "failed"
+)
+
+// Job represents an asynchronous scrape job
+// Once the job has finished, the embedded outcome carries the HTTP status and
+// the result, error or blocked response the synchronous API would have returned
+type Job struct {
+	ID          string       `json:"id"`
+	State       JobState     `json:"state"`
+	URL         string       `json:"url"`
+	CreatedAt   time.Time    `json:"createdAt"`
+	StartedAt   *time.Time   `json:"startedAt,omitempty"`
+	CompletedAt *time.Time   `json:"completedAt,omitempty"`
+	Callback    *JobCallback `json:"callback,omitempty"`
+	*ScrapeOutcome
+}
+
+// JobCallback reports delivery of a job result to its callback URL
+type JobCallback struct {
+	URL         string     `json:"url"`
+	Attempts    int        `json:"attempts"`
+	DeliveredAt *time.Time `json:"deliveredAt,omitempty"`
+	Error       string     `json:"error,omitempty"`
 }
 
 // Metadata contains request metadata
 type Metadata struct {
-	URL        string    `json:"url"`
-	ScrapedAt  time.Time `json:"scrapedAt"`
-	DurationMs int64     `json:"durationMs"`
+	URL         string    `json:"url"`
+	FinalURL    string    `json:"finalUrl,omitempty"`  // URL the content was served from, when it differs or is known
+	Fetcher     string    `json:"fetcher,omitempty"`   // Fetcher that produced the page ("http", "browser", ...)
+	Encoding    string    `json:"encoding,omitempty"`  // Character encoding the page was served in, such as "shift_jis"
+	Truncated   string    `json:"truncated,omitempty"` // Why the page was not read to its end: "size_limit" or "after_content"
+	Cache       string    `json:"cache,omitempty"`     // "hit" or "revalidated" when served from the cache
+	ScrapedAt   time.Time `json:"scrapedAt"`           // For cached results, when the page was last fetched or revalidated
+	DurationMs  int64     `json:"durationMs"`
+	QueueWaitMs int64     `json:"queueWaitMs,omitempty"` // Time spent queued behind other fetches of the same site
+}
+
+// DebugTrace explains how a result was produced: the fetch phases attempted and, for each,
+// the URLs tried, the browser snapshots captured and the extraction strategies compared
+type DebugTrace struct {
+	Phases     []DebugPhase    `json:"phases,omitempty"`
+	Strategies []DebugStrategy `json:"strategies,omitempty"` // Extraction of supplied HTML, which has no fetch phase
+}
+
+// DebugPhase is one fetcher attempt
+type DebugPhase struct {
+	Phase      int               `json:"phase"`
+	Fetcher    string            `json:"fetcher"`
+	Outcome    string            `json:"outcome"` // "success", "fetch_error", "extract_failed" or "skipped"
+	DurationMs int64             `json:"durationMs"`
+	Error      string            `json:"error,omitempty"`
+	FinalURL   string            `json:"finalUrl,omitempty"`
+	HTMLBytes  int               `json:"htmlBytes,omitempty"`
+	Truncated  string            `json:"truncated,omitempty"` // Why the page was not read to its end, as in Metadata
+	URLs       []DebugURLAttempt `json:"urls,omitempty"`
+	Snapshots  []DebugSnapshot   `json:"snapshots,omitempty"`
+	Strategies []DebugStrategy   `json:"strategies,omitempty"`
+}
+
+// DebugURLAttempt is a fetch of the original URL or one of its alternates
+type DebugURLAttempt struct {
+	URL        string `json:"url"`
+	Alternate  bool   `json:"alternate"`
+	DurationMs int64  `json:"durationMs"`
+	HTMLBytes  int    `json:"htmlBytes"`
+	Truncated  string `json:"truncated,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// DebugSnapshot is an HTML capture taken by the browser while the page loaded
+type DebugSnapshot struct {
+	Attempt  int    `json:"attempt"`
+	Stage    string `json:"stage"`
+	Bytes    int    `json:"bytes"`
+	URL      string `json:"url,omitempty"`
+	Selected bool   `json:"selected"`
+}
+
+// DebugStrategy is the result of one extraction strategy
+type DebugStrategy struct {
+	Strategy       string  `json:"strategy"`
+	TitleChars     int     `json:"titleChars"`
+	ContentChars   int     `json:"contentChars"`
+	Quality        Quality `json:"quality"`
+	CompositeScore int     `json:"compositeScore"` // Quality plus completeness bonuses, used to pick the result
+	Selected       bool    `json:"selected"`
 }
 
 // ImageCandidate represents a potential image with scoring data
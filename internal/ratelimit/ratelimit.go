@@ -0,0 +1,178 @@
+// Package ratelimit enforces per-client request rates and concurrency quotas.
+// Rates use a token bucket per client, refilled continuously up to a burst size;
+// concurrency quotas bound how many scrapes a client may have in flight.
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Limits are the quotas applied to one client
+// Zero fields take the limiter's defaults; a negative value means no limit
+type Limits struct {
+	RequestsPerMinute int `json:"requestsPerMinute,omitempty"`
+	Burst             int `json:"burst,omitempty"`         // Requests that may be made at once after a quiet period
+	MaxConcurrent     int `json:"maxConcurrent,omitempty"` // Scrapes in flight at once
+}
+
+// withDefaults returns l with zero fields taken from def
+func (l Limits) withDefaults(def Limits) Limits {
+	if l.RequestsPerMinute == 0 {
+		l.RequestsPerMinute = def.RequestsPerMinute
+	}
+	if l.Burst == 0 {
+		l.Burst = def.Burst
+	}
+	if l.MaxConcurrent == 0 {
+		l.MaxConcurrent = def.MaxConcurrent
+	}
+	return l
+}
+
+// Decision is the result of a rate check, carrying what the X-RateLimit-* headers report
+type Decision struct {
+	Allowed    bool
+	Unlimited  bool          // The client has no rate limit; the other fields are unset
+	Limit      int           // Bucket size
+	Remaining  int           // Requests that could be made right now
+	Reset      time.Duration // Until the bucket is full again
+	RetryAfter time.Duration // Until the request would be allowed, when it was not
+}
+
+// client is the state kept for one client
+type client struct {
+	bucket  bool // Whether tokens has been initialized by a rate check
+	tokens  float64
+	updated time.Time
+	full    time.Time // When the bucket will be full again
+	active  int       // Concurrent slots held
+}
+
+// Limiter tracks the buckets and concurrent scrapes of every client
+type Limiter struct {
+	defaults Limits
+	now      func() time.Time
+
+	mu        sync.Mutex
+	clients   map[string]*client
+	lastPrune time.Time
+}
+
+// pruneInterval is how often idle clients are forgotten
+const pruneInterval = 5 * time.Minute
+
+// NewLimiter creates a limiter applying defaults to clients without their own limits
+func NewLimiter(defaults Limits) *Limiter {
+	return &Limiter{defaults: defaults, now: time.Now, clients: make(map[string]*client)}
+}
+
+// Resolve returns limits with zero fields replaced by the limiter's defaults
+func (l *Limiter) Resolve(limits Limits) Limits {
+	return limits.withDefaults(l.defaults)
+}
+
+// clientLocked returns the state for id, creating it if needed
+func (l *Limiter) clientLocked(id string) *client {
+	c, ok := l.clients[id]
+	if !ok {
+		c = &client{}
+		l.clients[id] = c
+	}
+	return c
+}
+
+// Allow takes cost tokens from the client's bucket if it holds enough
+func (l *Limiter) Allow(id string, limits Limits, cost int) Decision {
+	limits = limits.withDefaults(l.defaults)
+	if limits.RequestsPerMinute < 0 {
+		return Decision{Allowed: true, Unlimited: true}
+	}
+	burst := limits.Burst
+	if burst < 1 {
+		burst = 1
+	}
+	rate := float64(limits.RequestsPerMinute) / 60 // Tokens per second
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	l.pruneLocked(now)
+
+	c := l.clientLocked(id)
+	if !c.bucket {
+		c.bucket, c.tokens = true, float64(burst)
+	} else {
+		c.tokens = math.Min(float64(burst), c.tokens+now.Sub(c.updated).Seconds()*rate)
+	}
+	c.updated = now
+
+	decision := Decision{Limit: burst}
+	if float64(cost) <= c.tokens {
+		c.tokens -= float64(cost)
+		decision.Allowed = true
+	} else if cost <= burst && rate > 0 {
+		decision.RetryAfter = seconds((float64(cost) - c.tokens) / rate)
+	} else {
+		// More than the bucket can ever hold; retrying cannot help until the limits change
+		decision.RetryAfter = time.Minute
+	}
+	decision.Remaining = int(c.tokens)
+	if rate > 0 {
+		decision.Reset = seconds((float64(burst) - c.tokens) / rate)
+		c.full = now.Add(decision.Reset)
+	} else {
+		c.full = now.Add(24 * time.Hour) // Never refills; keep the empty bucket around
+	}
+	return decision
+}
+
+// Acquire reserves between 1 and want concurrent slots for the client, as many as are free
+// It returns the number granted, 0 when none are free, and a function releasing them
+func (l *Limiter) Acquire(id string, limits Limits, want int) (int, func()) {
+	limits = limits.withDefaults(l.defaults)
+	if limits.MaxConcurrent < 0 {
+		return want, func() {}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	c := l.clientLocked(id)
+	granted := want
+	if free := limits.MaxConcurrent - c.active; granted > free {
+		granted = free
+	}
+	if granted <= 0 {
+		return 0, func() {}
+	}
+	c.active += granted
+
+	var once sync.Once
+	return granted, func() {
+		once.Do(func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			c.active -= granted
+		})
+	}
+}
+
+// pruneLocked forgets clients with no scrapes in flight whose bucket has refilled,
+// since a new client starts with a full bucket anyway
+func (l *Limiter) pruneLocked(now time.Time) {
+	if now.Sub(l.lastPrune) < pruneInterval {
+		return
+	}
+	l.lastPrune = now
+	for id, c := range l.clients {
+		if c.active == 0 && !now.Before(c.full) {
+			delete(l.clients, id)
+		}
+	}
+}
+
+// seconds converts a number of seconds to a duration
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
@@ -0,0 +1,99 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+// newTestLimiter returns a limiter whose clock only moves when advanced
+func newTestLimiter(defaults Limits) (*Limiter, func(time.Duration)) {
+	l := NewLimiter(defaults)
+	now := time.Unix(1_700_000_000, 0)
+	l.now = func() time.Time { return now }
+	return l, func(d time.Duration) { now = now.Add(d) }
+}
+
+func TestAllowRefillsAtConfiguredRate(t *testing.T) {
+	l, advance := newTestLimiter(Limits{RequestsPerMinute: 60, Burst: 3})
+
+	for i := 0; i < 3; i++ {
+		if d := l.Allow("a", Limits{}, 1); !d.Allowed || d.Remaining != 2-i {
+			t.Fatalf("request %d: %+v, want allowed with %d remaining", i+1, d, 2-i)
+		}
+	}
+	d := l.Allow("a", Limits{}, 1)
+	if d.Allowed || d.RetryAfter != time.Second || d.Limit != 3 || d.Reset != 3*time.Second {
+		t.Fatalf("request over burst: %+v, want rejected, retry after 1s, reset in 3s", d)
+	}
+
+	// Other clients have their own bucket
+	if d := l.Allow("b", Limits{}, 1); !d.Allowed {
+		t.Fatalf("other client: %+v, want allowed", d)
+	}
+
+	advance(time.Second)
+	if d := l.Allow("a", Limits{}, 1); !d.Allowed || d.Remaining != 0 {
+		t.Fatalf("after refill: %+v, want allowed with 0 remaining", d)
+	}
+}
+
+func TestAllowPerClientLimits(t *testing.T) {
+	l, _ := newTestLimiter(Limits{RequestsPerMinute: 60, Burst: 1})
+
+	if d := l.Allow("big", Limits{Burst: 5}, 5); !d.Allowed || d.Limit != 5 {
+		t.Fatalf("own burst: %+v, want 5 requests allowed at once", d)
+	}
+	if d := l.Allow("small", Limits{}, 2); d.Allowed || d.RetryAfter != time.Minute {
+		t.Fatalf("cost over burst: %+v, want rejected for a minute", d)
+	}
+	for i := 0; i < 100; i++ {
+		if d := l.Allow("unlimited", Limits{RequestsPerMinute: -1}, 1); !d.Allowed || !d.Unlimited {
+			t.Fatalf("unlimited client: %+v, want allowed", d)
+		}
+	}
+}
+
+func TestAcquireBoundsConcurrency(t *testing.T) {
+	l, _ := newTestLimiter(Limits{MaxConcurrent: 3})
+
+	granted, releaseBatch := l.Acquire("a", Limits{}, 2)
+	if granted != 2 {
+		t.Fatalf("granted %d slots, want 2", granted)
+	}
+	granted, releaseOne := l.Acquire("a", Limits{}, 4)
+	if granted != 1 {
+		t.Fatalf("granted %d slots, want the 1 left", granted)
+	}
+	if granted, _ := l.Acquire("a", Limits{}, 1); granted != 0 {
+		t.Fatalf("granted %d slots with none free, want 0", granted)
+	}
+
+	releaseBatch()
+	releaseBatch() // Releasing twice must not free slots held by others
+	if granted, _ := l.Acquire("a", Limits{}, 5); granted != 2 {
+		t.Fatalf("granted %d slots after release, want 2", granted)
+	}
+	releaseOne()
+
+	if granted, _ := l.Acquire("a", Limits{MaxConcurrent: -1}, 5); granted != 5 {
+		t.Fatalf("unlimited client granted %d slots, want 5", granted)
+	}
+}
+
+func TestPruneKeepsClientsUntilRefilled(t *testing.T) {
+	l, advance := newTestLimiter(Limits{RequestsPerMinute: 6, Burst: 2})
+
+	l.Allow("a", Limits{}, 2)
+	advance(pruneInterval + time.Second) // Long enough to refill both tokens
+	l.Allow("b", Limits{}, 1)            // Triggers a prune
+	if _, ok := l.clients["a"]; ok {
+		t.Fatal("refilled idle client was not pruned")
+	}
+
+	l.Allow("c", Limits{RequestsPerMinute: 1, Burst: 10}, 10)
+	advance(pruneInterval + time.Second)
+	l.Allow("b", Limits{}, 1)
+	if _, ok := l.clients["c"]; !ok {
+		t.Fatal("client with an empty bucket was pruned, which would reset its limit")
+	}
+}
@@ -2,42 +2,93 @@ package scraper
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 	"regexp"
 	"strings"
+	"sync"
 	"time"
 
-	"extract-html-scraper/internal/config"
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/config"
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/metrics"
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/models"
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/tracing"
 
 	"github.com/chromedp/chromedp"
 )
 
 type BrowserClient struct {
-	config  config.ScrapeConfig
-	regexes map[string]*regexp.Regexp
+	logSink
+	settingsMu sync.RWMutex
+	settings   *config.Settings
+	options    BrowserOptions
+	regexes    map[string]*regexp.Regexp
+
+	allocators allocatorSet
 }
 
 func NewBrowserClient() *BrowserClient {
-	cfg := config.DefaultScrapeConfig()
-	regexes := config.CompileRegexes()
+	return NewBrowserClientWithConfig(config.DefaultScrapeConfig(), OptimizedBrowserOptions())
+}
+
+// NewBrowserClientWithConfig creates a browser client with the given configuration and the
+// default timeouts, blocked domains and robots.txt settings
+// opts are used by ScrapeWithBrowserOptimized; an empty UserAgent falls back to cfg.UserAgent
+func NewBrowserClientWithConfig(cfg config.ScrapeConfig, opts BrowserOptions) *BrowserClient {
+	settings := config.DefaultSettings()
+	settings.Scrape = cfg
+	return NewBrowserClientWithSettings(settings, opts)
+}
 
+// NewBrowserClientWithSettings creates a browser client using settings, which must not be modified
+// opts are used by ScrapeWithBrowserOptimized; an empty UserAgent falls back to settings.Scrape.UserAgent
+func NewBrowserClientWithSettings(settings *config.Settings, opts BrowserOptions) *BrowserClient {
 	return &BrowserClient{
-		config:  cfg,
-		regexes: regexes,
+		settings: settings,
+		options:  opts,
+		regexes:  config.CompileRegexes(),
 	}
 }
 
+// SetSettings replaces the settings, which must not be modified; navigations already running
+// keep the previous ones
+func (b *BrowserClient) SetSettings(settings *config.Settings) {
+	b.settingsMu.Lock()
+	defer b.settingsMu.Unlock()
+	b.settings = settings
+}
+
+// currentSettings returns the settings new navigations use
+func (b *BrowserClient) currentSettings() *config.Settings {
+	b.settingsMu.RLock()
+	defer b.settingsMu.RUnlock()
+	return b.settings
+}
+
+// userAgent returns the configured user agent
+func (b *BrowserClient) userAgent() string {
+	return b.currentSettings().Scrape.UserAgent
+}
+
+// SetLogger sends the browser client's progress messages to logger instead of slog.Default()
+func (b *BrowserClient) SetLogger(logger *slog.Logger) {
+	b.logger = withRequestIDs(logger)
+}
+
 // ScrapeWithBrowser uses chromedp to scrape content with fallback to alternate URLs
 func (b *BrowserClient) ScrapeWithBrowser(ctx context.Context, targetURL string, timeoutMs int) (string, string, error) {
 	opts := DefaultBrowserOptions()
-	opts.UserAgent = b.config.UserAgent
+	opts.UserAgent = b.userAgent()
 	return b.scrapeWithOptions(ctx, targetURL, timeoutMs, opts)
 }
 
 // ScrapeWithBrowserOptimized is an optimized version that blocks more resources
 func (b *BrowserClient) ScrapeWithBrowserOptimized(ctx context.Context, targetURL string, timeoutMs int) (string, string, error) {
-	opts := OptimizedBrowserOptions()
-	opts.UserAgent = b.config.UserAgent
+	opts := b.options
+	if opts.UserAgent == "" {
+		opts.UserAgent = b.userAgent()
+	}
 	return b.scrapeWithOptions(ctx, targetURL, timeoutMs, opts)
 }
 
@@ -46,11 +97,21 @@ func (b *BrowserClient) scrapeWithOptions(ctx context.Context, targetURL string,
 	// Create a new context with timeout
 	ctx, cancel := context.WithTimeout(ctx, time.Duration(timeoutMs)*time.Millisecond)
 	defer cancel()
+	settings := b.currentSettings()
+
+	// Only the pages navigated to are checked, before Chrome starts and at each redirect they
+	// follow (see guardRedirects); the requests a page makes are not crawler fetches
+	if err := sharedRobots.Admit(ctx, settings, targetURL); err != nil {
+		return "", "", err
+	}
 
 	// Build Chrome options
 	chromeOpts := BuildChromeOptions(opts)
 
-	allocCtx, cancel := chromedp.NewExecAllocator(ctx, chromeOpts...)
+	allocCtx, cancel, err := b.allocators.start(ctx, chromeOpts...)
+	if err != nil {
+		return "", "", err
+	}
 	defer cancel()
 
 	// Create browser context
@@ -65,45 +126,54 @@ func (b *BrowserClient) scrapeWithOptions(ctx context.Context, targetURL string,
 	defer cancel()
 
 	// Set up request blocking
-	err := chromedp.Run(ctx, chromedp.Tasks{
+	err = chromedp.Run(ctx, chromedp.Tasks{
 		chromedp.ActionFunc(func(ctx context.Context) error {
 			return chromedp.Run(ctx, chromedp.Tasks{
-				chromedp.Evaluate(GetRequestBlockingScript(opts), nil),
+				chromedp.Evaluate(GetRequestBlockingScript(opts, settings.BlockedDomains), nil),
 			})
 		}),
 	})
 	if err != nil {
 		return "", "", fmt.Errorf("failed to set up request blocking: %w", err)
 	}
+	guard, err := guardRedirects(ctx, settings)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to set up redirect checks: %w", err)
+	}
 
 	// Try primary URL first with graceful degradation
-	html, finalURL, err := b.navigateAndExtract(ctx, targetURL)
+	html, finalURL, err := b.navigateTraced(ctx, settings, guard, targetURL, false)
+	var robotsErr *models.RobotsDisallowedError
+	if errors.As(err, &robotsErr) {
+		return "", "", err
+	}
 	if err == nil && len(html) > 0 {
 		// Check for blocking first - this is a hard failure
 		if b.LooksLikeCFBlock(html) {
-			fmt.Printf("Primary URL blocked by site protection\n")
+			b.warn(ctx, "primary URL blocked by site protection", "url", targetURL)
 			// Continue to alternates instead of returning error immediately
 		} else {
 			// Got HTML - return it (let extraction determine validity)
 			textLength := len(strings.TrimSpace(html))
-			fmt.Printf("Primary URL navigation complete: HTML length=%d chars, finalURL=%s\n", textLength, finalURL)
+			b.info(ctx, "primary URL navigation complete", "html_chars", textLength, "final_url", finalURL)
 		return html, finalURL, nil
 		}
 	} else if err != nil {
-		fmt.Printf("Primary URL navigation had errors: %v (HTML length: %d)\n", err, len(html))
+		b.warn(ctx, "primary URL navigation had errors", "error", err, "html_chars", len(html))
 		// Even with errors, if we got HTML, try to use it
 		if len(html) > 0 && !b.LooksLikeCFBlock(html) {
-			fmt.Printf("Using HTML despite navigation errors (graceful degradation)\n")
+			b.info(ctx, "using HTML despite navigation errors (graceful degradation)")
 			return html, finalURL, nil
 		}
 	} else {
-		fmt.Printf("Primary URL navigation returned empty HTML\n")
+		b.warn(ctx, "primary URL navigation returned empty HTML", "url", targetURL)
 	}
+	primaryErr := err
 
 	// Generate alternate URLs and try them
 	alternates, err := b.GenerateAlternateURLs(targetURL)
 	if err != nil {
-		fmt.Printf("Failed to generate alternate URLs: %v\n", err)
+		b.warn(ctx, "failed to generate alternate URLs", "error", err)
 		// If we have HTML from primary, return it even without alternates
 		if len(html) > 0 && !b.LooksLikeCFBlock(html) {
 			return html, finalURL, nil
@@ -111,34 +181,42 @@ func (b *BrowserClient) scrapeWithOptions(ctx context.Context, targetURL string,
 		return "", "", fmt.Errorf("failed to generate alternate URLs: %w", err)
 	}
 
-	fmt.Printf("Trying %d alternate URLs\n", len(alternates))
+	b.info(ctx, "trying alternate URLs", "count", len(alternates))
 	for i, altURL := range alternates {
-		fmt.Printf("Trying alternate URL %d/%d: %s\n", i+1, len(alternates), altURL)
-		altHTML, altFinalURL, altErr := b.navigateAndExtract(ctx, altURL)
+		b.debug(ctx, "trying alternate URL", "index", i+1, "count", len(alternates), "url", altURL)
+		altHTML, altFinalURL, altErr := b.navigateTraced(ctx, settings, guard, altURL, true)
 		if altErr == nil && len(altHTML) > 0 {
 			// Reject only if blocked
 			if b.LooksLikeCFBlock(altHTML) {
-				fmt.Printf("Alternate URL %d blocked, trying next\n", i+1)
+				b.debug(ctx, "alternate URL blocked, trying next", "index", i+1, "url", altURL)
 				continue // Try next alternate
 			}
 			// Got valid HTML from alternate
 			textLength := len(strings.TrimSpace(altHTML))
-			fmt.Printf("Alternate URL %d succeeded: HTML length=%d chars\n", i+1, textLength)
+			b.info(ctx, "alternate URL succeeded", "index", i+1, "url", altURL, "html_chars", textLength)
+			metrics.AlternateURLHits.Inc(FetcherBrowser)
 			return altHTML, altFinalURL, nil
 		} else if len(altHTML) > 0 && !b.LooksLikeCFBlock(altHTML) {
 			// Got HTML despite errors
-			fmt.Printf("Alternate URL %d had errors but returning HTML (graceful degradation)\n", i+1)
+			b.info(ctx, "alternate URL had errors but returning HTML (graceful degradation)", "index", i+1, "url", altURL)
+			metrics.AlternateURLHits.Inc(FetcherBrowser)
 			return altHTML, altFinalURL, nil
 		}
-		fmt.Printf("Alternate URL %d failed: %v\n", i+1, altErr)
+		b.debug(ctx, "alternate URL failed", "index", i+1, "url", altURL, "error", altErr)
 	}
 
 	// Last resort: return HTML from primary if we have any
 	if len(html) > 0 && !b.LooksLikeCFBlock(html) {
-		fmt.Printf("Returning primary URL HTML as last resort (length: %d)\n", len(html))
+		b.info(ctx, "returning primary URL HTML as last resort", "html_chars", len(html))
 			return html, finalURL, nil
 		}
 
+	if b.LooksLikeCFBlock(html) {
+		return "", "", &models.CloudflareBlockError{Domain: hostname(targetURL), Err: errors.New("all URLs failed or were blocked")}
+	}
+	if primaryErr != nil {
+		return "", "", fmt.Errorf("all URLs failed or were blocked: %w", primaryErr)
+	}
 	return "", "", fmt.Errorf("all URLs failed or were blocked")
 }
 
@@ -151,6 +229,40 @@ type HTMLSnapshot struct {
 	Length    int
 }
 
+// navigateTraced runs navigateAndExtract inside a span
+func (b *BrowserClient) navigateTraced(ctx context.Context, settings *config.Settings, guard *redirectGuard, targetURL string, alternate bool) (string, string, error) {
+	ctx, span := tracing.Start(ctx, "browser.page", slog.String("url", targetURL), slog.Bool("alternate", alternate))
+	start := time.Now()
+	html, finalURL, err := b.navigateRobots(ctx, settings, guard, targetURL, alternate)
+	debugFrom(ctx).recordURL(targetURL, alternate, time.Since(start), len(html), "", err)
+	span.SetAttributes(slog.Int("html_bytes", len(html)))
+	endSpan(span, err)
+	return html, finalURL, err
+}
+
+// navigateRobots is navigateAndExtract under the robots.txt rules HTTPClient applies: alternate
+// URLs are admitted like the primary one was, redirects somewhere robots.txt disallows are
+// blocked by guard before Chrome follows them, and a page that script moved somewhere disallowed
+// is discarded
+func (b *BrowserClient) navigateRobots(ctx context.Context, settings *config.Settings, guard *redirectGuard, targetURL string, alternate bool) (string, string, error) {
+	if alternate {
+		if err := sharedRobots.Admit(ctx, settings, targetURL); err != nil {
+			return "", "", err
+		}
+	}
+	guard.take()
+	html, finalURL, err := b.navigateAndExtract(ctx, targetURL)
+	if robotsErr := guard.take(); robotsErr != nil {
+		return "", "", robotsErr
+	}
+	if finalURL != "" && finalURL != targetURL {
+		if robotsErr := sharedRobots.Allowed(ctx, settings, finalURL); robotsErr != nil {
+			return "", "", robotsErr
+		}
+	}
+	return html, finalURL, err
+}
+
 // navigateAndExtract navigates to a URL and extracts HTML content
 // Refactored to use progressive capture, retry logic, and graceful degradation
 func (b *BrowserClient) navigateAndExtract(ctx context.Context, targetURL string) (string, string, error) {
@@ -171,8 +283,8 @@ func (b *BrowserClient) navigateAndExtract(ctx context.Context, targetURL string
 		if len(snapshots) > 0 {
 			best := b.getBestHTML(snapshots)
 			if best != nil && len(best.HTML) > 0 {
-				fmt.Printf("Navigation had errors but returning captured HTML (stage: %s, length: %d)\n",
-					best.Stage, best.Length)
+				debugFrom(ctx).selectSnapshot(best)
+				b.info(ctx, "navigation had errors but returning captured HTML", "stage", best.Stage, "html_chars", best.Length)
 				return best.HTML, best.URL, nil
 			}
 		}
@@ -182,7 +294,8 @@ func (b *BrowserClient) navigateAndExtract(ctx context.Context, targetURL string
 	// Return best HTML from snapshots
 	best := b.getBestHTML(snapshots)
 	if best != nil {
-		fmt.Printf("Returning best HTML snapshot (stage: %s, length: %d)\n", best.Stage, best.Length)
+		debugFrom(ctx).selectSnapshot(best)
+		b.info(ctx, "returning best HTML snapshot", "stage", best.Stage, "html_chars", best.Length)
 		// Use captured URL if best snapshot doesn't have URL
 		if best.URL == "" {
 			best.URL = capturedURL
@@ -231,9 +344,9 @@ func (b *BrowserClient) navigateAndExtractLegacy(ctx context.Context, targetURL
 
 	// Log challenge wait configuration
 	if deadline, ok := ctx.Deadline(); ok {
-		fmt.Printf("Challenge wait timeout: %v (parent deadline: %v)\n", maxChallengeWait, deadline.Format(time.RFC3339))
+		b.debug(ctx, "challenge wait timeout", "timeout", maxChallengeWait, "parent_deadline", deadline.Format(time.RFC3339))
 	} else {
-		fmt.Printf("Challenge wait timeout: %v (no parent deadline)\n", maxChallengeWait)
+		b.debug(ctx, "challenge wait timeout (no parent deadline)", "timeout", maxChallengeWait)
 	}
 
 	err := chromedp.Run(ctx, chromedp.Tasks{
@@ -323,7 +436,7 @@ func (b *BrowserClient) navigateAndExtractLegacy(ctx context.Context, targetURL
 				select {
 				case <-ctx.Done():
 					// Parent context expired, return error to distinguish from timeout
-					fmt.Printf("Challenge wait: parent context expired\n")
+					b.warn(ctx, "challenge wait: parent context expired")
 					return fmt.Errorf("parent context expired during challenge wait")
 				default:
 				}
@@ -333,7 +446,7 @@ func (b *BrowserClient) navigateAndExtractLegacy(ctx context.Context, targetURL
 				case <-challengeCtx.Done():
 					// Challenge wait timeout - proceed with whatever we have
 					if challengeDetected {
-						fmt.Printf("Challenge wait: timeout after detecting challenge\n")
+						b.warn(ctx, "challenge wait: timeout after detecting challenge")
 					}
 					// Check if parent context also expired
 					if ctx.Err() != nil {
@@ -352,7 +465,7 @@ func (b *BrowserClient) navigateAndExtractLegacy(ctx context.Context, targetURL
 					// Re-check parent context after DOM operation
 					select {
 					case <-ctx.Done():
-						fmt.Printf("Challenge wait: context expired during check\n")
+						b.warn(ctx, "challenge wait: context expired during check")
 						return fmt.Errorf("parent context expired during challenge wait check")
 					default:
 					}
@@ -362,7 +475,7 @@ func (b *BrowserClient) navigateAndExtractLegacy(ctx context.Context, targetURL
 					if isAppError {
 						if !errorDetected {
 							errorDetected = true
-							fmt.Printf("Application error detected, waiting for recovery...\n")
+							b.info(ctx, "application error detected, waiting for recovery")
 						}
 						errorCheckCount++
 						// Wait up to 10 checks (5 seconds) for error to resolve
@@ -372,11 +485,11 @@ func (b *BrowserClient) navigateAndExtractLegacy(ctx context.Context, targetURL
 							continue
 						} else {
 							// Error persisted, but check if we have content anyway
-							fmt.Printf("Application error persisted after %d checks\n", errorCheckCount)
+							b.warn(ctx, "application error persisted", "checks", errorCheckCount)
 						}
 					} else if errorDetected {
 						// Error was detected but now resolved
-						fmt.Printf("Application error resolved after %d checks\n", errorCheckCount)
+						b.info(ctx, "application error resolved", "checks", errorCheckCount)
 						errorDetected = false
 					}
 
@@ -387,7 +500,7 @@ func (b *BrowserClient) navigateAndExtractLegacy(ctx context.Context, targetURL
 					} else if challengeDetected {
 						// Challenge was detected earlier but now it's resolved
 						if previousHTML != bodyHTML {
-							fmt.Printf("Challenge wait: challenge resolved after %d checks\n", checkCount)
+							b.info(ctx, "challenge resolved", "checks", checkCount)
 							return nil
 						}
 					} else {
@@ -409,20 +522,17 @@ func (b *BrowserClient) navigateAndExtractLegacy(ctx context.Context, targetURL
 							
 							// Diagnostic logging
 							if checkCount%5 == 0 { // Log every 5th check to reduce noise
-								fmt.Printf("Content check %d: appError=%v, hasContent=%v, textLength=%d (min=%d)\n",
-									checkCount, isAppError, hasContent, textLength, minTextLength)
+								b.debug(ctx, "content check", "check", checkCount, "app_error", isAppError, "has_content", hasContent, "text_chars", textLength, "min_chars", minTextLength)
 							}
 							
 							if !isAppError && hasContent {
 								// Check text length with progressive threshold
 								if textLength > minTextLength {
-									fmt.Printf("Content verified after %d checks (text length: %d, min required: %d)\n",
-										checkCount, textLength, minTextLength)
+									b.debug(ctx, "content verified", "checks", checkCount, "text_chars", textLength, "min_chars", minTextLength)
 									return nil
 								} else if textLength > 1000 && checkCount > 15 {
 									// If we've waited a while and have some content, be more lenient
-									fmt.Printf("Content verified (lenient) after %d checks (text length: %d)\n",
-										checkCount, textLength)
+									b.debug(ctx, "content verified (lenient)", "checks", checkCount, "text_chars", textLength)
 							return nil
 						}
 							}
@@ -433,7 +543,7 @@ func (b *BrowserClient) navigateAndExtractLegacy(ctx context.Context, targetURL
 							// First check, store initial HTML
 							previousHTML = bodyHTML
 							textLength := len(strings.TrimSpace(bodyHTML))
-							fmt.Printf("Initial content check: HTML length=%d chars\n", textLength)
+							b.debug(ctx, "initial content check", "html_chars", textLength)
 						}
 					}
 				}
@@ -557,7 +667,7 @@ func (b *BrowserClient) handleConsentDialogs(ctx context.Context) error {
 		if clicked {
 			// Wait longer for dialog to dismiss (increased from 500ms to 1s)
 			chromedp.Sleep(1 * time.Second).Do(ctx)
-			fmt.Printf("Consent dialog dismissed (attempt %d/%d)\n", attempt+1, maxRetries)
+			b.debug(ctx, "consent dialog dismissed", "attempt", attempt+1, "max_attempts", maxRetries)
 			
 			// Check if there are more dialogs (some sites have nested consent)
 			// Continue to next attempt to handle additional dialogs
@@ -646,7 +756,7 @@ func (b *BrowserClient) handlePaywall(ctx context.Context) bool {
 	}
 
 	if paywallFound {
-		fmt.Printf("Paywall detected and removal attempted\n")
+		b.debug(ctx, "paywall detected and removal attempted")
 		chromedp.Sleep(500 * time.Millisecond).Do(ctx)
 	}
 
@@ -702,7 +812,7 @@ func (b *BrowserClient) retryNavigation(ctx context.Context, targetURL string, m
 		if attempt > 0 {
 			// Exponential backoff: 2s, 4s
 			backoff := time.Duration(1<<uint(attempt)) * time.Second
-			fmt.Printf("Retrying navigation (attempt %d/%d) after %v backoff...\n", attempt+1, maxRetries, backoff)
+			b.info(ctx, "retrying navigation", "attempt", attempt+1, "max_attempts", maxRetries, "backoff", backoff)
 			select {
 			case <-ctx.Done():
 				break
@@ -710,7 +820,11 @@ func (b *BrowserClient) retryNavigation(ctx context.Context, targetURL string, m
 			}
 		}
 
-		snapshots, url, err := b.captureHTMLSnapshots(ctx, targetURL)
+		captureCtx, span := tracing.Start(ctx, "browser.capture", slog.Int("attempt", attempt+1))
+		snapshots, url, err := b.captureHTMLSnapshots(captureCtx, targetURL)
+		span.SetAttributes(slog.Int("snapshots", len(snapshots)))
+		endSpan(span, err)
+		debugFrom(ctx).recordSnapshots(attempt+1, snapshots)
 
 		// Collect all snapshots
 		allSnapshots = append(allSnapshots, snapshots...)
@@ -721,7 +835,7 @@ func (b *BrowserClient) retryNavigation(ctx context.Context, targetURL string, m
 		// Check if we should retry
 		if err == nil && len(snapshots) > 0 {
 			// Success - return immediately
-			fmt.Printf("Navigation succeeded on attempt %d/%d\n", attempt+1, maxRetries)
+			b.info(ctx, "navigation succeeded", "attempt", attempt+1, "max_attempts", maxRetries)
 			return snapshots, url, nil
 		}
 
@@ -743,14 +857,14 @@ func (b *BrowserClient) retryNavigation(ctx context.Context, targetURL string, m
 			break
 		}
 
-		fmt.Printf("Navigation attempt %d/%d failed: %v, will retry...\n", attempt+1, maxRetries, err)
+		b.warn(ctx, "navigation attempt failed, will retry", "attempt", attempt+1, "max_attempts", maxRetries, "error", err)
 	}
 
 	// Return best result from all attempts
 	if len(allSnapshots) > 0 {
 		best := b.getBestHTML(allSnapshots)
 		if best != nil {
-			fmt.Printf("Returning best snapshot from %d attempts (%d total snapshots)\n", maxRetries, len(allSnapshots))
+			b.info(ctx, "returning best snapshot from all attempts", "attempts", maxRetries, "snapshots", len(allSnapshots))
 			return []HTMLSnapshot{*best}, finalURL, nil
 		}
 	}
@@ -766,22 +880,22 @@ func (b *BrowserClient) captureHTMLSnapshots(ctx context.Context, targetURL stri
 	var finalURL string
 	var captureErrors []error
 
-	fmt.Printf("Starting progressive HTML capture for: %s\n", targetURL)
+	b.info(ctx, "starting progressive HTML capture", "url", targetURL)
 
 	// Log context deadline information
 	remainingTime := calculateRemainingTime(ctx)
 	if deadline, ok := ctx.Deadline(); ok {
-		fmt.Printf("Context deadline: %v (remaining: %v)\n", deadline.Format(time.RFC3339), remainingTime)
+		b.debug(ctx, "context deadline", "deadline", deadline.Format(time.RFC3339), "remaining", remainingTime)
 		if remainingTime < 30*time.Second {
-			fmt.Printf("WARNING: Low time budget (%v), some phases may be skipped\n", remainingTime)
+			b.warn(ctx, "low time budget, some phases may be skipped", "remaining", remainingTime)
 		}
 	} else {
-		fmt.Printf("No context deadline set\n")
+		b.debug(ctx, "no context deadline set")
 	}
 
 	// Calculate wait times
 	maxChallengeWait := b.calculateChallengeWait(ctx)
-	fmt.Printf("Max challenge wait: %v\n", maxChallengeWait)
+	b.debug(ctx, "max challenge wait", "timeout", maxChallengeWait)
 
 	// Variables to capture HTML inline during tasks
 	var initialHTML, afterConsentHTML, afterScrollHTML string
@@ -799,9 +913,11 @@ func (b *BrowserClient) captureHTMLSnapshots(ctx context.Context, targetURL stri
 	}
 
 	// Navigate with timeout protection
-	err := b.navigateWithTimeout(ctx, targetURL, navTimeout)
+	navCtx, span := tracing.Start(ctx, "browser.navigate", slog.String("url", targetURL), slog.Duration("timeout", navTimeout))
+	err := b.navigateWithTimeout(navCtx, targetURL, navTimeout)
+	endSpan(span, err)
 	if err != nil {
-		fmt.Printf("Navigation had error (will try to capture anyway): %v\n", err)
+		b.warn(ctx, "navigation had error, will try to capture anyway", "error", err)
 	}
 
 	// Wait for DOMContentLoaded (faster than WaitReady("body"))
@@ -809,25 +925,29 @@ func (b *BrowserClient) captureHTMLSnapshots(ctx context.Context, targetURL stri
 	if remainingTime < domWait+3*time.Second {
 		domWait = 3 * time.Second
 	}
-	_ = b.waitForDOMContentLoaded(ctx, domWait)
+	domCtx, span := tracing.Start(ctx, "browser.dom_content_loaded")
+	endSpan(span, b.waitForDOMContentLoaded(domCtx, domWait))
 	
 	// Try periodic capture during DOM wait (limited duration)
-	periodicSnaps := b.captureHTMLPeriodically(ctx, 2*time.Second, 5*time.Second)
+	periodicCtx, span := tracing.Start(ctx, "browser.periodic_capture")
+	periodicSnaps := b.captureHTMLPeriodically(periodicCtx, 2*time.Second, 5*time.Second)
+	span.SetAttributes(slog.Int("snapshots", len(periodicSnaps)))
+	span.End()
 	if len(periodicSnaps) > 0 {
 		snapshots = append(snapshots, periodicSnaps...)
-		fmt.Printf("Collected %d periodic snapshots during navigation\n", len(periodicSnaps))
+		b.debug(ctx, "collected periodic snapshots during navigation", "snapshots", len(periodicSnaps))
 	}
 	
 	// Immediately capture HTML in separate operation (even if navigation had errors)
 	_ = chromedp.Run(ctx, chromedp.Tasks{
-		chromedp.ActionFunc(func(ctx context.Context) error {
+		tracedAction("browser.snapshot", func(ctx context.Context) error {
 			var html, url string
 			if err := chromedp.Location(&url).Do(ctx); err == nil {
 				if err := chromedp.OuterHTML("html", &html).Do(ctx); err == nil {
 					initialHTML = html
 					currentURL = url
 					textLength := len(strings.TrimSpace(html))
-					fmt.Printf("Captured initial snapshot: %d chars\n", textLength)
+					b.debug(ctx, "captured snapshot", "stage", "initial", "html_chars", textLength)
 					if textLength > 0 {
 						snapshots = append(snapshots, HTMLSnapshot{
 							HTML:      html,
@@ -840,7 +960,7 @@ func (b *BrowserClient) captureHTMLSnapshots(ctx context.Context, targetURL stri
 				}
 			}
 			return nil
-		}),
+		}, slog.String("stage", "initial")),
 	})
 
 	// Now do the longer waits and processing in separate chromedp.Run
@@ -848,7 +968,7 @@ func (b *BrowserClient) captureHTMLSnapshots(ctx context.Context, targetURL stri
 	err2 := chromedp.Run(ctx, chromedp.Tasks{
 
 		// Check for Cloudflare challenge (with adaptive timeout)
-		chromedp.ActionFunc(func(ctx context.Context) error {
+		tracedAction("browser.challenge_wait", func(ctx context.Context) error {
 			var bodyHTML string
 			if err := chromedp.OuterHTML("body", &bodyHTML).Do(ctx); err == nil {
 				if b.LooksLikeCFBlock(bodyHTML) {
@@ -861,14 +981,14 @@ func (b *BrowserClient) captureHTMLSnapshots(ctx context.Context, targetURL stri
 							cfWait = 5 * time.Second
 						}
 					}
-					fmt.Printf("Cloudflare challenge detected, waiting up to %v for resolution...\n", cfWait)
+					b.info(ctx, "Cloudflare challenge detected, waiting for resolution", "timeout", cfWait)
 					chromedp.Sleep(cfWait).Do(ctx)
 					// Re-check after wait
 					chromedp.OuterHTML("body", &bodyHTML).Do(ctx)
 					if b.LooksLikeCFBlock(bodyHTML) {
-						fmt.Printf("Challenge still present after %v wait\n", cfWait)
+						b.warn(ctx, "challenge still present after wait", "waited", cfWait)
 					} else {
-						fmt.Printf("Challenge resolved after wait\n")
+						b.info(ctx, "challenge resolved after wait")
 					}
 				}
 			}
@@ -876,7 +996,7 @@ func (b *BrowserClient) captureHTMLSnapshots(ctx context.Context, targetURL stri
 		}),
 
 		// Phase 2: Wait for ready state (adaptive timeout based on remaining time)
-		chromedp.ActionFunc(func(ctx context.Context) error {
+		tracedAction("browser.ready_state", func(ctx context.Context) error {
 			remainingTime := calculateRemainingTime(ctx)
 			maxWait := 10 * time.Second // Reduced default from 15s
 			// Reduce wait if we're low on time
@@ -908,10 +1028,10 @@ func (b *BrowserClient) captureHTMLSnapshots(ctx context.Context, targetURL stri
 		}),
 
 		// Phase 2.5: Wait for network idle (optional, skip if low on time)
-		chromedp.ActionFunc(func(ctx context.Context) error {
+		tracedAction("browser.network_idle", func(ctx context.Context) error {
 			remainingTime := calculateRemainingTime(ctx)
 			if remainingTime < 10*time.Second {
-				fmt.Printf("Skipping network idle wait (low time: %v)\n", remainingTime)
+				b.debug(ctx, "skipping network idle wait (low time budget)", "remaining", remainingTime)
 				return nil
 			}
 			networkWait := 3 * time.Second // Reduced from 5s
@@ -923,10 +1043,10 @@ func (b *BrowserClient) captureHTMLSnapshots(ctx context.Context, targetURL stri
 		}),
 
 		// Phase 2.6: Wait for content selectors (optional, skip if low on time)
-		chromedp.ActionFunc(func(ctx context.Context) error {
+		tracedAction("browser.content_selectors", func(ctx context.Context) error {
 			remainingTime := calculateRemainingTime(ctx)
 			if remainingTime < 10*time.Second {
-				fmt.Printf("Skipping content selector wait (low time: %v)\n", remainingTime)
+				b.debug(ctx, "skipping content selector wait (low time budget)", "remaining", remainingTime)
 				return nil
 			}
 			contentWait := 10 * time.Second // Reduced from 15s
@@ -941,13 +1061,13 @@ func (b *BrowserClient) captureHTMLSnapshots(ctx context.Context, targetURL stri
 		}),
 
 		// Phase 3: Handle consent dialogs
-		chromedp.ActionFunc(func(ctx context.Context) error {
+		tracedAction("browser.consent", func(ctx context.Context) error {
 			return b.handleConsentDialogs(ctx)
 		}),
 		chromedp.Sleep(500 * time.Millisecond),
 
 		// Capture after consent inline
-		chromedp.ActionFunc(func(ctx context.Context) error {
+		tracedAction("browser.snapshot", func(ctx context.Context) error {
 			var html, url string
 			if err := chromedp.Location(&url).Do(ctx); err == nil {
 				if err := chromedp.OuterHTML("html", &html).Do(ctx); err == nil {
@@ -956,7 +1076,7 @@ func (b *BrowserClient) captureHTMLSnapshots(ctx context.Context, targetURL stri
 						currentURL = url
 					}
 					textLength := len(strings.TrimSpace(html))
-					fmt.Printf("Captured after-consent snapshot: %d chars\n", textLength)
+					b.debug(ctx, "captured snapshot", "stage", "after-consent", "html_chars", textLength)
 					if textLength > 0 {
 						snapshots = append(snapshots, HTMLSnapshot{
 							HTML:      html,
@@ -969,14 +1089,14 @@ func (b *BrowserClient) captureHTMLSnapshots(ctx context.Context, targetURL stri
 				}
 			}
 			return nil
-		}),
+		}, slog.String("stage", "after-consent")),
 
 		// Phase 4: Scroll to trigger lazy loading (optional - skip if low on time)
-		chromedp.ActionFunc(func(ctx context.Context) error {
+		tracedAction("browser.scroll", func(ctx context.Context) error {
 			// Check if we have enough time for scrolling
 			remainingTime := calculateRemainingTime(ctx)
 			if remainingTime < 10*time.Second {
-				fmt.Printf("Skipping scroll phase due to low time budget (%v)\n", remainingTime)
+				b.debug(ctx, "skipping scroll phase (low time budget)", "remaining", remainingTime)
 				return nil
 			}
 
@@ -998,7 +1118,7 @@ func (b *BrowserClient) captureHTMLSnapshots(ctx context.Context, targetURL stri
 		}),
 
 		// Capture after scroll inline
-		chromedp.ActionFunc(func(ctx context.Context) error {
+		tracedAction("browser.snapshot", func(ctx context.Context) error {
 			var html, url string
 			if err := chromedp.Location(&url).Do(ctx); err == nil {
 				if err := chromedp.OuterHTML("html", &html).Do(ctx); err == nil {
@@ -1007,7 +1127,7 @@ func (b *BrowserClient) captureHTMLSnapshots(ctx context.Context, targetURL stri
 						currentURL = url
 					}
 					textLength := len(strings.TrimSpace(html))
-					fmt.Printf("Captured after-scroll snapshot: %d chars\n", textLength)
+					b.debug(ctx, "captured snapshot", "stage", "after-scroll", "html_chars", textLength)
 					if textLength > 0 {
 						snapshots = append(snapshots, HTMLSnapshot{
 							HTML:      html,
@@ -1020,13 +1140,13 @@ func (b *BrowserClient) captureHTMLSnapshots(ctx context.Context, targetURL stri
 				}
 			}
 			return nil
-		}),
+		}, slog.String("stage", "after-scroll")),
 
 		// Phase 5: Wait for stability (captures inline during stability checks)
-		chromedp.ActionFunc(func(ctx context.Context) error {
+		tracedAction("browser.stability_wait", func(ctx context.Context) error {
 			stableSnap := b.waitForContentStabilityInline(ctx, maxChallengeWait, &snapshots)
 			if stableSnap != nil {
-				fmt.Printf("Captured stable snapshot: %d chars\n", stableSnap.Length)
+				b.debug(ctx, "captured snapshot", "stage", "stable", "html_chars", stableSnap.Length)
 			}
 			return nil
 		}),
@@ -1037,6 +1157,11 @@ func (b *BrowserClient) captureHTMLSnapshots(ctx context.Context, targetURL stri
 			if finalURL == "" {
 				finalURL = currentURL
 			}
+			// Chrome has already decoded the page; report the encoding it used
+			var characterSet string
+			if err := chromedp.Evaluate("document.characterSet", &characterSet).Do(ctx); err == nil {
+				pagesFrom(ctx).record(finalURL, pageInfo{Encoding: strings.ToLower(characterSet)})
+			}
 			return nil
 		}),
 	})
@@ -1050,7 +1175,7 @@ func (b *BrowserClient) captureHTMLSnapshots(ctx context.Context, targetURL stri
 	}
 	
 	if len(captureErrors) > 0 {
-		fmt.Printf("Navigation had errors but captured %d snapshots\n", len(snapshots))
+		b.info(ctx, "navigation had errors but captured snapshots", "snapshots", len(snapshots))
 	}
 
 	// Ensure we have at least one snapshot - use whatever HTML we captured
@@ -1066,7 +1191,7 @@ func (b *BrowserClient) captureHTMLSnapshots(ctx context.Context, targetURL stri
 
 		if bestHTML != "" {
 			textLength := len(strings.TrimSpace(bestHTML))
-			fmt.Printf("Creating fallback snapshot from captured HTML: %d chars\n", textLength)
+			b.debug(ctx, "creating fallback snapshot from captured HTML", "html_chars", textLength)
 			snapshots = append(snapshots, HTMLSnapshot{
 				HTML:      bestHTML,
 				URL:       currentURL,
@@ -1076,8 +1201,10 @@ func (b *BrowserClient) captureHTMLSnapshots(ctx context.Context, targetURL stri
 			})
 		} else {
 			// Last resort: try minimal navigation
-			fmt.Printf("No HTML captured, trying minimal navigation strategy\n")
-			minHTML, minURL, minErr := b.minimalNavigation(ctx, targetURL)
+			b.info(ctx, "no HTML captured, trying minimal navigation strategy")
+			minCtx, span := tracing.Start(ctx, "browser.minimal_navigation")
+			minHTML, minURL, minErr := b.minimalNavigation(minCtx, targetURL)
+			endSpan(span, minErr)
 			if minErr == nil && len(minHTML) > 0 {
 				textLength := len(strings.TrimSpace(minHTML))
 				snapshots = append(snapshots, HTMLSnapshot{
@@ -1087,11 +1214,14 @@ func (b *BrowserClient) captureHTMLSnapshots(ctx context.Context, targetURL stri
 					Stage:     "minimal-fallback",
 					Length:    textLength,
 				})
-				fmt.Printf("Minimal navigation captured: %d chars\n", textLength)
+				b.debug(ctx, "minimal navigation captured HTML", "html_chars", textLength)
 			} else {
 				// Final fallback: try JavaScript fetch
-				fmt.Printf("Minimal navigation failed, trying JavaScript fetch fallback\n")
-				jsHTML := b.fetchHTMLViaJS(ctx, targetURL)
+				b.info(ctx, "minimal navigation failed, trying JavaScript fetch fallback")
+				jsCtx, span := tracing.Start(ctx, "browser.js_fetch")
+				jsHTML := b.fetchHTMLViaJS(jsCtx, targetURL)
+				span.SetAttributes(slog.Int("html_bytes", len(jsHTML)))
+				span.End()
 				if len(jsHTML) > 0 {
 					snapshots = append(snapshots, HTMLSnapshot{
 						HTML:      jsHTML,
@@ -1100,7 +1230,7 @@ func (b *BrowserClient) captureHTMLSnapshots(ctx context.Context, targetURL stri
 						Stage:     "js-fetch-fallback",
 						Length:    len(strings.TrimSpace(jsHTML)),
 					})
-					fmt.Printf("JS fetch captured: %d chars\n", len(strings.TrimSpace(jsHTML)))
+					b.debug(ctx, "JavaScript fetch captured HTML", "html_chars", len(strings.TrimSpace(jsHTML)))
 				} else {
 					// Last resort: try final capture
 					finalSnap := b.captureSnapshotFallback(ctx)
@@ -1123,7 +1253,7 @@ func (b *BrowserClient) captureHTMLSnapshots(ctx context.Context, targetURL stri
 		combinedErr = fmt.Errorf("capture errors: %v", captureErrors)
 	}
 
-	fmt.Printf("Total snapshots captured: %d\n", len(snapshots))
+	b.info(ctx, "snapshot capture finished", "snapshots", len(snapshots))
 	return snapshots, finalURL, combinedErr
 }
 
@@ -1197,14 +1327,14 @@ func (b *BrowserClient) waitForContentStabilityInline(ctx context.Context, maxWa
 	stableThreshold := 3 // Number of consecutive stable checks needed
 	checkInterval := 500 * time.Millisecond
 
-	fmt.Printf("Waiting for content stability (max: %v)\n", maxWait)
+	b.debug(ctx, "waiting for content stability", "max_wait", maxWait)
 
 	var lastSnap *HTMLSnapshot
 
 	for {
 		select {
 		case <-stabilityCtx.Done():
-			fmt.Printf("Stability wait timeout, returning latest snapshot\n")
+			b.debug(ctx, "stability wait timeout, returning latest snapshot")
 			// Return whatever we have
 			if lastSnap != nil {
 				lastSnap.Stage = "stable-timeout"
@@ -1257,8 +1387,7 @@ func (b *BrowserClient) waitForContentStabilityInline(ctx context.Context, maxWa
 		if previousLength > 0 && percentChange < 5.0 {
 			stableCount++
 			if stableCount >= stableThreshold {
-				fmt.Printf("Content stabilized after %d checks (length: %d, stable for %d checks)\n",
-					stableCount, currentLength, stableThreshold)
+				b.debug(ctx, "content stabilized", "checks", stableCount, "html_chars", currentLength, "stable_checks", stableThreshold)
 				snap.Stage = "stable"
 				*snapshots = append(*snapshots, *snap)
 				return snap
@@ -1268,10 +1397,9 @@ func (b *BrowserClient) waitForContentStabilityInline(ctx context.Context, maxWa
 		}
 
 		if previousLength > 0 {
-			fmt.Printf("Stability check: length=%d (change: %d, %.1f%%), stable_count=%d\n",
-				currentLength, lengthDiff, percentChange, stableCount)
+			b.debug(ctx, "stability check", "html_chars", currentLength, "change", lengthDiff, "change_percent", percentChange, "stable_count", stableCount)
 		} else {
-			fmt.Printf("Stability check: initial length=%d\n", currentLength)
+			b.debug(ctx, "stability check", "html_chars", currentLength)
 		}
 
 		previousLength = currentLength
@@ -1291,12 +1419,12 @@ func (b *BrowserClient) waitForContentStability(ctx context.Context, maxWait tim
 	stableThreshold := 3 // Number of consecutive stable checks needed
 	checkInterval := 500 * time.Millisecond
 
-	fmt.Printf("Waiting for content stability (max: %v)\n", maxWait)
+	b.debug(ctx, "waiting for content stability", "max_wait", maxWait)
 
 	for {
 		select {
 		case <-stabilityCtx.Done():
-			fmt.Printf("Stability wait timeout, returning latest snapshot\n")
+			b.debug(ctx, "stability wait timeout, returning latest snapshot")
 			// Return whatever we have
 			return b.captureSnapshot(ctx, "stable-timeout")
 		case <-ctx.Done():
@@ -1325,8 +1453,7 @@ func (b *BrowserClient) waitForContentStability(ctx context.Context, maxWait tim
 		if previousLength > 0 && percentChange < 5.0 {
 			stableCount++
 			if stableCount >= stableThreshold {
-				fmt.Printf("Content stabilized after %d checks (length: %d, stable for %d checks)\n",
-					stableCount, currentLength, stableThreshold)
+				b.debug(ctx, "content stabilized", "checks", stableCount, "html_chars", currentLength, "stable_checks", stableThreshold)
 				return snap
 			}
 		} else {
@@ -1334,10 +1461,9 @@ func (b *BrowserClient) waitForContentStability(ctx context.Context, maxWait tim
 		}
 
 		if previousLength > 0 {
-			fmt.Printf("Stability check: length=%d (change: %d, %.1f%%), stable_count=%d\n",
-				currentLength, lengthDiff, percentChange, stableCount)
+			b.debug(ctx, "stability check", "html_chars", currentLength, "change", lengthDiff, "change_percent", percentChange, "stable_count", stableCount)
 		} else {
-			fmt.Printf("Stability check: initial length=%d\n", currentLength)
+			b.debug(ctx, "stability check", "html_chars", currentLength)
 		}
 
 		previousLength = currentLength
@@ -1413,7 +1539,7 @@ func (b *BrowserClient) navigateWithTimeout(ctx context.Context, url string, max
 	select {
 	case err := <-errChan:
 		if err != nil && navCtx.Err() == context.DeadlineExceeded {
-			fmt.Printf("Navigation timeout after %v (will try to capture HTML anyway)\n", maxWait)
+			b.warn(ctx, "navigation timeout, will try to capture HTML anyway", "waited", maxWait)
 			// Don't return error - allow HTML capture to proceed
 			return nil
 		}
@@ -1483,10 +1609,10 @@ func (b *BrowserClient) captureHTMLPeriodically(ctx context.Context, interval, m
 					Length:    textLength,
 				}
 				periodicSnapshots = append(periodicSnapshots, snap)
-				fmt.Printf("Periodic capture: %d chars\n", textLength)
+				b.debug(ctx, "periodic capture", "html_chars", textLength)
 				// If we have meaningful content (>500 chars), we can stop early
 				if textLength > 500 {
-					fmt.Printf("Got meaningful content (%d chars), stopping periodic capture\n", textLength)
+					b.debug(ctx, "got meaningful content, stopping periodic capture", "html_chars", textLength)
 					return periodicSnapshots
 				}
 			}
@@ -1532,7 +1658,7 @@ func (b *BrowserClient) minimalNavigation(ctx context.Context, targetURL string)
 			Stage:     "minimal",
 			Length:    textLength,
 		})
-		fmt.Printf("Minimal navigation captured: %d chars\n", textLength)
+		b.debug(ctx, "minimal navigation captured HTML", "html_chars", textLength)
 		return html, url, nil
 	}
 
@@ -1659,9 +1785,9 @@ func (b *BrowserClient) waitForContentSelectors(ctx context.Context, maxWait tim
 		fullScript := fmt.Sprintf(`(%s)(%s)`, checkScript, selectorsJSON)
 		if err := chromedp.Evaluate(fullScript, &found).Do(ctx); err == nil && found {
 			if deadline, ok := waitCtx.Deadline(); ok {
-				fmt.Printf("Content selector found (wait: %v)\n", maxWait-time.Until(deadline))
+				b.debug(ctx, "content selector found", "waited", maxWait-time.Until(deadline))
 			} else {
-				fmt.Printf("Content selector found\n")
+				b.debug(ctx, "content selector found")
 			}
 			return true
 		}
@@ -1718,13 +1844,13 @@ func (b *BrowserClient) waitForNetworkIdle(ctx context.Context, maxWait time.Dur
 	err := chromedp.Evaluate(fmt.Sprintf("(%s)(%d)", networkIdleScript, int(maxWait.Milliseconds())), nil).Do(waitCtx)
 	if err != nil {
 		if waitCtx.Err() == context.DeadlineExceeded {
-			fmt.Printf("Network idle wait timeout after %v\n", maxWait)
+			b.debug(ctx, "network idle wait timeout", "waited", maxWait)
 			return nil // Not a critical error, continue anyway
 		}
 		return err
 	}
 
-	fmt.Printf("Network idle detected\n")
+	b.debug(ctx, "network idle detected")
 	return nil
 }
 
@@ -0,0 +1,88 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/chromedp/chromedp"
+)
+
+// ErrBrowserClosed is returned by browser scrapes started after BrowserClient.Close
+var ErrBrowserClosed = errors.New("browser client is closed")
+
+// allocatorSet tracks the running Chrome allocators so they can all be torn down on shutdown
+// The zero value is ready to use
+type allocatorSet struct {
+	mu      sync.Mutex
+	closed  bool
+	nextID  int
+	cancels map[int]context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+// start launches a Chrome allocator that close can tear down
+// The returned release function stops Chrome and must be called once the allocator is no longer needed
+func (a *allocatorSet) start(ctx context.Context, opts ...chromedp.ExecAllocatorOption) (context.Context, context.CancelFunc, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.closed {
+		return nil, nil, ErrBrowserClosed
+	}
+	if a.cancels == nil {
+		a.cancels = make(map[int]context.CancelFunc)
+	}
+
+	// The allocator's cancel function kills Chrome and waits for it to exit
+	allocCtx, cancel := chromedp.NewExecAllocator(ctx, opts...)
+	id := a.nextID
+	a.nextID++
+	a.cancels[id] = cancel
+	a.wg.Add(1)
+
+	var once sync.Once
+	release := func() {
+		once.Do(func() {
+			cancel()
+			a.mu.Lock()
+			delete(a.cancels, id)
+			a.mu.Unlock()
+			a.wg.Done()
+		})
+	}
+	return allocCtx, release, nil
+}
+
+// close refuses new allocators, stops the running ones and waits for their scrapes to release them
+func (a *allocatorSet) close(ctx context.Context) error {
+	a.mu.Lock()
+	a.closed = true
+	cancels := make([]context.CancelFunc, 0, len(a.cancels))
+	for _, cancel := range a.cancels {
+		cancels = append(cancels, cancel)
+	}
+	a.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		for _, cancel := range cancels {
+			cancel()
+		}
+		a.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close stops every Chrome instance started by the client and waits for them to exit
+// Scrapes still running fail, and later scrapes return ErrBrowserClosed
+func (b *BrowserClient) Close(ctx context.Context) error {
+	return b.allocators.close(ctx)
+}
@@ -0,0 +1,42 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCloseWaitsForAllocatorsAndRefusesNewOnes(t *testing.T) {
+	b := NewBrowserClient()
+	s := NewScraperWithFetchers(NewArticleExtractor(), NewHTTPClient(), b)
+
+	// The allocator does not launch Chrome until a browser context runs on it
+	allocCtx, release, err := b.allocators.start(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- s.Close(context.Background()) }()
+
+	select {
+	case <-allocCtx.Done():
+	case <-time.After(2 * time.Second):
+		t.Fatal("Close did not cancel the running allocator")
+	}
+	select {
+	case err := <-done:
+		t.Fatalf("Close returned %v before the allocator was released", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	release()
+	if err := <-done; err != nil {
+		t.Fatalf("Close returned %v", err)
+	}
+
+	if _, _, err := b.ScrapeWithBrowser(context.Background(), "https://example.com", 1000); !errors.Is(err, ErrBrowserClosed) {
+		t.Fatalf("expected ErrBrowserClosed, got %v", err)
+	}
+}
@@ -0,0 +1,44 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/chromedp/chromedp"
+)
+
+// readinessTitle is set by the script in readinessPage once Chrome has run JavaScript
+const readinessTitle = "rendered"
+
+// readinessPage is a trivial page that proves Chrome can parse HTML and run scripts
+var readinessPage = "data:text/html," + url.PathEscape(
+	"<html><head><title>loading</title></head><body><p>ready</p>"+
+		"<script>document.title = '"+readinessTitle+"'</script></body></html>")
+
+// CheckChrome starts a Chrome allocator with the client's options and renders a trivial page
+// It returns an error when Chrome is missing, crashes, or cannot render before ctx expires
+func (b *BrowserClient) CheckChrome(ctx context.Context) error {
+	opts := b.options
+	if opts.UserAgent == "" {
+		opts.UserAgent = b.userAgent()
+	}
+
+	allocCtx, cancel, err := b.allocators.start(ctx, BuildChromeOptions(opts)...)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	browserCtx, cancel := chromedp.NewContext(allocCtx, chromedp.WithLogf(func(string, ...interface{}) {}))
+	defer cancel()
+
+	var title string
+	if err := chromedp.Run(browserCtx, chromedp.Navigate(readinessPage), chromedp.Title(&title)); err != nil {
+		return fmt.Errorf("chrome failed to render test page: %w", err)
+	}
+	if title != readinessTitle {
+		return fmt.Errorf("chrome rendered test page with unexpected title %q", title)
+	}
+	return nil
+}
@@ -2,6 +2,8 @@
 package scraper
 
 import (
+	"encoding/json"
+
 	"github.com/chromedp/chromedp"
 )
 
@@ -104,18 +106,15 @@ func BuildChromeOptions(opts BrowserOptions) []chromedp.ExecAllocatorOption {
 	return chromeOpts
 }
 
-// GetRequestBlockingScript returns JavaScript for blocking unwanted requests
-func GetRequestBlockingScript(opts BrowserOptions) string {
+// GetRequestBlockingScript returns JavaScript for blocking unwanted requests, including those to
+// URLs containing any of blockedDomains
+func GetRequestBlockingScript(opts BrowserOptions, blockedDomains []string) string {
 	script := `
 		const originalFetch = window.fetch;
 		const originalXHR = window.XMLHttpRequest;
 		
 		// Block ads and trackers
-		const blockedDomains = [
-			'doubleclick', 'googlesyndication', 'google-analytics',
-			'facebook.com/tr', 'taboola', 'outbrain', 'scorecardresearch',
-			'chartbeat', 'amazon-adsystem'
-		];
+		const blockedDomains = ` + blockedDomainsJSON(blockedDomains) + `;
 		
 		// Override fetch
 		window.fetch = function(...args) {
@@ -223,3 +222,12 @@ func GetRequestBlockingScript(opts BrowserOptions) string {
 
 	return script
 }
+
+// blockedDomainsJSON returns blocked domains as a JavaScript array literal
+func blockedDomainsJSON(blockedDomains []string) string {
+	domains, err := json.Marshal(blockedDomains)
+	if err != nil || string(domains) == "null" {
+		return "[]"
+	}
+	return string(domains)
+}
@@ -0,0 +1,73 @@
+package scraper
+
+import (
+	"context"
+	"sync"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/fetch"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/config"
+)
+
+// redirectGuard holds back the redirects of a tab's navigations until robots.txt admits their
+// target, so Chrome never fetches a disallowed page; it remembers the refusal for the navigation
+type redirectGuard struct {
+	mu  sync.Mutex
+	err error
+}
+
+// guardRedirects installs a redirectGuard on the tab of ctx, which must already be running
+// Only documents loaded in the tab's main frame after a redirect are checked: the pages navigated
+// to were admitted before, and what a page loads itself is not a crawler fetch
+// Nothing is intercepted unless robots.txt compliance is required for ctx or by settings
+func guardRedirects(ctx context.Context, settings *config.Settings) (*redirectGuard, error) {
+	guard := &redirectGuard{}
+	if !robotsRequired(ctx, settings) {
+		return guard, nil
+	}
+
+	target := chromedp.FromContext(ctx).Target
+	mainFrame := cdp.FrameID(target.TargetID)
+	executor := cdp.WithExecutor(ctx, target)
+	chromedp.ListenTarget(ctx, func(ev interface{}) {
+		paused, ok := ev.(*fetch.EventRequestPaused)
+		if !ok {
+			return
+		}
+		// Checking robots.txt may download it, which must not block the tab's event loop
+		go func() {
+			if paused.FrameID == mainFrame && paused.RedirectedRequestID != "" {
+				if err := sharedRobots.Admit(ctx, settings, paused.Request.URL); err != nil {
+					guard.refuse(err)
+					_ = fetch.FailRequest(paused.RequestID, network.ErrorReasonBlockedByClient).Do(executor)
+					return
+				}
+			}
+			_ = fetch.ContinueRequest(paused.RequestID).Do(executor)
+		}()
+	})
+
+	patterns := []*fetch.RequestPattern{{URLPattern: "*", ResourceType: network.ResourceTypeDocument}}
+	return guard, chromedp.Run(ctx, fetch.Enable().WithPatterns(patterns))
+}
+
+// refuse records why a redirect was blocked, keeping the first reason
+func (g *redirectGuard) refuse(err error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.err == nil {
+		g.err = err
+	}
+}
+
+// take returns and clears the reason a redirect was blocked since the previous call, if any
+func (g *redirectGuard) take() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	err := g.err
+	g.err = nil
+	return err
+}
@@ -0,0 +1,292 @@
+package scraper
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/cache"
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/logging"
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/metrics"
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/models"
+)
+
+// Cache results reported in response metadata and metrics
+const (
+	CacheHit         = "hit"         // Served from the cache without contacting the site
+	CacheRevalidated = "revalidated" // The site answered 304, so the cached result was served
+	cacheRefreshed   = "refreshed"   // The conditional request returned a new page, extracted again
+	cacheMiss        = "miss"        // Scraped in full
+)
+
+// SetCache makes the scraper serve results from store while they are younger than maxAge,
+// or than the MaxAge of the extraction options when set, and store what it scrapes
+// Stale results fetched over HTTP are revalidated with a conditional request
+func (s *Scraper) SetCache(store cache.Store, maxAge time.Duration) {
+	s.cache = store
+	s.cacheMaxAge = maxAge
+}
+
+// scrapeCached runs scrapeWithMode behind the cache, when there is one
+// Debug requests skip the lookup, since a cached result cannot explain how it was produced
+func (s *Scraper) scrapeCached(ctx context.Context, targetURL string, mode FetchMode, options ExtractionOptions) (models.ScrapeResponse, error) {
+	if s.cache == nil {
+		result, _, err := s.scrapeWithMode(ctx, targetURL, mode, options)
+		return result, err
+	}
+
+	if err := sharedRobots.Allowed(ctx, s.settings, targetURL); err != nil {
+		return models.ScrapeResponse{}, err
+	}
+
+	// Results scraped without honoring robots.txt are kept apart from those that must, so
+	// neither a cached result nor an in-flight scrape crosses over
+	key := cacheKey(targetURL, mode, options, robotsRequired(ctx, s.settings))
+	maxAge := s.cacheMaxAge
+	if options.MaxAge != nil {
+		maxAge = time.Duration(*options.MaxAge) * time.Second
+	}
+
+	if entry, ok := s.cache.Get(key); ok && !options.Debug {
+		// The page may have been served after a redirect that robots.txt has since disallowed
+		if entry.FetchURL != "" {
+			if err := sharedRobots.Allowed(ctx, s.settings, entry.FetchURL); err != nil {
+				return models.ScrapeResponse{}, err
+			}
+		}
+		if age := entry.Age(time.Now()); age < maxAge {
+			s.debug(ctx, "serving cached result", "url", targetURL, "age", age)
+			return cached(entry, CacheHit), nil
+		}
+		if result, ok := s.revalidate(ctx, entry, options); ok {
+			return result, nil
+		}
+	}
+
+	if options.Debug {
+		return s.scrapeAndStore(ctx, key, targetURL, mode, options)
+	}
+
+	// Requests for a page already being scraped wait for that scrape rather than repeating it
+	f, leader := s.joinFlight(ctx, key)
+	if f == nil {
+		s.debug(ctx, "scraping alongside an in-flight scrape with a shorter deadline", "url", targetURL)
+		return s.scrapeAndStore(ctx, key, targetURL, mode, options)
+	}
+	if leader {
+		go s.runFlight(ctx, f, key, targetURL, mode, options)
+	} else {
+		s.debug(ctx, "sharing an in-flight scrape of the same page", "url", targetURL, "flight_request_id", f.requestID)
+	}
+	select {
+	case <-f.done:
+		return f.result, f.err
+	case <-ctx.Done():
+		if ctx.Err() == context.DeadlineExceeded {
+			return models.ScrapeResponse{}, &models.TimeoutError{Operation: "waiting for an in-flight scrape of the same page", Err: ctx.Err()}
+		}
+		return models.ScrapeResponse{}, fmt.Errorf("scraping failed: parent context expired waiting for an in-flight scrape: %w", ctx.Err())
+	}
+}
+
+// flight is a scrape of an uncached page shared by the requests for it that arrive meanwhile
+type flight struct {
+	deadline  time.Time // Zero when the scrape has no deadline
+	requestID string    // Of the request that started it, which its log lines carry
+	done      chan struct{}
+	result    models.ScrapeResponse
+	err       error
+}
+
+// joinFlight returns the flight scraping key, starting one with ctx's deadline if there is none,
+// and whether the caller started it and must run it
+// A request that could wait longer than the flight in progress would be failed by its deadline,
+// so it gets nil and scrapes on its own
+func (s *Scraper) joinFlight(ctx context.Context, key string) (*flight, bool) {
+	deadline, _ := ctx.Deadline()
+
+	s.flightsMu.Lock()
+	defer s.flightsMu.Unlock()
+	if f, ok := s.flights[key]; ok {
+		if !f.deadline.IsZero() && (deadline.IsZero() || deadline.After(f.deadline)) {
+			return nil, false
+		}
+		return f, false
+	}
+	if s.flights == nil {
+		s.flights = make(map[string]*flight)
+	}
+	f := &flight{deadline: deadline, requestID: logging.RequestID(ctx), done: make(chan struct{})}
+	s.flights[key] = f
+	return f, true
+}
+
+// runFlight scrapes the page for everyone waiting on f
+// The scrape keeps the starting request's values and deadline but not its cancellation, so a
+// client going away does not fail the requests that joined it
+func (s *Scraper) runFlight(ctx context.Context, f *flight, key, targetURL string, mode FetchMode, options ExtractionOptions) {
+	flightCtx := context.WithoutCancel(ctx)
+	if !f.deadline.IsZero() {
+		var cancel context.CancelFunc
+		flightCtx, cancel = context.WithDeadline(flightCtx, f.deadline)
+		defer cancel()
+	}
+	f.result, f.err = s.scrapeAndStore(flightCtx, key, targetURL, mode, options)
+
+	s.flightsMu.Lock()
+	delete(s.flights, key)
+	s.flightsMu.Unlock()
+	close(f.done)
+}
+
+// scrapeAndStore scrapes the page in full and caches the result under key
+func (s *Scraper) scrapeAndStore(ctx context.Context, key, targetURL string, mode FetchMode, options ExtractionOptions) (models.ScrapeResponse, error) {
+	result, validators, err := s.scrapeWithMode(ctx, targetURL, mode, options)
+	if err != nil {
+		return result, err
+	}
+	metrics.CacheTotal.Inc(cacheMiss)
+	s.store(key, result, validators)
+	return result, nil
+}
+
+// revalidate asks the site whether a stale entry's page changed, using the fetcher that produced it
+// It returns false when the entry cannot be revalidated or revalidation failed, so the page is scraped in full
+func (s *Scraper) revalidate(ctx context.Context, entry cache.Entry, options ExtractionOptions) (models.ScrapeResponse, bool) {
+	validators := Validators{ETag: entry.ETag, LastModified: entry.LastModified}
+	if validators.IsZero() || entry.FetchURL == "" {
+		return models.ScrapeResponse{}, false
+	}
+	var revalidator Revalidator
+	for _, f := range s.fetchers {
+		if r, ok := f.(Revalidator); ok && f.Name() == entry.Response.Metadata.Fetcher {
+			revalidator = r
+			break
+		}
+	}
+	if revalidator == nil {
+		return models.ScrapeResponse{}, false
+	}
+
+	if err := sharedRobots.Admit(ctx, s.settings, entry.FetchURL); err != nil {
+		return models.ScrapeResponse{}, false
+	}
+	release, waited, err := s.scheduler.Wait(ctx, entry.FetchURL, s.settings.Politeness)
+	if err != nil {
+		return models.ScrapeResponse{}, false
+	}
+	metrics.HostQueueWait.Observe(waited.Seconds(), entry.Response.Metadata.Fetcher)
+	start := time.Now()
+	page, err := revalidator.Revalidate(ctx, entry.FetchURL, validators)
+	release()
+	switch {
+	case errors.Is(err, ErrNotModified):
+		s.debug(ctx, "cached result revalidated", "url", entry.FetchURL, "duration", time.Since(start))
+		entry.StoredAt = time.Now()
+		s.cache.Put(entry)
+		result := cached(entry, CacheRevalidated)
+		result.Metadata.QueueWaitMs = waited.Milliseconds()
+		return result, true
+	case err != nil:
+		s.debug(ctx, "revalidation failed, scraping again", "url", entry.FetchURL, "error", err)
+		return models.ScrapeResponse{}, false
+	}
+
+	page.Metadata.Fetcher = entry.Response.Metadata.Fetcher
+	page.Metadata.Duration = time.Since(start)
+	result, err := s.extractPage(ctx, 1, entry.FetchURL, page, options)
+	if err != nil {
+		s.debug(ctx, "changed page could not be extracted, scraping again", "url", entry.FetchURL, "error", err)
+		return models.ScrapeResponse{}, false
+	}
+	metrics.CacheTotal.Inc(cacheRefreshed)
+	s.store(entry.Key, result, page.Validators)
+	result.Metadata.QueueWaitMs = waited.Milliseconds()
+	return result, true
+}
+
+// store adds a successful result to the cache
+func (s *Scraper) store(key string, result models.ScrapeResponse, validators Validators) {
+	now := time.Now()
+	result.Metadata.ScrapedAt = now
+	result.Metadata.QueueWaitMs = 0
+	s.cache.Put(cache.Entry{
+		Key:          key,
+		Response:     result,
+		StoredAt:     now,
+		FetchURL:     result.Metadata.FinalURL,
+		ETag:         validators.ETag,
+		LastModified: validators.LastModified,
+	})
+}
+
+// cached returns the response held by entry, marked with how the cache served it
+func cached(entry cache.Entry, how string) models.ScrapeResponse {
+	metrics.CacheTotal.Inc(how)
+	result := entry.Response
+	result.Metadata.Cache = how
+	result.Metadata.ScrapedAt = entry.StoredAt
+	return result
+}
+
+// cacheKey identifies a scrape by its normalized URL, fetch mode, extraction options and whether
+// it honored robots.txt
+// Options that do not change the result, such as debug output, are left out
+func cacheKey(targetURL string, mode FetchMode, options ExtractionOptions, robots bool) string {
+	if mode == "" {
+		mode = FetchModeAuto
+	}
+	options.Debug = false
+	options.MaxAge = nil
+	encoded, _ := json.Marshal(options)
+
+	sum := sha256.New()
+	sum.Write([]byte(NormalizeURL(targetURL)))
+	sum.Write([]byte{0})
+	sum.Write([]byte(mode))
+	sum.Write([]byte{0})
+	sum.Write(encoded)
+	if robots {
+		sum.Write([]byte{0, 'r'})
+	}
+	return hex.EncodeToString(sum.Sum(nil))
+}
+
+// trackingParams are query parameters that do not change the page served
+var trackingParams = map[string]bool{
+	"fbclid": true, "gclid": true, "dclid": true, "msclkid": true, "mc_cid": true, "mc_eid": true, "ref_src": true,
+}
+
+// NormalizeURL returns a canonical form of rawURL for cache keys: lowercase scheme and host,
+// no default port, fragment or tracking parameters, and query parameters sorted by name
+// URLs that cannot be parsed are returned unchanged
+func NormalizeURL(rawURL string) string {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || u.Host == "" {
+		return rawURL
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	if port := u.Port(); (u.Scheme == "http" && port == "80") || (u.Scheme == "https" && port == "443") {
+		u.Host = u.Hostname()
+	}
+	u.Fragment, u.RawFragment = "", ""
+	if u.Path == "" {
+		u.Path = "/"
+	}
+
+	query := u.Query()
+	for name := range query {
+		if strings.HasPrefix(strings.ToLower(name), "utm_") || trackingParams[strings.ToLower(name)] {
+			query.Del(name)
+		}
+	}
+	u.RawQuery = query.Encode() // Encode sorts by parameter name
+	return u.String()
+}
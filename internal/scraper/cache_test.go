@@ -0,0 +1,195 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/cache"
+)
+
+func TestCacheServesFreshAndRevalidatesStaleResults(t *testing.T) {
+	var full, notModified atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		full.Add(1)
+		w.Header().Set("Content-Type", "text/html")
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte(fakeArticleHTML))
+	}))
+	defer server.Close()
+
+	s := NewScraperWithFetchers(NewArticleExtractor(), NewHTTPClient())
+	s.SetLogger(discardLogger())
+	s.SetCache(cache.NewLRU(10), time.Hour)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	scrape := func(targetURL string, maxAge *int) string {
+		t.Helper()
+		options := DefaultExtractionOptions()
+		options.MaxAge = maxAge
+		result, err := s.ScrapeSmartWithOptions(ctx, targetURL, options)
+		if err != nil || result.Title != "Fake Article" {
+			t.Fatalf("scrape %s: %q, %v", targetURL, result.Title, err)
+		}
+		return result.Metadata.Cache
+	}
+
+	if got := scrape(server.URL+"/article?b=2&a=1", nil); got != "" {
+		t.Errorf("first scrape served from cache (%q)", got)
+	}
+	if got := scrape(server.URL+"/article?a=1&b=2&utm_source=feed#comments", nil); got != CacheHit {
+		t.Errorf("same normalized URL: cache = %q, want hit", got)
+	}
+	zero := 0
+	if got := scrape(server.URL+"/article?a=1&b=2", &zero); got != CacheRevalidated {
+		t.Errorf("maxAge 0: cache = %q, want revalidated", got)
+	}
+	if full.Load() != 1 || notModified.Load() != 1 {
+		t.Errorf("site served %d full pages and %d 304s, want 1 and 1", full.Load(), notModified.Load())
+	}
+}
+
+func TestConcurrentMissesShareOneScrape(t *testing.T) {
+	var fetches atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		time.Sleep(200 * time.Millisecond) // Keep the first scrape in flight while the others arrive
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(fakeArticleHTML))
+	}))
+	defer server.Close()
+
+	s := NewScraperWithFetchers(NewArticleExtractor(), NewHTTPClient())
+	s.SetLogger(discardLogger())
+	s.SetCache(cache.NewLRU(10), time.Hour)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, err := s.ScrapeSmartWithOptions(ctx, server.URL+"/article", DefaultExtractionOptions())
+			if err != nil || result.Title != "Fake Article" {
+				t.Errorf("scrape: %q, %v", result.Title, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := fetches.Load(); n != 1 {
+		t.Errorf("site fetched %d times for concurrent requests, want 1", n)
+	}
+}
+
+func TestCancelledRequestDoesNotFailTheScrapeOthersShare(t *testing.T) {
+	var fetches atomic.Int32
+	started, release := make(chan struct{}), make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		close(started)
+		<-release
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(fakeArticleHTML))
+	}))
+	defer server.Close()
+	defer close(release)
+
+	s := NewScraperWithFetchers(NewArticleExtractor(), NewHTTPClient())
+	s.SetLogger(discardLogger())
+	s.SetCache(cache.NewLRU(10), time.Hour)
+
+	leaderCtx, cancelLeader := context.WithTimeout(context.Background(), 10*time.Second)
+	leaderErr := make(chan error, 1)
+	go func() {
+		_, err := s.ScrapeSmartWithOptions(leaderCtx, server.URL+"/article", DefaultExtractionOptions())
+		leaderErr <- err
+	}()
+	<-started
+	cancelLeader()
+	if err := <-leaderErr; err == nil {
+		t.Fatal("cancelled request succeeded")
+	}
+
+	// A deadline no later than the first request's joins the scrape still running for it
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	joined := make(chan error, 1)
+	go func() {
+		result, err := s.ScrapeSmartWithOptions(ctx, server.URL+"/article", DefaultExtractionOptions())
+		if err == nil && result.Title != "Fake Article" {
+			err = fmt.Errorf("title %q", result.Title)
+		}
+		joined <- err
+	}()
+	release <- struct{}{}
+	if err := <-joined; err != nil {
+		t.Errorf("joined scrape: %v", err)
+	}
+	if n := fetches.Load(); n != 1 {
+		t.Errorf("site fetched %d times, want 1", n)
+	}
+}
+
+func TestLongerDeadlineDoesNotJoinAShorterScrape(t *testing.T) {
+	var fetches atomic.Int32
+	started, release := make(chan struct{}), make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fetches.Add(1) == 1 {
+			close(started)
+			<-release
+		}
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(fakeArticleHTML))
+	}))
+	defer server.Close()
+
+	s := NewScraperWithFetchers(NewArticleExtractor(), NewHTTPClient())
+	s.SetLogger(discardLogger())
+	s.SetCache(cache.NewLRU(10), time.Hour)
+
+	shortCtx, cancelShort := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelShort()
+	shortDone := make(chan struct{})
+	go func() {
+		defer close(shortDone)
+		s.ScrapeSmartWithOptions(shortCtx, server.URL+"/article", DefaultExtractionOptions())
+	}()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	result, err := s.ScrapeSmartWithOptions(ctx, server.URL+"/article", DefaultExtractionOptions())
+	close(release)
+	<-shortDone
+	if err != nil || result.Title != "Fake Article" {
+		t.Fatalf("scrape: %q, %v", result.Title, err)
+	}
+	if n := fetches.Load(); n != 2 {
+		t.Errorf("site fetched %d times, want 2", n)
+	}
+}
+
+func TestNormalizeURL(t *testing.T) {
+	tests := map[string]string{
+		"HTTPS://Example.COM:443?b=2&a=1#top":             "https://example.com/?a=1&b=2",
+		"http://example.com:8080/a?utm_medium=x&fbclid=1": "http://example.com:8080/a",
+		"not a url": "not a url",
+	}
+	for in, want := range tests {
+		if got := NormalizeURL(in); got != want {
+			t.Errorf("NormalizeURL(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
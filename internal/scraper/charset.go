@@ -0,0 +1,166 @@
+package scraper
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gogs/chardet"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/charset"
+	"golang.org/x/text/encoding"
+)
+
+// Where the encoding of a page was found, from most to least authoritative
+const (
+	encodingFromBOM     = "bom"
+	encodingFromHeader  = "header"
+	encodingFromMeta    = "meta"
+	encodingFromSniff   = "sniffed"
+	encodingFromDefault = "default" // Nothing declared and nothing recognized: windows-1252, as browsers do
+)
+
+const (
+	metaPrescanBytes   = 1024     // Browsers only look for <meta charset> this far into a page
+	sniffBytes         = 64 << 10 // Bytes examined when guessing an undeclared encoding
+	minSniffConfidence = 30       // Guesses below this chardet confidence fall back to the default
+)
+
+// byteOrderMarks identify Unicode encodings by their first bytes
+var byteOrderMarks = []struct {
+	bom      []byte
+	encoding string
+}{
+	{[]byte{0xEF, 0xBB, 0xBF}, "utf-8"},
+	{[]byte{0xFE, 0xFF}, "utf-16be"},
+	{[]byte{0xFF, 0xFE}, "utf-16le"},
+}
+
+// charsetParam matches the charset parameter of a Content-Type value
+var charsetParam = regexp.MustCompile(`(?i)charset\s*=\s*["']?\s*([a-z0-9_.:-]+)`)
+
+// decodeHTML converts a fetched page to UTF-8, returning it with the name of the encoding it
+// was served in and where that was found
+// The encoding comes from, in order, a byte order mark, the Content-Type header, a <meta>
+// declaration near the top of the page, and finally a guess from the bytes themselves
+func decodeHTML(body []byte, contentType string) (string, string, string) {
+	for _, mark := range byteOrderMarks {
+		if bytes.HasPrefix(body, mark.bom) {
+			e, name := charset.Lookup(mark.encoding)
+			return transcode(body[len(mark.bom):], e, name), name, encodingFromBOM
+		}
+	}
+
+	e, name, source := detectEncoding(body, contentType)
+	return transcode(body, e, name), name, source
+}
+
+// detectEncoding finds the encoding of a page without a byte order mark
+func detectEncoding(body []byte, contentType string) (encoding.Encoding, string, string) {
+	if e, name := lookupCharsetParam(contentType); e != nil {
+		return e, name, encodingFromHeader
+	}
+	if e, name := metaCharset(body); e != nil {
+		// Markup that could be read as ASCII is not UTF-16, whatever it claims
+		if strings.HasPrefix(name, "utf-16") {
+			e, name = charset.Lookup("utf-8")
+		}
+		return e, name, encodingFromMeta
+	}
+	return sniffEncoding(body)
+}
+
+// lookupCharsetParam returns the encoding named by the charset parameter of a Content-Type value
+func lookupCharsetParam(contentType string) (encoding.Encoding, string) {
+	m := charsetParam.FindStringSubmatch(contentType)
+	if m == nil {
+		return nil, ""
+	}
+	return charset.Lookup(m[1])
+}
+
+// metaCharset returns the encoding declared by a <meta charset> or <meta http-equiv="Content-Type">
+// element in the first metaPrescanBytes of body
+func metaCharset(body []byte) (encoding.Encoding, string) {
+	if len(body) > metaPrescanBytes {
+		body = body[:metaPrescanBytes]
+	}
+	z := html.NewTokenizer(bytes.NewReader(body))
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return nil, ""
+		case html.StartTagToken, html.SelfClosingTagToken:
+			tag, hasAttr := z.TagName()
+			if string(tag) != "meta" || !hasAttr {
+				continue
+			}
+			var declared, httpEquiv, content string
+			for more := true; more; {
+				var key, value []byte
+				key, value, more = z.TagAttr()
+				switch string(key) {
+				case "charset":
+					declared = string(value)
+				case "http-equiv":
+					httpEquiv = string(value)
+				case "content":
+					content = string(value)
+				}
+			}
+			if declared != "" {
+				if e, name := charset.Lookup(declared); e != nil {
+					return e, name
+				}
+			}
+			if strings.EqualFold(httpEquiv, "content-type") {
+				if e, name := lookupCharsetParam(content); e != nil {
+					return e, name
+				}
+			}
+		}
+	}
+}
+
+// sniffEncoding guesses the encoding of a page that declares none
+func sniffEncoding(body []byte) (encoding.Encoding, string, string) {
+	sample := body
+	if len(sample) > sniffBytes {
+		sample = sample[:sniffBytes]
+		// Drop a character cut in half by the sample boundary
+		for i := len(sample) - 1; i >= 0 && i > len(sample)-utf8.UTFMax; i-- {
+			if utf8.RuneStart(sample[i]) {
+				if !utf8.FullRune(sample[i:]) {
+					sample = sample[:i]
+				}
+				break
+			}
+		}
+	}
+	if utf8.Valid(sample) {
+		e, name := charset.Lookup("utf-8")
+		return e, name, encodingFromSniff
+	}
+
+	if guess, err := chardet.NewHtmlDetector().DetectBest(sample); err == nil && guess.Confidence >= minSniffConfidence {
+		// chardet names GB 18030 differently from the WHATWG encoding labels
+		if e, name := charset.Lookup(strings.Replace(guess.Charset, "GB-18030", "gb18030", 1)); e != nil {
+			return e, name, encodingFromSniff
+		}
+	}
+	e, name := charset.Lookup("windows-1252")
+	return e, name, encodingFromDefault
+}
+
+// transcode converts body from e to UTF-8; bytes that are invalid in e become U+FFFD
+func transcode(body []byte, e encoding.Encoding, name string) string {
+	if e == nil || name == "utf-8" {
+		return string(body)
+	}
+	decoded, err := e.NewDecoder().Bytes(body)
+	if err != nil {
+		return string(body)
+	}
+	return string(decoded)
+}
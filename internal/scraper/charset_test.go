@@ -0,0 +1,101 @@
+package scraper
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/traditionalchinese"
+	"golang.org/x/text/encoding/unicode"
+)
+
+func encode(t *testing.T, e encoding.Encoding, s string) []byte {
+	t.Helper()
+	b, err := e.NewEncoder().Bytes([]byte(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestDecodeHTML(t *testing.T) {
+	const japaneseText = "日本語の記事本文です。文字化けせずに読めることを確認します。東京都の天気は晴れ、明日は雨の予報です。"
+	const chineseText = "这是一篇中文新闻文章。我们需要确认标题和正文都能正确显示，而不是乱码。北京今天天气晴朗。"
+	const traditionalText = "這是一篇繁體中文新聞文章。我們需要確認標題和正文都能正確顯示，而不是亂碼。台北今天天氣晴朗。"
+	page := func(head, text string) string {
+		return "<html><head>" + head + "<title>T</title></head><body><p>" + text + "</p></body></html>"
+	}
+
+	tests := []struct {
+		name        string
+		body        []byte
+		contentType string
+		want        string
+		encoding    string
+		source      string
+	}{
+		{"header", encode(t, japanese.ShiftJIS, page("", japaneseText)), "text/html; charset=Shift_JIS",
+			japaneseText, "shift_jis", encodingFromHeader},
+		{"meta charset", encode(t, simplifiedchinese.GBK, page(`<meta charset="gbk">`, chineseText)), "text/html",
+			chineseText, "gbk", encodingFromMeta},
+		{"meta http-equiv", encode(t, traditionalchinese.Big5, page(`<meta http-equiv="Content-Type" content="text/html; charset=big5">`, traditionalText)), "text/html",
+			traditionalText, "big5", encodingFromMeta},
+		// The header wins over the page's own declaration
+		{"header over meta", encode(t, charmap.Windows1252, page(`<meta charset="utf-8">`, "Café déjà vu")), "text/html; charset=windows-1252",
+			"Café déjà vu", "windows-1252", encodingFromHeader},
+		// ISO-8859-1 is read as its windows-1252 superset, as browsers do
+		{"latin-1", encode(t, charmap.ISO8859_1, page("", "Ça coûte dix euros à l'hôtel")), "text/html; charset=ISO-8859-1",
+			"Ça coûte dix euros à l'hôtel", "windows-1252", encodingFromHeader},
+		{"bom", append([]byte{0xFF, 0xFE}, encode(t, unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM), page(`<meta charset="windows-1252">`, "Grüße"))...), "text/html; charset=iso-8859-1",
+			"Grüße", "utf-16le", encodingFromBOM},
+		{"sniffed shift_jis", encode(t, japanese.ShiftJIS, page("", strings.Repeat(japaneseText, 4))), "text/html",
+			japaneseText, "shift_jis", encodingFromSniff},
+		{"sniffed utf-8", []byte(page("", japaneseText)), "text/html", japaneseText, "utf-8", encodingFromSniff},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			html, encoding, source := decodeHTML(tt.body, tt.contentType)
+			if !strings.Contains(html, tt.want) {
+				t.Errorf("decoded page does not contain %q:\n%s", tt.want, html)
+			}
+			if encoding != tt.encoding || source != tt.source {
+				t.Errorf("encoding = %q from %s, want %q from %s", encoding, source, tt.encoding, tt.source)
+			}
+		})
+	}
+}
+
+func TestScrapeReportsPageEncoding(t *testing.T) {
+	title := "東京の天気"
+	body := "<html><head><meta charset=\"Shift_JIS\"><title>" + title + "</title></head><body><article><h1>" + title + "</h1>" +
+		strings.Repeat("<p>今日の東京は朝から晴れて、気温は二十度まで上がる見込みです。午後は風が強くなるでしょう。</p>", 5) +
+		"</article></body></html>"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write(encode(t, japanese.ShiftJIS, body))
+	}))
+	defer server.Close()
+
+	s := NewScraperWithFetchers(NewArticleExtractor(), NewHTTPClient())
+	s.SetLogger(discardLogger())
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	result, err := s.ScrapeSmartWithOptions(ctx, server.URL+"/article", DefaultExtractionOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Title != title || !strings.Contains(result.Content, "気温は二十度") {
+		t.Errorf("title %q, content %q: want them decoded from Shift_JIS", result.Title, result.Content)
+	}
+	if result.Metadata.Encoding != "shift_jis" {
+		t.Errorf("metadata encoding = %q, want shift_jis", result.Metadata.Encoding)
+	}
+}
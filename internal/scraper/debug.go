@@ -0,0 +1,158 @@
+package scraper
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/models"
+)
+
+// debugRecorder collects the DebugTrace of one scrape or extraction
+// Fetchers and the extractor report into the recorder carried by their context; the details
+// they report are attached to the phase the scraper ends next
+// All methods are safe to call on a nil recorder, which is what debugFrom returns when
+// debug output was not requested
+type debugRecorder struct {
+	mu    sync.Mutex
+	trace models.DebugTrace
+
+	// Details reported during the current phase
+	urls          []models.DebugURLAttempt
+	snapshots     []models.DebugSnapshot
+	snapshotTimes []time.Time // Capture time of each snapshot, used to find the selected one
+	strategies    []models.DebugStrategy
+}
+
+type debugKey struct{}
+
+// withDebug returns a copy of ctx carrying a new recorder
+func withDebug(ctx context.Context) (context.Context, *debugRecorder) {
+	d := &debugRecorder{}
+	return context.WithValue(ctx, debugKey{}, d), d
+}
+
+// debugFrom returns the recorder carried by ctx, or nil
+func debugFrom(ctx context.Context) *debugRecorder {
+	d, _ := ctx.Value(debugKey{}).(*debugRecorder)
+	return d
+}
+
+// recordURL records a fetch of the original URL or an alternate
+func (d *debugRecorder) recordURL(url string, alternate bool, duration time.Duration, htmlBytes int, truncated string, err error) {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.urls = append(d.urls, models.DebugURLAttempt{
+		URL:        url,
+		Alternate:  alternate,
+		DurationMs: duration.Milliseconds(),
+		HTMLBytes:  htmlBytes,
+		Truncated:  truncated,
+		Error:      errorString(err),
+	})
+}
+
+// recordSnapshots records the snapshots captured by one browser navigation attempt
+func (d *debugRecorder) recordSnapshots(attempt int, snapshots []HTMLSnapshot) {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, snap := range snapshots {
+		d.snapshots = append(d.snapshots, models.DebugSnapshot{
+			Attempt: attempt,
+			Stage:   snap.Stage,
+			Bytes:   snap.Length,
+			URL:     snap.URL,
+		})
+		d.snapshotTimes = append(d.snapshotTimes, snap.Timestamp)
+	}
+}
+
+// selectSnapshot marks the recorded snapshot that getBestHTML picked
+func (d *debugRecorder) selectSnapshot(best *HTMLSnapshot) {
+	if d == nil || best == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for i := range d.snapshots {
+		if d.snapshots[i].Stage == best.Stage && d.snapshots[i].Bytes == best.Length && d.snapshotTimes[i].Equal(best.Timestamp) {
+			d.snapshots[i].Selected = true
+			return
+		}
+	}
+}
+
+// recordStrategies records every strategy's result and composite score, marking the selected one
+func (d *debugRecorder) recordStrategies(results []models.ScrapeResponse, strategies []string, best extractionResultWithStrategy) {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for i, result := range results {
+		strategy := "unknown"
+		if i < len(strategies) {
+			strategy = strategies[i]
+		}
+		entry := models.DebugStrategy{
+			Strategy:     strategy,
+			TitleChars:   len(result.Title),
+			ContentChars: len(result.Content),
+			Quality:      result.Quality,
+			Selected:     i == best.Index,
+		}
+		if i < len(best.Scores) {
+			entry.CompositeScore = best.Scores[i]
+		}
+		d.strategies = append(d.strategies, entry)
+	}
+}
+
+// endPhase records a fetcher attempt together with the details reported since the previous phase
+func (d *debugRecorder) endPhase(phase int, fetcher, outcome string, duration time.Duration, page FetchResult, err error) {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.trace.Phases = append(d.trace.Phases, models.DebugPhase{
+		Phase:      phase,
+		Fetcher:    fetcher,
+		Outcome:    outcome,
+		DurationMs: duration.Milliseconds(),
+		Error:      errorString(err),
+		FinalURL:   page.FinalURL,
+		HTMLBytes:  len(page.HTML),
+		Truncated:  page.Truncated,
+		URLs:       d.urls,
+		Snapshots:  d.snapshots,
+		Strategies: d.strategies,
+	})
+	d.urls, d.snapshots, d.snapshotTimes, d.strategies = nil, nil, nil, nil
+}
+
+// result returns the collected trace; strategies reported outside a phase, as when extracting
+// supplied HTML, are returned at the top level
+func (d *debugRecorder) result() *models.DebugTrace {
+	if d == nil {
+		return nil
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	trace := d.trace
+	trace.Strategies = d.strategies
+	return &trace
+}
+
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
@@ -1,5 +1,21 @@
 package scraper
 
+import "fmt"
+
+// Output formats supported by the extractor
+const (
+	OutputFormatText     = "text"
+	OutputFormatMarkdown = "markdown"
+	OutputFormatHTML     = "html"
+)
+
+// HTML output sanitization profiles
+const (
+	HTMLProfileStrict = "strict" // Structural elements only: headings, paragraphs, lists, quotes, code
+	HTMLProfileLinks  = "links"  // Structural elements plus links and images (default)
+	HTMLProfileTables = "tables" // Links and images plus tables
+)
+
 // ExtractionOptions defines configurable options for article extraction
 type ExtractionOptions struct {
 	PreserveHTML      bool   `json:"preserveHtml"`
@@ -7,7 +23,11 @@ type ExtractionOptions struct {
 	MinTextLength     int    `json:"minTextLength"`
 	MinParagraphChars int    `json:"minParagraphChars"`
 	RemoveComments    bool   `json:"removeComments"`
-	OutputFormat      string `json:"outputFormat"` // "text", "markdown", "html"
+	OutputFormat      string `json:"outputFormat"`          // "text", "markdown", "html"
+	HTMLProfile       string `json:"htmlProfile,omitempty"` // "strict", "links", "tables"; html output only
+	Debug             bool   `json:"debug,omitempty"`       // Attach a DebugTrace explaining how the result was produced
+	MaxAge            *int   `json:"maxAge,omitempty"`      // Seconds a cached result may be old; 0 revalidates, nil uses the cache default
+	MaxBytes          int    `json:"maxBytes,omitempty"`    // Most of a fetched page to read, capped by the configured maximum; 0 uses the configured limits
 }
 
 // DefaultExtractionOptions returns sensible defaults for extraction
@@ -16,23 +36,99 @@ func DefaultExtractionOptions() ExtractionOptions {
 		PreserveHTML:      false,
 		IncludeMetadata:   true,
 		MinTextLength:     100,
-		MinParagraphChars: 40,
+		MinParagraphChars: 21, // A minimum: keeps the historical filter that dropped lines of 20 bytes or fewer
 		RemoveComments:    true,
-		OutputFormat:      "text",
+		OutputFormat:      OutputFormatText,
 	}
 }
 
+// LegacyExtractionOptions returns the options GET / has always extracted with: the simple
+// strategy only runs when readability finds no content at all, and comment sections are kept
+func LegacyExtractionOptions() ExtractionOptions {
+	opts := DefaultExtractionOptions()
+	opts.MinTextLength = 1
+	opts.RemoveComments = false
+	return opts
+}
+
 // HTMLExtractionOptions returns options for HTML output
 func HTMLExtractionOptions() ExtractionOptions {
 	opts := DefaultExtractionOptions()
 	opts.PreserveHTML = true
-	opts.OutputFormat = "html"
+	opts.OutputFormat = OutputFormatHTML
 	return opts
 }
 
 // MarkdownExtractionOptions returns options for markdown output
 func MarkdownExtractionOptions() ExtractionOptions {
 	opts := DefaultExtractionOptions()
-	opts.OutputFormat = "markdown"
+	opts.OutputFormat = OutputFormatMarkdown
 	return opts
 }
+
+// Validate checks that the options can be honoured by the extractor
+// An empty output format is text, and preserveHtml selects html output whatever the format
+func (o ExtractionOptions) Validate() error {
+	switch o.OutputFormat {
+	case "", OutputFormatText, OutputFormatMarkdown, OutputFormatHTML:
+	default:
+		return fmt.Errorf("unknown output format %q (expected text, markdown or html)", o.OutputFormat)
+	}
+
+	switch o.HTMLProfile {
+	case "":
+	case HTMLProfileStrict, HTMLProfileLinks, HTMLProfileTables:
+		if !o.wantsHTML() {
+			return fmt.Errorf("htmlProfile requires outputFormat \"html\"")
+		}
+	default:
+		return fmt.Errorf("unknown htmlProfile %q (expected strict, links or tables)", o.HTMLProfile)
+	}
+	if o.MinTextLength < 0 {
+		return fmt.Errorf("minTextLength must not be negative")
+	}
+	if o.MinParagraphChars < 0 {
+		return fmt.Errorf("minParagraphChars must not be negative")
+	}
+	if o.MaxAge != nil && *o.MaxAge < 0 {
+		return fmt.Errorf("maxAge must not be negative")
+	}
+	if o.MaxBytes < 0 {
+		return fmt.Errorf("maxBytes must not be negative")
+	}
+
+	return nil
+}
+
+// Normalized returns the options with their effective output format spelled out, so options
+// producing the same result compare and cache alike: preserveHtml becomes outputFormat "html",
+// and an empty format "text"
+func (o ExtractionOptions) Normalized() ExtractionOptions {
+	o.OutputFormat = o.format()
+	o.PreserveHTML = o.OutputFormat == OutputFormatHTML
+	return o
+}
+
+// wantsHTML reports whether the content should be returned as sanitized HTML
+func (o ExtractionOptions) wantsHTML() bool {
+	return o.PreserveHTML || o.OutputFormat == OutputFormatHTML
+}
+
+// format returns the effective output format, treating an empty value as text
+func (o ExtractionOptions) format() string {
+	if o.wantsHTML() {
+		return OutputFormatHTML
+	}
+	if o.OutputFormat == "" {
+		return OutputFormatText
+	}
+	return o.OutputFormat
+}
+
+// htmlProfile returns the effective HTML sanitization profile
+func (o ExtractionOptions) htmlProfile() string {
+	if o.HTMLProfile == "" {
+		return HTMLProfileLinks
+	}
+	return o.HTMLProfile
+}
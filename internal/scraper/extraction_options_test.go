@@ -0,0 +1,55 @@
+package scraper
+
+import "testing"
+
+func TestExtractionOptionsValidate(t *testing.T) {
+	negative := -1
+	tests := []struct {
+		name    string
+		modify  func(o *ExtractionOptions)
+		wantErr bool
+		format  string // Normalized output format of valid options
+	}{
+		{"defaults", func(o *ExtractionOptions) {}, false, OutputFormatText},
+		{"empty output format is text", func(o *ExtractionOptions) { o.OutputFormat = "" }, false, OutputFormatText},
+		{"markdown", func(o *ExtractionOptions) { o.OutputFormat = OutputFormatMarkdown }, false, OutputFormatMarkdown},
+		{"preserveHtml with the default format", func(o *ExtractionOptions) { o.PreserveHTML = true }, false, OutputFormatHTML},
+		{"preserveHtml without a format", func(o *ExtractionOptions) { o.PreserveHTML, o.OutputFormat = true, "" }, false, OutputFormatHTML},
+		{"preserveHtml with html", func(o *ExtractionOptions) { o.PreserveHTML, o.OutputFormat = true, OutputFormatHTML }, false, OutputFormatHTML},
+		{"htmlProfile with preserveHtml", func(o *ExtractionOptions) { o.PreserveHTML, o.HTMLProfile = true, HTMLProfileTables }, false, OutputFormatHTML},
+		{"unknown output format", func(o *ExtractionOptions) { o.OutputFormat = "pdf" }, true, ""},
+		{"htmlProfile with text", func(o *ExtractionOptions) { o.HTMLProfile = HTMLProfileStrict }, true, ""},
+		{"unknown htmlProfile", func(o *ExtractionOptions) { o.OutputFormat, o.HTMLProfile = OutputFormatHTML, "loose" }, true, ""},
+		{"negative maxAge", func(o *ExtractionOptions) { o.MaxAge = &negative }, true, ""},
+		{"negative maxBytes", func(o *ExtractionOptions) { o.MaxBytes = -1 }, true, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := DefaultExtractionOptions()
+			tt.modify(&options)
+
+			err := options.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			normalized := options.Normalized()
+			if normalized.OutputFormat != tt.format || normalized.PreserveHTML != (tt.format == OutputFormatHTML) {
+				t.Errorf("normalized to outputFormat %q, preserveHtml %v; want %q", normalized.OutputFormat, normalized.PreserveHTML, tt.format)
+			}
+		})
+	}
+}
+
+func TestNormalizedOptionsShareCacheKeys(t *testing.T) {
+	legacy := DefaultExtractionOptions()
+	legacy.PreserveHTML = true
+	html := HTMLExtractionOptions()
+	html.PreserveHTML = false
+
+	if cacheKey("https://example.com/a", FetchModeAuto, legacy.Normalized(), false) != cacheKey("https://example.com/a", FetchModeAuto, html.Normalized(), false) {
+		t.Errorf("preserveHtml and outputFormat html are cached apart")
+	}
+}
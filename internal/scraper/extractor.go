@@ -1,10 +1,14 @@
 package scraper
 
 import (
-	"fmt"
+	"context"
+	"log/slog"
 	"strings"
 
-	"extract-html-scraper/internal/models"
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/config"
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/metrics"
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/models"
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/tracing"
 
 	"github.com/PuerkitoBio/goquery"
 	"github.com/go-shiori/go-readability"
@@ -12,24 +16,44 @@ import (
 )
 
 type ArticleExtractor struct {
-	sanitizer     *bluemonday.Policy
-	htmlSanitizer *bluemonday.Policy
+	logSink
+	sanitizer      *bluemonday.Policy
+	htmlSanitizers map[string]*bluemonday.Policy // Keyed by HTML output profile
+	images         *ImageExtractor
+
+	contentSelectors []string // Tried in order to find the article container when readability fails
 }
 
 func NewArticleExtractor() *ArticleExtractor {
+	return NewArticleExtractorWithImages(NewImageExtractor())
+}
+
+// NewArticleExtractorWithImages creates an article extractor that uses the given image extractor
+func NewArticleExtractorWithImages(images *ImageExtractor) *ArticleExtractor {
 	// Configure bluemonday for HTML sanitization
 	policy := bluemonday.StrictPolicy()
 
-	// Configure HTML sanitizer for preserving structure
-	htmlPolicy := bluemonday.UGCPolicy()
-	htmlPolicy.AllowElements("p", "br", "h1", "h2", "h3", "h4", "h5", "h6", "strong", "em", "blockquote", "ul", "ol", "li")
-
 	return &ArticleExtractor{
-		sanitizer:     policy,
-		htmlSanitizer: htmlPolicy,
+		sanitizer:        policy,
+		htmlSanitizers:   newHTMLSanitizers(),
+		images:           images,
+		contentSelectors: config.DefaultSettings().ContentSelectors,
 	}
 }
 
+// NewArticleExtractorWithSettings creates an article extractor using the image filtering and
+// content selectors of settings
+func NewArticleExtractorWithSettings(settings *config.Settings) *ArticleExtractor {
+	ae := NewArticleExtractorWithImages(NewImageExtractorWithConfig(settings.Image))
+	ae.contentSelectors = settings.ContentSelectors
+	return ae
+}
+
+// SetLogger sends the extractor's progress messages to logger instead of slog.Default()
+func (ae *ArticleExtractor) SetLogger(logger *slog.Logger) {
+	ae.logger = withRequestIDs(logger)
+}
+
 // ExtractArticleWithOptions extracts content with configurable options
 func (ae *ArticleExtractor) ExtractArticleWithOptions(html, baseURL string, options ExtractionOptions) models.ScrapeResponse {
 	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
@@ -42,16 +66,23 @@ func (ae *ArticleExtractor) ExtractArticleWithOptions(html, baseURL string, opti
 	title := ae.extractTitle(doc)
 	description := ae.extractDescription(doc)
 
+	// Drop reader comment threads before looking for the article body
+	if options.RemoveComments {
+		doc.Find(CommentSelectors).Remove()
+	}
+
 	var content string
-	if options.PreserveHTML {
-		content = ae.extractContentAsHTML(doc)
-	} else {
-		content = ae.extractContent(doc)
+	switch options.format() {
+	case OutputFormatHTML:
+		content = ae.extractContentAsHTML(doc, baseURL, options.htmlProfile())
+	case OutputFormatMarkdown:
+		content = ae.extractContentAsMarkdown(doc, baseURL)
+	default:
+		content = ae.extractContent(doc, options.MinParagraphChars)
 	}
 
 	// Extract images using the optimized image extractor
-	imageExtractor := NewImageExtractor()
-	images := imageExtractor.ExtractImagesFromHTML(html, baseURL)
+	images := ae.images.ExtractImagesFromHTML(html, baseURL)
 
 	// Extract metadata if requested
 	var metadata models.ScrapeResponse
@@ -97,7 +128,10 @@ func (ae *ArticleExtractor) ExtractArticle(html, baseURL string) models.ScrapeRe
 }
 
 // extractContentAsHTML extracts content preserving HTML structure
-func (ae *ArticleExtractor) extractContentAsHTML(doc *goquery.Document) string {
+// Links and images are made absolute against baseURL before sanitizing with the given profile
+func (ae *ArticleExtractor) extractContentAsHTML(doc *goquery.Document, baseURL, profile string) string {
+	policy := ae.htmlSanitizers[profile]
+
 	// First, try to use readability algorithm for better content extraction
 	html, err := doc.Html()
 	if err == nil {
@@ -105,18 +139,38 @@ func (ae *ArticleExtractor) extractContentAsHTML(doc *goquery.Document) string {
 		article, err := readability.FromReader(strings.NewReader(html), nil)
 		if err == nil && article.Content != "" {
 			// Sanitize HTML content while preserving structure
-			return ae.htmlSanitizer.Sanitize(article.Content)
+			return policy.Sanitize(absolutizeHTML(article.Content, baseURL))
 		}
 	}
 
 	// Fallback to original selector-based approach if readability fails
-	return ae.extractContentFallbackAsHTML(doc)
+	return ae.extractContentFallbackAsHTML(doc, baseURL, policy)
+}
+
+// extractContentAsMarkdown extracts the article body and renders it as markdown
+func (ae *ArticleExtractor) extractContentAsMarkdown(doc *goquery.Document, baseURL string) string {
+	html, err := doc.Html()
+	if err == nil {
+		article, err := readability.FromReader(strings.NewReader(html), nil)
+		if err == nil && article.Content != "" {
+			if markdown := ConvertHTMLToMarkdown(article.Content, baseURL); markdown != "" {
+				return markdown
+			}
+		}
+	}
+
+	// Fallback to the selected content container if readability fails
+	htmlContent, err := FindContentContainer(doc, ae.contentSelectors).Html()
+	if err != nil {
+		return ""
+	}
+	return ConvertHTMLToMarkdown(htmlContent, baseURL)
 }
 
 // extractContentFallbackAsHTML provides HTML-based content extraction fallback
-func (ae *ArticleExtractor) extractContentFallbackAsHTML(doc *goquery.Document) string {
+func (ae *ArticleExtractor) extractContentFallbackAsHTML(doc *goquery.Document, baseURL string, policy *bluemonday.Policy) string {
 	// Find the main content container
-	contentElement := FindContentContainer(doc)
+	contentElement := FindContentContainer(doc, ae.contentSelectors)
 
 	// Get HTML content and sanitize it
 	htmlContent, err := contentElement.Html()
@@ -124,7 +178,7 @@ func (ae *ArticleExtractor) extractContentFallbackAsHTML(doc *goquery.Document)
 		return ""
 	}
 
-	return ae.htmlSanitizer.Sanitize(htmlContent)
+	return policy.Sanitize(absolutizeHTML(htmlContent, baseURL))
 }
 
 // sanitizeText sanitizes text content
@@ -195,7 +249,7 @@ func (ae *ArticleExtractor) extractDescription(doc *goquery.Document) string {
 }
 
 // extractContent extracts the main article content using readability algorithm
-func (ae *ArticleExtractor) extractContent(doc *goquery.Document) string {
+func (ae *ArticleExtractor) extractContent(doc *goquery.Document, minParagraphChars int) string {
 	// First, try to use readability algorithm for better content extraction
 	html, err := doc.Html()
 	if err == nil {
@@ -203,16 +257,16 @@ func (ae *ArticleExtractor) extractContent(doc *goquery.Document) string {
 		article, err := readability.FromReader(strings.NewReader(html), nil)
 		if err == nil && article.Content != "" {
 			// Convert readability's HTML content to structured text
-			return ae.convertHTMLToStructuredText(article.Content)
+			return ae.convertHTMLToStructuredText(article.Content, minParagraphChars)
 		}
 	}
 
 	// Fallback to original selector-based approach if readability fails
-	return ae.extractContentFallback(doc)
+	return ae.extractContentFallback(doc, minParagraphChars)
 }
 
 // convertHTMLToStructuredText converts HTML content to structured text
-func (ae *ArticleExtractor) convertHTMLToStructuredText(htmlContent string) string {
+func (ae *ArticleExtractor) convertHTMLToStructuredText(htmlContent string, minParagraphChars int) string {
 	doc, err := goquery.NewDocumentFromReader(strings.NewReader(htmlContent))
 	if err != nil {
 		return ae.sanitizeText(htmlContent)
@@ -227,14 +281,14 @@ func (ae *ArticleExtractor) convertHTMLToStructuredText(htmlContent string) stri
 	}
 
 	// Clean up whitespace and remove noise
-	content = CleanTextContent(content)
+	content = CleanTextContentWithMinLength(content, minParagraphChars)
 	return ae.sanitizeText(content)
 }
 
 // extractContentFallback provides the original selector-based content extraction
-func (ae *ArticleExtractor) extractContentFallback(doc *goquery.Document) string {
+func (ae *ArticleExtractor) extractContentFallback(doc *goquery.Document, minParagraphChars int) string {
 	// Find the main content container
-	contentElement := FindContentContainer(doc)
+	contentElement := FindContentContainer(doc, ae.contentSelectors)
 
 	// Extract structured text from the container
 	content := ExtractTextFromElements(contentElement, TextElements)
@@ -245,7 +299,7 @@ func (ae *ArticleExtractor) extractContentFallback(doc *goquery.Document) string
 	}
 
 	// Clean up whitespace and remove noise
-	content = CleanTextContent(content)
+	content = CleanTextContentWithMinLength(content, minParagraphChars)
 	return ae.sanitizeText(content)
 }
 
@@ -314,8 +368,7 @@ func (ae *ArticleExtractor) ExtractArticleSimple(html, baseURL string) models.Sc
 	})
 
 	// Extract images
-	imageExtractor := NewImageExtractor()
-	images := imageExtractor.ExtractImagesFromHTML(html, baseURL)
+	images := ae.images.ExtractImagesFromHTML(html, baseURL)
 
 	return models.ScrapeResponse{
 		Title:       ae.sanitizeText(title),
@@ -332,23 +385,29 @@ func (ae *ArticleExtractor) ExtractArticleSimple(html, baseURL string) models.Sc
 // 3. Simple extraction (ExtractArticleSimple)
 // 4. Metadata-only extraction (at least get title/description)
 // Returns the result with highest quality score
-func (ae *ArticleExtractor) ExtractArticleWithMultipleStrategies(html, baseURL string) models.ScrapeResponse {
+// Strategies that can only produce plain text (JSON-LD, simple) are skipped for
+// html and markdown output so the content format always matches the request
+func (ae *ArticleExtractor) ExtractArticleWithMultipleStrategies(ctx context.Context, html, baseURL string, options ExtractionOptions) models.ScrapeResponse {
 	var results []models.ScrapeResponse
 	var strategies []string
 
 	// Parse HTML once for all strategies
 	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
 	if err != nil {
-		fmt.Printf("Failed to parse HTML: %v\n", err)
+		ae.warn(ctx, "failed to parse HTML", "error", err)
 		return models.ScrapeResponse{Images: []models.Image{}}
 	}
 
+	textOutput := options.format() == OutputFormatText
+
 	// Strategy 0: Try JSON-LD structured data first (best for news sites like SCMP)
-	fmt.Printf("Extraction strategy 0: JSON-LD structured data\n")
-	if headline, body, description, found := ExtractJSONLD(doc); found {
+	if !textOutput {
+		ae.debug(ctx, "extraction strategy skipped", "strategy", "jsonld", "format", options.format())
+	} else if headline, body, description, found := ExtractJSONLD(doc); found {
+		ae.debug(ctx, "running extraction strategy", "strategy", "jsonld")
+		_, span := tracing.Start(ctx, "extract.strategy", slog.String("strategy", "jsonld"))
 		// Extract images using the optimized image extractor
-		imageExtractor := NewImageExtractor()
-		images := imageExtractor.ExtractImagesFromHTML(html, baseURL)
+		images := ae.images.ExtractImagesFromHTML(html, baseURL)
 
 		// If articleBody is available, use it
 		content := body
@@ -377,28 +436,33 @@ func (ae *ArticleExtractor) ExtractArticleWithMultipleStrategies(html, baseURL s
 		}
 		results = append(results, result0)
 		strategies = append(strategies, "jsonld")
-		fmt.Printf("Strategy 0 result: title=%d chars, content=%d chars, quality=%d\n",
-			len(result0.Title), len(result0.Content), result0.Quality.Score)
+		endStrategySpan(span, result0)
+		ae.debug(ctx, "extraction strategy result", "strategy", "jsonld",
+			"title_chars", len(result0.Title), "content_chars", len(result0.Content), "quality", result0.Quality.Score)
 
 		// If JSON-LD has good content, might be sufficient, but continue for comparison
 	}
 
 	// Strategy 1: Full extraction with readability
-	fmt.Printf("Extraction strategy 1: Full extraction with readability\n")
-	result1 := ae.ExtractArticle(html, baseURL)
+	ae.debug(ctx, "running extraction strategy", "strategy", "readability")
+	_, span := tracing.Start(ctx, "extract.strategy", slog.String("strategy", "readability"))
+	result1 := ae.ExtractArticleWithOptions(html, baseURL, options)
 	results = append(results, result1)
 	strategies = append(strategies, "readability")
-	fmt.Printf("Strategy 1 result: title=%d chars, content=%d chars, quality=%d\n",
-		len(result1.Title), len(result1.Content), result1.Quality.Score)
-
-	// Strategy 2: Simple extraction (fallback if readability fails)
-	if len(result1.Content) == 0 || len(result1.Title) == 0 || result1.Quality.Score < 30 {
-		fmt.Printf("Extraction strategy 2: Simple extraction\n")
+	endStrategySpan(span, result1)
+	ae.debug(ctx, "extraction strategy result", "strategy", "readability",
+		"title_chars", len(result1.Title), "content_chars", len(result1.Content), "quality", result1.Quality.Score)
+
+	// Strategy 2: Simple extraction (fallback if readability fails or finds too little text)
+	if textOutput && (len(result1.Content) < options.MinTextLength || len(result1.Title) == 0 || result1.Quality.Score < 30) {
+		ae.debug(ctx, "running extraction strategy", "strategy", "simple")
+		_, span := tracing.Start(ctx, "extract.strategy", slog.String("strategy", "simple"))
 		result2 := ae.ExtractArticleSimple(html, baseURL)
 		results = append(results, result2)
 		strategies = append(strategies, "simple")
-		fmt.Printf("Strategy 2 result: title=%d chars, content=%d chars\n",
-			len(result2.Title), len(result2.Content))
+		endStrategySpan(span, result2)
+		ae.debug(ctx, "extraction strategy result", "strategy", "simple",
+			"title_chars", len(result2.Title), "content_chars", len(result2.Content))
 	}
 
 	// Strategy 3: Metadata-only (last resort - at least get title/description)
@@ -410,25 +474,42 @@ func (ae *ArticleExtractor) ExtractArticleWithMultipleStrategies(html, baseURL s
 		}
 	}
 	if allEmpty {
-		fmt.Printf("Extraction strategy 3: Metadata-only extraction\n")
+		ae.debug(ctx, "running extraction strategy", "strategy", "metadata-only")
+		_, span := tracing.Start(ctx, "extract.strategy", slog.String("strategy", "metadata-only"))
 		result3 := ae.ExtractMetadataOnly(html, baseURL)
 		results = append(results, result3)
 		strategies = append(strategies, "metadata-only")
-		fmt.Printf("Strategy 3 result: title=%d chars, description=%d chars\n",
-			len(result3.Title), len(result3.Description))
+		endStrategySpan(span, result3)
+		ae.debug(ctx, "extraction strategy result", "strategy", "metadata-only",
+			"title_chars", len(result3.Title), "description_chars", len(result3.Description))
 	}
 
 	// Select best result based on quality score and content length
 	best := ae.selectBestResult(results, strategies)
-	fmt.Printf("Selected best result: strategy=%s, quality=%d, title=%d chars, content=%d chars\n",
-		best.Strategy, best.Result.Quality.Score, len(best.Result.Title), len(best.Result.Content))
+	debugFrom(ctx).recordStrategies(results, strategies, best)
+	metrics.ExtractionStrategyTotal.Inc(best.Strategy)
+	metrics.QualityScore.Observe(float64(best.Result.Quality.Score), best.Strategy)
+	ae.info(ctx, "selected best extraction result", "strategy", best.Strategy,
+		"quality", best.Result.Quality.Score, "title_chars", len(best.Result.Title), "content_chars", len(best.Result.Content))
 	return best.Result
 }
 
+// endStrategySpan records the size and quality of a strategy's result and ends its span
+func endStrategySpan(span *tracing.Span, result models.ScrapeResponse) {
+	span.SetAttributes(
+		slog.Int("title_chars", len(result.Title)),
+		slog.Int("content_chars", len(result.Content)),
+		slog.Int("quality", result.Quality.Score),
+	)
+	span.End()
+}
+
 // ExtractionResult wraps a result with its strategy name for selection
 type extractionResultWithStrategy struct {
 	Result   models.ScrapeResponse
 	Strategy string
+	Index    int   // Position of the selected result, -1 when there are none
+	Scores   []int // Composite score of every result, in order
 }
 
 // selectBestResult chooses the best extraction result based on multiple criteria
@@ -437,11 +518,13 @@ func (ae *ArticleExtractor) selectBestResult(results []models.ScrapeResponse, st
 		return extractionResultWithStrategy{
 			Result:   models.ScrapeResponse{Images: []models.Image{}},
 			Strategy: "none",
+			Index:    -1,
 		}
 	}
 
 	var best extractionResultWithStrategy
 	bestScore := -1
+	scores := make([]int, 0, len(results))
 
 	for i, result := range results {
 		strategy := "unknown"
@@ -468,16 +551,19 @@ func (ae *ArticleExtractor) selectBestResult(results []models.ScrapeResponse, st
 		if strategy == "readability" && score >= bestScore-10 && len(result.Content) > 0 {
 			score += 5 // Small bonus for preferred strategy
 		}
+		scores = append(scores, score)
 
 		if score > bestScore || (score == bestScore && len(result.Content) > len(best.Result.Content)) {
 			bestScore = score
 			best = extractionResultWithStrategy{
 				Result:   result,
 				Strategy: strategy,
+				Index:    i,
 			}
 		}
 	}
 
+	best.Scores = scores
 	return best
 }
 
@@ -507,8 +593,7 @@ func (ae *ArticleExtractor) ExtractMetadataOnly(html, baseURL string) models.Scr
 	metadata := ae.extractMetadataFromReadability(html)
 
 	// Extract images
-	imageExtractor := NewImageExtractor()
-	images := imageExtractor.ExtractImagesFromHTML(html, baseURL)
+	images := ae.images.ExtractImagesFromHTML(html, baseURL)
 
 	return models.ScrapeResponse{
 		Title:       ae.sanitizeText(title),
@@ -80,12 +80,9 @@ func ExtractFallbackText(selection *goquery.Selection) string {
 	return text
 }
 
-// FindContentContainer finds the main content container using common selectors
-func FindContentContainer(doc *goquery.Document) *goquery.Selection {
-	selectors := strings.Split(ContentSelectors, ", ")
-
+// FindContentContainer finds the main content container using the first of selectors that matches
+func FindContentContainer(doc *goquery.Document, selectors []string) *goquery.Selection {
 	for _, selector := range selectors {
-		selector = strings.TrimSpace(selector)
 		if doc.Find(selector).Length() > 0 {
 			return doc.Find(selector).First()
 		}
@@ -0,0 +1,133 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Names of the built-in fetchers, matching the fetch modes that select them
+const (
+	FetcherHTTP    = "http"
+	FetcherBrowser = "browser"
+)
+
+// Fetcher retrieves the HTML for a URL
+// Scraper tries its fetchers in order until one returns a page that yields an article
+type Fetcher interface {
+	// Name identifies the fetcher in logs and response metadata
+	Name() string
+	// Fetch returns the page for targetURL, respecting the deadline on ctx
+	Fetch(ctx context.Context, targetURL string) (FetchResult, error)
+}
+
+// FetchResult is a page returned by a Fetcher
+type FetchResult struct {
+	HTML       string
+	FinalURL   string     // URL the HTML was actually served from, used to resolve relative links
+	Validators Validators // ETag and Last-Modified of FinalURL, when the fetcher can revalidate it
+	Encoding   string     // Character encoding the page was served in, such as "shift_jis", when known
+	Truncated  string     // TruncatedSizeLimit or TruncatedAfterContent when the page was not read to its end
+	Metadata   FetchMetadata
+}
+
+// FetchMetadata describes how a page was fetched
+type FetchMetadata struct {
+	Fetcher  string        // Set by Scraper to the name of the fetcher that produced the page
+	Duration time.Duration // Set by Scraper to the time spent in Fetch
+}
+
+// Name implements Fetcher
+func (h *HTTPClient) Name() string {
+	return FetcherHTTP
+}
+
+// Fetch implements Fetcher using plain HTTP with AMP and mobile alternates
+func (h *HTTPClient) Fetch(ctx context.Context, targetURL string) (FetchResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, adjustTimeoutForBudget(h.settings.Timeouts.HTTP(), calculateRemainingTime(ctx), 1.0))
+	defer cancel()
+
+	ctx, pages := withPageRecorder(ctx)
+	html, finalURL, err := h.FetchWithAlternatesGroup(ctx, targetURL)
+	if err != nil {
+		return FetchResult{}, err
+	}
+	page := pages.get(finalURL)
+	return FetchResult{HTML: html, FinalURL: finalURL, Validators: page.Validators, Encoding: page.Encoding, Truncated: page.Truncated}, nil
+}
+
+// Name implements Fetcher
+func (b *BrowserClient) Name() string {
+	return FetcherBrowser
+}
+
+// Fetch implements Fetcher using headless Chrome
+// A few seconds of the budget are kept back for browser cleanup
+func (b *BrowserClient) Fetch(ctx context.Context, targetURL string) (FetchResult, error) {
+	remainingTime := calculateRemainingTime(ctx)
+	maxBrowserTime := remainingTime - 5*time.Second
+	if maxBrowserTime < 1*time.Second {
+		return FetchResult{}, fmt.Errorf("insufficient time budget for browser (remaining: %v)", remainingTime)
+	}
+	browserTimeout := adjustTimeoutForBudget(b.currentSettings().Timeouts.Browser(), maxBrowserTime, 1.0)
+
+	ctx, cancel := context.WithTimeout(ctx, browserTimeout)
+	defer cancel()
+
+	ctx, pages := withPageRecorder(ctx)
+	html, finalURL, err := b.ScrapeWithBrowserOptimized(ctx, targetURL, int(browserTimeout.Milliseconds()))
+	if err != nil {
+		return FetchResult{}, err
+	}
+	return FetchResult{HTML: html, FinalURL: finalURL, Encoding: pages.get(finalURL).Encoding}, nil
+}
+
+// pageInfo is what a fetch learned about a page besides its HTML
+type pageInfo struct {
+	Validators Validators
+	Encoding   string
+	Truncated  string
+}
+
+// pageRecorder collects what one fetch learned about each page it fetched, by URL,
+// so the fetcher can report it for the URL it ends up using
+// Its methods are safe to call on a nil recorder
+type pageRecorder struct {
+	mu    sync.Mutex
+	byURL map[string]pageInfo
+}
+
+type pageRecorderKey struct{}
+
+// withPageRecorder returns a copy of ctx carrying a new recorder
+func withPageRecorder(ctx context.Context) (context.Context, *pageRecorder) {
+	r := &pageRecorder{byURL: make(map[string]pageInfo)}
+	return context.WithValue(ctx, pageRecorderKey{}, r), r
+}
+
+// pagesFrom returns the recorder carried by ctx, or nil
+func pagesFrom(ctx context.Context) *pageRecorder {
+	r, _ := ctx.Value(pageRecorderKey{}).(*pageRecorder)
+	return r
+}
+
+// record stores what was learned about the page at url
+func (r *pageRecorder) record(url string, info pageInfo) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.byURL[url] = info
+}
+
+// get returns what was recorded for url
+func (r *pageRecorder) get(url string) pageInfo {
+	if r == nil {
+		return pageInfo{}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.byURL[url]
+}
@@ -0,0 +1,101 @@
+package scraper
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/microcosm-cc/bluemonday"
+)
+
+// structuralElements are kept by every HTML output profile
+var structuralElements = []string{
+	"p", "br", "hr", "h1", "h2", "h3", "h4", "h5", "h6",
+	"strong", "b", "em", "i", "u", "s", "del", "sub", "sup", "mark",
+	"blockquote", "pre", "code", "ul", "ol", "li",
+	"figure", "figcaption", "section", "article",
+}
+
+// lazyImageAttributes hold the real image URL on lazy-loaded images
+var lazyImageAttributes = []string{"data-src", "data-original", "data-lazy-src"}
+
+// newHTMLSanitizers builds one bluemonday policy per HTML output profile
+func newHTMLSanitizers() map[string]*bluemonday.Policy {
+	strict := bluemonday.NewPolicy()
+	strict.AllowElements(structuralElements...)
+	strict.AllowLists()
+
+	links := bluemonday.NewPolicy()
+	links.AllowElements(structuralElements...)
+	links.AllowLists()
+	links.AllowStandardURLs()
+	links.AllowAttrs("href").OnElements("a")
+	links.AllowImages()
+
+	tables := bluemonday.NewPolicy()
+	tables.AllowElements(structuralElements...)
+	tables.AllowLists()
+	tables.AllowStandardURLs()
+	tables.AllowAttrs("href").OnElements("a")
+	tables.AllowImages()
+	tables.AllowTables()
+
+	return map[string]*bluemonday.Policy{
+		HTMLProfileStrict: strict,
+		HTMLProfileLinks:  links,
+		HTMLProfileTables: tables,
+	}
+}
+
+// absolutizeHTML rewrites link hrefs and image sources in an HTML fragment to absolute URLs
+// Lazy-loaded images get their real source promoted from data attributes
+func absolutizeHTML(htmlContent, baseURL string) string {
+	base, err := url.Parse(baseURL)
+	if err != nil || !base.IsAbs() {
+		return htmlContent
+	}
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(htmlContent))
+	if err != nil {
+		return htmlContent
+	}
+
+	resolve := func(raw string) string {
+		raw = strings.TrimSpace(raw)
+		ref, err := url.Parse(raw)
+		if err != nil {
+			return raw
+		}
+		return base.ResolveReference(ref).String()
+	}
+
+	doc.Find("a[href]").Each(func(i int, s *goquery.Selection) {
+		href, _ := s.Attr("href")
+		if href == "" || strings.HasPrefix(href, "#") {
+			return
+		}
+		s.SetAttr("href", resolve(href))
+	})
+
+	doc.Find("img").Each(func(i int, s *goquery.Selection) {
+		src, _ := s.Attr("src")
+		if src == "" || strings.HasPrefix(src, "data:") {
+			for _, name := range lazyImageAttributes {
+				if value, ok := s.Attr(name); ok && value != "" {
+					src = value
+					break
+				}
+			}
+		}
+		if src == "" || strings.HasPrefix(src, "data:") {
+			return
+		}
+		s.SetAttr("src", resolve(src))
+	})
+
+	body, err := doc.Find("body").Html()
+	if err != nil {
+		return htmlContent
+	}
+	return body
+}
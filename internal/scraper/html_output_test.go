@@ -0,0 +1,50 @@
+package scraper
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExtractArticleWithOptionsHTMLProfiles(t *testing.T) {
+	html := `<html><head><title>Story</title></head><body><article>
+		<h1>Story</h1>
+		<p>` + strings.Repeat("Sanitized HTML output keeps the article structure. ", 10) + `</p>
+		<p>See <a href="../related">the related piece</a> and <a href="javascript:alert(1)">this</a>.</p>
+		<p><img src="/img/chart.png" alt="Chart" onerror="alert(1)"></p>
+		<table><tr><th>Year</th><th>Sales</th></tr><tr><td>2024</td><td>42</td></tr></table>
+		<script>alert("x")</script>
+	</article></body></html>`
+
+	ae := NewArticleExtractor()
+	baseURL := "https://example.com/news/2024/story"
+
+	links := HTMLExtractionOptions()
+	result := ae.ExtractArticleWithOptions(html, baseURL, links)
+	for _, want := range []string{`href="https://example.com/news/related"`, `src="https://example.com/img/chart.png"`} {
+		if !strings.Contains(result.Content, want) {
+			t.Errorf("links profile: expected %s in:\n%s", want, result.Content)
+		}
+	}
+	for _, unwanted := range []string{"<script", "javascript:", "onerror", "<table"} {
+		if strings.Contains(result.Content, unwanted) {
+			t.Errorf("links profile: unexpected %s in:\n%s", unwanted, result.Content)
+		}
+	}
+
+	strict := HTMLExtractionOptions()
+	strict.HTMLProfile = HTMLProfileStrict
+	result = ae.ExtractArticleWithOptions(html, baseURL, strict)
+	if strings.Contains(result.Content, "<a ") || strings.Contains(result.Content, "<img") {
+		t.Errorf("strict profile: expected no links or images in:\n%s", result.Content)
+	}
+	if !strings.Contains(result.Content, "<p>") {
+		t.Errorf("strict profile: expected paragraphs in:\n%s", result.Content)
+	}
+
+	tables := HTMLExtractionOptions()
+	tables.HTMLProfile = HTMLProfileTables
+	result = ae.ExtractArticleWithOptions(html, baseURL, tables)
+	if !strings.Contains(result.Content, "<table") || !strings.Contains(result.Content, "<td>2024</td>") {
+		t.Errorf("tables profile: expected table in:\n%s", result.Content)
+	}
+}
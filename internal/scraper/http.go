@@ -2,8 +2,10 @@ package scraper
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"io"
+	"log/slog"
+	"net"
 	"net/http"
 	"net/url"
 	"regexp"
@@ -11,21 +13,59 @@ import (
 	"sync"
 	"time"
 
-	"extract-html-scraper/internal/config"
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/config"
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/metrics"
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/models"
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/tracing"
 
 	"golang.org/x/sync/errgroup"
 )
 
 type HTTPClient struct {
-	client  *http.Client
-	config  config.ScrapeConfig
-	regexes map[string]*regexp.Regexp
+	logSink
+	client   *http.Client
+	settings *config.Settings
+	regexes  map[string]*regexp.Regexp
+
+	admitsRedirects bool // client checks robots.txt before following each redirect
 }
 
 func NewHTTPClient() *HTTPClient {
-	cfg := config.DefaultScrapeConfig()
-	regexes := config.CompileRegexes()
+	return NewHTTPClientWithConfig(nil, config.DefaultScrapeConfig())
+}
+
+// NewHTTPClientWithConfig creates an HTTP client with the given configuration and the default
+// timeouts and robots.txt settings
+// A nil client gets a pooled transport and the configured timeout and redirect limit
+func NewHTTPClientWithConfig(client *http.Client, cfg config.ScrapeConfig) *HTTPClient {
+	settings := config.DefaultSettings()
+	settings.Scrape = cfg
+	return NewHTTPClientWithSettings(client, settings)
+}
+
+// NewHTTPClientWithSettings creates an HTTP client using settings, which must not be modified
+// A nil client gets a pooled transport and the configured timeout and redirect limit
+func NewHTTPClientWithSettings(client *http.Client, settings *config.Settings) *HTTPClient {
+	pooled := client == nil
+	if pooled {
+		client = newPooledHTTPClient(settings)
+	}
 
+	return &HTTPClient{
+		client:          client,
+		settings:        settings,
+		regexes:         config.CompileRegexes(),
+		admitsRedirects: pooled,
+	}
+}
+
+// SetLogger sends the HTTP client's progress messages to logger instead of slog.Default()
+func (h *HTTPClient) SetLogger(logger *slog.Logger) {
+	h.logger = withRequestIDs(logger)
+}
+
+// newPooledHTTPClient builds the default net/http client used for fetching
+func newPooledHTTPClient(settings *config.Settings) *http.Client {
 	// Configure HTTP client with connection pooling
 	transport := &http.Transport{
 		MaxIdleConns:        100,
@@ -36,26 +76,22 @@ func NewHTTPClient() *HTTPClient {
 
 	client := &http.Client{
 		Transport: transport,
-		Timeout:   time.Duration(cfg.TimeoutMs) * time.Millisecond,
+		Timeout:   time.Duration(settings.Scrape.TimeoutMs) * time.Millisecond,
 		CheckRedirect: func(req *http.Request, via []*http.Request) error {
 			// Allow up to MaxRedirects redirects
 			if len(via) >= MaxRedirects {
 				return fmt.Errorf("too many redirects")
 			}
-			return nil
+			return sharedRobots.Admit(req.Context(), settings, req.URL.String())
 		},
 	}
 
-	return &HTTPClient{
-		client:  client,
-		config:  cfg,
-		regexes: regexes,
-	}
+	return client
 }
 
 // setRequestHeaders sets browser-like headers on the request
 func (h *HTTPClient) setRequestHeaders(req *http.Request) {
-	req.Header.Set("User-Agent", h.config.UserAgent)
+	req.Header.Set("User-Agent", h.settings.Scrape.UserAgent)
 	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8")
 	req.Header.Set("Accept-Language", "en-US,en;q=0.9")
 	req.Header.Set("Cache-Control", "no-cache")
@@ -64,9 +100,10 @@ func (h *HTTPClient) setRequestHeaders(req *http.Request) {
 }
 
 // retryWithBackoff implements exponential backoff for retries
-func (h *HTTPClient) retryWithBackoff(ctx context.Context, targetURL string, retryCount int) (string, error) {
-	if retryCount >= h.config.MaxRetries {
-		return "", fmt.Errorf("max retries exceeded")
+// statusCode is the server error that triggered the retry, reported once retries run out
+func (h *HTTPClient) retryWithBackoff(ctx context.Context, targetURL string, retryCount, statusCode int, cond Validators) (string, error) {
+	if retryCount >= h.settings.Scrape.MaxRetries {
+		return "", &models.HTTPError{StatusCode: statusCode, URL: targetURL, Err: errors.New("max retries exceeded")}
 	}
 
 	delay := time.Duration(1000*(1<<retryCount)) * time.Millisecond
@@ -75,11 +112,23 @@ func (h *HTTPClient) retryWithBackoff(ctx context.Context, targetURL string, ret
 	}
 
 	time.Sleep(delay)
-	return h.FetchHTML(ctx, targetURL, retryCount+1)
+	return h.fetchHTML(ctx, targetURL, retryCount+1, cond)
 }
 
 // FetchHTML fetches HTML content from a URL with retry logic
 func (h *HTTPClient) FetchHTML(ctx context.Context, targetURL string, retryCount int) (string, error) {
+	return h.fetchHTML(ctx, targetURL, retryCount, Validators{})
+}
+
+// fetchHTML is FetchHTML, made conditional when cond holds validators from an earlier response:
+// it returns ErrNotModified when the server answers 304
+// The page is converted to UTF-8; its encoding, validators and any truncation are reported to the
+// recorder carried by ctx
+func (h *HTTPClient) fetchHTML(ctx context.Context, targetURL string, retryCount int, cond Validators) (string, error) {
+	if err := sharedRobots.Admit(ctx, h.settings, targetURL); err != nil {
+		return "", err
+	}
+
 	req, err := http.NewRequestWithContext(ctx, "GET", targetURL, nil)
 	if err != nil {
 		return "", fmt.Errorf("failed to create request: %w", err)
@@ -87,41 +136,82 @@ func (h *HTTPClient) FetchHTML(ctx context.Context, targetURL string, retryCount
 
 	// Set headers to mimic a real browser
 	h.setRequestHeaders(req)
+	cond.setRequestHeaders(req)
 
+	start := time.Now()
 	resp, err := h.client.Do(req)
 	if err != nil {
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() {
+			return "", &models.TimeoutError{Operation: "HTTP fetch", Timeout: time.Since(start).Round(time.Millisecond).String(), Err: err}
+		}
 		return "", fmt.Errorf("request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
+	// A client supplied by the caller follows redirects without asking robots.txt, so the page it
+	// ended on is admitted now, before anything is read from it
+	if finalURL := resp.Request.URL.String(); finalURL != targetURL && !h.admitsRedirects {
+		if err := sharedRobots.Admit(ctx, h.settings, finalURL); err != nil {
+			return "", err
+		}
+	}
+
+	h.debug(ctx, "HTTP response", "url", targetURL, "status", resp.StatusCode, "retry", retryCount)
+	tracing.SpanFromContext(ctx).SetAttributes(slog.Int("http.status_code", resp.StatusCode))
+
 	// Handle 5xx server errors with retry logic
 	if resp.StatusCode >= 500 {
-		return h.retryWithBackoff(ctx, targetURL, retryCount)
+		return h.retryWithBackoff(ctx, targetURL, retryCount, resp.StatusCode, cond)
+	}
+
+	if resp.StatusCode == http.StatusNotModified && !cond.IsZero() {
+		return "", ErrNotModified
 	}
 
 	if resp.StatusCode >= 400 {
-		return "", fmt.Errorf("HTTP %d", resp.StatusCode)
+		return "", &models.HTTPError{StatusCode: resp.StatusCode, URL: targetURL, Err: errors.New(http.StatusText(resp.StatusCode))}
 	}
 
 	// Check content type
 	contentType := resp.Header.Get("Content-Type")
 	if !strings.Contains(strings.ToLower(contentType), "text/html") {
-		return "", fmt.Errorf("non-HTML content-type: %s", contentType)
+		return "", &models.NonHTMLError{URL: targetURL, ContentType: contentType}
 	}
 
 	// Read response body with size limit
-	reader := io.LimitReader(resp.Body, int64(h.config.SizeLimitBytes))
-	body, err := io.ReadAll(reader)
+	soft, hard := h.sizeLimits(ctx)
+	body, truncated, err := readPage(resp.Body, soft, hard)
 	if err != nil {
 		return "", fmt.Errorf("failed to read response: %w", err)
 	}
+	switch truncated {
+	case TruncatedSizeLimit:
+		h.warn(ctx, "page truncated at the size limit", "url", targetURL, "bytes", len(body))
+	case TruncatedAfterContent:
+		h.debug(ctx, "stopped reading page after its content", "url", targetURL, "bytes", len(body))
+	}
 
-	return string(body), nil
+	html, encoding, source := decodeHTML(body, contentType)
+	h.debug(ctx, "decoded page", "url", targetURL, "encoding", encoding, "source", source)
+	pagesFrom(ctx).record(targetURL, pageInfo{Validators: validatorsOf(resp), Encoding: encoding, Truncated: truncated})
+	return html, nil
+}
+
+// fetchTraced runs FetchHTML inside a client span named name
+func (h *HTTPClient) fetchTraced(ctx context.Context, name, targetURL string) (string, error) {
+	ctx, span := tracing.StartKind(ctx, tracing.SpanKindClient, name, slog.String("url", targetURL))
+	start := time.Now()
+	html, err := h.FetchHTML(ctx, targetURL, 0)
+	debugFrom(ctx).recordURL(targetURL, name != "http.primary", time.Since(start), len(html), pagesFrom(ctx).get(targetURL).Truncated, err)
+	span.SetAttributes(slog.Int("html_bytes", len(html)))
+	endSpan(span, err)
+	return html, err
 }
 
 // LooksLikeCFBlock checks if HTML content indicates Cloudflare blocking
 func (h *HTTPClient) LooksLikeCFBlock(html string) bool {
-	return IsCloudflareBlock(fmt.Errorf(html))
+	return h.regexes["cfBlock"].MatchString(strings.ToLower(html))
 }
 
 // GenerateAlternateURLs creates alternative URLs for AMP/mobile fallback
@@ -180,12 +270,13 @@ func (h *HTTPClient) FetchWithAlternates(ctx context.Context, targetURL string)
 	}
 
 	// Check if we should try alternates (only for specific errors)
-	if err != nil && !strings.Contains(err.Error(), "HTTP 403") &&
-		!strings.Contains(err.Error(), "HTTP 406") &&
-		!strings.Contains(err.Error(), "HTTP 451") &&
-		!strings.Contains(err.Error(), "HTTP 5") {
+	if err != nil && !shouldTryAlternates(err) {
 		return "", "", err
 	}
+	primaryErr := err
+	if primaryErr == nil {
+		primaryErr = &models.CloudflareBlockError{Domain: hostname(targetURL), Err: errors.New("challenge page served")}
+	}
 
 	// Generate alternate URLs
 	alternates, err := h.GenerateAlternateURLs(targetURL)
@@ -231,11 +322,12 @@ func (h *HTTPClient) FetchWithAlternates(ctx context.Context, targetURL string)
 	// Check results as they come in
 	for result := range resultChan {
 		if result.err == nil && result.html != "" {
+			metrics.AlternateURLHits.Inc(FetcherHTTP)
 			return result.html, result.url, nil
 		}
 	}
 
-	return "", "", fmt.Errorf("all alternate URLs failed or were blocked")
+	return "", "", fmt.Errorf("all alternate URLs failed or were blocked: %w", primaryErr)
 }
 
 // FetchWithAlternatesGroup uses errgroup for better error handling
@@ -246,7 +338,7 @@ func (h *HTTPClient) FetchWithAlternatesGroup(ctx context.Context, targetURL str
 	}
 
 	// Try primary URL first
-	html, err := h.FetchHTML(ctx, targetURL, 0)
+	html, err := h.fetchTraced(ctx, "http.primary", targetURL)
 	if err == nil && !h.LooksLikeCFBlock(html) && len(html) > 0 {
 		// Validate HTML has minimum content
 		if len(strings.TrimSpace(html)) > 100 {
@@ -256,10 +348,7 @@ func (h *HTTPClient) FetchWithAlternatesGroup(ctx context.Context, targetURL str
 	}
 
 	// Check if we should try alternates
-	if err != nil && !strings.Contains(err.Error(), "HTTP 403") &&
-		!strings.Contains(err.Error(), "HTTP 406") &&
-		!strings.Contains(err.Error(), "HTTP 451") &&
-		!strings.Contains(err.Error(), "HTTP 5") {
+	if err != nil && !shouldTryAlternates(err) {
 		// Check if error is due to parent context expiration
 		if ctx.Err() != nil {
 			return "", "", fmt.Errorf("HTTP fetch failed: parent context expired: %w", ctx.Err())
@@ -267,6 +356,16 @@ func (h *HTTPClient) FetchWithAlternatesGroup(ctx context.Context, targetURL str
 		return "", "", err
 	}
 
+	// Keep the reason the primary URL was unusable for the error returned if every alternate fails
+	primaryErr := err
+	if primaryErr == nil {
+		if h.LooksLikeCFBlock(html) {
+			primaryErr = &models.CloudflareBlockError{Domain: hostname(targetURL), Err: errors.New("challenge page served")}
+		} else {
+			primaryErr = &models.ContentExtractionError{Step: "fetch", Err: fmt.Errorf("minimal HTML (%d bytes)", len(html))}
+		}
+	}
+
 	// Generate alternate URLs
 	alternates, err := h.GenerateAlternateURLs(targetURL)
 	if err != nil {
@@ -278,9 +377,11 @@ func (h *HTTPClient) FetchWithAlternatesGroup(ctx context.Context, targetURL str
 		if ctx.Err() != nil {
 			return "", "", fmt.Errorf("HTTP fetch failed: parent context expired: %w", ctx.Err())
 		}
-		return "", "", fmt.Errorf("HTTP fetch failed: %w", err)
+		return "", "", fmt.Errorf("HTTP fetch failed: %w", primaryErr)
 	}
 
+	h.debug(ctx, "primary URL unusable, trying alternate URLs", "url", targetURL, "count", len(alternates))
+
 	// Use errgroup for parallel execution
 	// Use errgroup context but check parent context explicitly to avoid canceling parent
 	g, groupCtx := errgroup.WithContext(ctx)
@@ -300,7 +401,7 @@ func (h *HTTPClient) FetchWithAlternatesGroup(ctx context.Context, targetURL str
 			
 			// Use parent context (not group context) for the actual fetch
 			// This way parent expiration is independent of errgroup cancellation
-			html, fetchErr := h.FetchHTML(ctx, altURL, 0)
+			html, fetchErr := h.fetchTraced(ctx, "http.alternate", altURL)
 			
 			// Check parent context after fetch
 			if ctx.Err() != nil {
@@ -337,6 +438,7 @@ func (h *HTTPClient) FetchWithAlternatesGroup(ctx context.Context, targetURL str
 	select {
 	case result := <-resultChan:
 		if result.err == nil && result.html != "" {
+			metrics.AlternateURLHits.Inc(FetcherHTTP)
 			return result.html, result.url, nil
 		}
 	case <-ctx.Done():
@@ -348,5 +450,19 @@ func (h *HTTPClient) FetchWithAlternatesGroup(ctx context.Context, targetURL str
 	if ctx.Err() != nil {
 		return "", "", fmt.Errorf("HTTP fetch failed: parent context expired, all alternate URLs failed: %w", ctx.Err())
 	}
-	return "", "", fmt.Errorf("HTTP fetch failed: all alternate URLs failed or were blocked")
+	return "", "", fmt.Errorf("HTTP fetch failed: all alternate URLs failed or were blocked: %w", primaryErr)
+}
+
+// shouldTryAlternates reports whether the primary URL failed in a way an AMP or mobile
+// version might avoid: access denied, not acceptable, legal block or a server error
+func shouldTryAlternates(err error) bool {
+	var httpErr *models.HTTPError
+	if !errors.As(err, &httpErr) {
+		return false
+	}
+	switch httpErr.StatusCode {
+	case http.StatusForbidden, http.StatusNotAcceptable, http.StatusUnavailableForLegalReasons:
+		return true
+	}
+	return httpErr.StatusCode >= 500
 }
@@ -9,8 +9,8 @@ import (
 	"sync"
 	"unicode"
 
-	"extract-html-scraper/internal/config"
-	"extract-html-scraper/internal/models"
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/config"
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/models"
 
 	"github.com/PuerkitoBio/goquery"
 )
@@ -21,12 +21,14 @@ type ImageExtractor struct {
 }
 
 func NewImageExtractor() *ImageExtractor {
-	cfg := config.DefaultImageConfig()
-	regexes := config.CompileRegexes()
+	return NewImageExtractorWithConfig(config.DefaultImageConfig())
+}
 
+// NewImageExtractorWithConfig creates an image extractor with the given size and hint rules
+func NewImageExtractorWithConfig(cfg config.ImageConfig) *ImageExtractor {
 	return &ImageExtractor{
 		config:  cfg,
-		regexes: regexes,
+		regexes: config.CompileImageRegexes(cfg),
 	}
 }
 
@@ -0,0 +1,43 @@
+package scraper
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/logging"
+)
+
+// logSink routes progress messages to a slog.Logger, or to slog.Default() when none is set
+type logSink struct {
+	logger *slog.Logger
+}
+
+func (l logSink) log() *slog.Logger {
+	if l.logger == nil {
+		return slog.Default()
+	}
+	return l.logger
+}
+
+// debug logs fine-grained progress such as snapshot and stability checks
+func (l logSink) debug(ctx context.Context, msg string, args ...any) {
+	l.log().DebugContext(ctx, msg, args...)
+}
+
+// info logs phase progress and results
+func (l logSink) info(ctx context.Context, msg string, args ...any) {
+	l.log().InfoContext(ctx, msg, args...)
+}
+
+// warn logs recoverable failures
+func (l logSink) warn(ctx context.Context, msg string, args ...any) {
+	l.log().WarnContext(ctx, msg, args...)
+}
+
+// withRequestIDs makes logger add the request ID carried by the logging context
+func withRequestIDs(logger *slog.Logger) *slog.Logger {
+	if logger == nil {
+		return nil
+	}
+	return slog.New(logging.NewContextHandler(logger.Handler()))
+}
@@ -0,0 +1,393 @@
+// Package scraper provides HTML to markdown conversion for article content.
+package scraper
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// markdownSkipTags are elements whose content never belongs in the markdown output
+var markdownSkipTags = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Noscript: true, atom.Iframe: true,
+	atom.Svg: true, atom.Button: true, atom.Form: true, atom.Input: true,
+	atom.Select: true, atom.Textarea: true, atom.Template: true, atom.Head: true,
+}
+
+// markdownBlockTags are elements rendered as separate blocks
+var markdownBlockTags = map[atom.Atom]bool{
+	atom.P: true, atom.Div: true, atom.Section: true, atom.Article: true, atom.Main: true,
+	atom.Header: true, atom.Footer: true, atom.Aside: true, atom.Nav: true,
+	atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+	atom.Ul: true, atom.Ol: true, atom.Li: true, atom.Blockquote: true, atom.Pre: true,
+	atom.Hr: true, atom.Table: true, atom.Figure: true, atom.Figcaption: true,
+	atom.Dl: true, atom.Dt: true, atom.Dd: true, atom.Body: true, atom.Html: true,
+}
+
+// markdownEscaper escapes characters that would otherwise be read as markdown syntax
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	"`", "\\`",
+	"*", `\*`,
+	"_", `\_`,
+	"[", `\[`,
+	"]", `\]`,
+)
+
+// markdownRenderer converts an HTML fragment to markdown, resolving URLs against base
+type markdownRenderer struct {
+	base *url.URL
+}
+
+// ConvertHTMLToMarkdown renders an HTML fragment as markdown
+// Relative links and image sources are resolved against baseURL
+func ConvertHTMLToMarkdown(htmlContent, baseURL string) string {
+	nodes, err := html.ParseFragment(strings.NewReader(htmlContent), &html.Node{
+		Type:     html.ElementNode,
+		Data:     "body",
+		DataAtom: atom.Body,
+	})
+	if err != nil {
+		return ""
+	}
+
+	r := &markdownRenderer{}
+	if base, err := url.Parse(baseURL); err == nil {
+		r.base = base
+	}
+
+	root := &html.Node{Type: html.ElementNode, Data: "div", DataAtom: atom.Div}
+	for _, n := range nodes {
+		root.AppendChild(n)
+	}
+
+	return strings.TrimSpace(strings.Join(r.blocks(root), DoubleNewline))
+}
+
+// blocks renders the children of n as a list of markdown blocks
+// Consecutive inline children are gathered into a single paragraph
+func (r *markdownRenderer) blocks(n *html.Node) []string {
+	var out []string
+	var paragraph strings.Builder
+
+	flush := func() {
+		if text := strings.TrimSpace(paragraph.String()); text != "" {
+			out = append(out, text)
+		}
+		paragraph.Reset()
+	}
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && markdownSkipTags[c.DataAtom] {
+			continue
+		}
+		if c.Type == html.ElementNode && markdownBlockTags[c.DataAtom] {
+			flush()
+			out = append(out, r.block(c)...)
+			continue
+		}
+		paragraph.WriteString(r.inline(c))
+	}
+	flush()
+
+	return out
+}
+
+// block renders a single block-level element
+func (r *markdownRenderer) block(n *html.Node) []string {
+	switch n.DataAtom {
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		text := strings.TrimSpace(r.inlineChildren(n))
+		if text == "" {
+			return nil
+		}
+		level := int(n.Data[1] - '0')
+		return []string{strings.Repeat("#", level) + " " + text}
+
+	case atom.Ul, atom.Ol:
+		if list := r.list(n); list != "" {
+			return []string{list}
+		}
+		return nil
+
+	case atom.Blockquote:
+		inner := strings.Join(r.blocks(n), DoubleNewline)
+		if strings.TrimSpace(inner) == "" {
+			return nil
+		}
+		return []string{prefixLines(inner, "> ", ">")}
+
+	case atom.Pre:
+		code := strings.Trim(textContent(n), "\n")
+		if strings.TrimSpace(code) == "" {
+			return nil
+		}
+		return []string{"```\n" + code + "\n```"}
+
+	case atom.Hr:
+		return []string{"---"}
+
+	case atom.Table:
+		if table := r.table(n); table != "" {
+			return []string{table}
+		}
+		return nil
+
+	case atom.Figcaption:
+		if text := strings.TrimSpace(r.inlineChildren(n)); text != "" {
+			return []string{"_" + text + "_"}
+		}
+		return nil
+
+	default:
+		// Generic containers (div, section, li outside a list, ...) just contribute their blocks
+		return r.blocks(n)
+	}
+}
+
+// list renders ul/ol items, indenting nested content under each marker
+func (r *markdownRenderer) list(n *html.Node) string {
+	var items []string
+	index := 1
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode || c.DataAtom != atom.Li {
+			continue
+		}
+
+		content := strings.Join(r.blocks(c), SingleNewline)
+		if strings.TrimSpace(content) == "" {
+			continue
+		}
+
+		marker := "- "
+		if n.DataAtom == atom.Ol {
+			marker = fmt.Sprintf("%d. ", index)
+			index++
+		}
+
+		indent := strings.Repeat(" ", len(marker))
+		lines := strings.Split(content, SingleNewline)
+		for i := 1; i < len(lines); i++ {
+			if lines[i] != "" {
+				lines[i] = indent + lines[i]
+			}
+		}
+		items = append(items, marker+strings.Join(lines, SingleNewline))
+	}
+
+	return strings.Join(items, SingleNewline)
+}
+
+// table renders a table as a GFM pipe table, using the first row as the header
+func (r *markdownRenderer) table(n *html.Node) string {
+	var rows [][]string
+	var walk func(*html.Node)
+	walk = func(node *html.Node) {
+		for c := node.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type != html.ElementNode {
+				continue
+			}
+			if c.DataAtom == atom.Tr {
+				var cells []string
+				for cell := c.FirstChild; cell != nil; cell = cell.NextSibling {
+					if cell.Type == html.ElementNode && (cell.DataAtom == atom.Td || cell.DataAtom == atom.Th) {
+						text := collapseWhitespace(r.inlineChildren(cell))
+						cells = append(cells, strings.ReplaceAll(strings.TrimSpace(text), "|", `\|`))
+					}
+				}
+				if len(cells) > 0 {
+					rows = append(rows, cells)
+				}
+				continue
+			}
+			walk(c)
+		}
+	}
+	walk(n)
+
+	if len(rows) == 0 {
+		return ""
+	}
+
+	columns := 0
+	for _, row := range rows {
+		if len(row) > columns {
+			columns = len(row)
+		}
+	}
+
+	var b strings.Builder
+	for i, row := range rows {
+		for len(row) < columns {
+			row = append(row, "")
+		}
+		b.WriteString("| " + strings.Join(row, " | ") + " |")
+		if i == 0 {
+			b.WriteString(SingleNewline + "|" + strings.Repeat(" --- |", columns))
+		}
+		if i < len(rows)-1 {
+			b.WriteString(SingleNewline)
+		}
+	}
+
+	return b.String()
+}
+
+// inlineChildren renders the children of n as inline markdown
+func (r *markdownRenderer) inlineChildren(n *html.Node) string {
+	var b strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		b.WriteString(r.inline(c))
+	}
+	return b.String()
+}
+
+// inline renders a node as inline markdown
+func (r *markdownRenderer) inline(n *html.Node) string {
+	switch n.Type {
+	case html.TextNode:
+		return markdownEscaper.Replace(collapseWhitespace(n.Data))
+	case html.ElementNode:
+	default:
+		return ""
+	}
+
+	if markdownSkipTags[n.DataAtom] {
+		return ""
+	}
+
+	switch n.DataAtom {
+	case atom.Br:
+		return "  \n"
+
+	case atom.Strong, atom.B:
+		return wrapInline(r.inlineChildren(n), "**")
+
+	case atom.Em, atom.I:
+		return wrapInline(r.inlineChildren(n), "_")
+
+	case atom.Del, atom.S:
+		return wrapInline(r.inlineChildren(n), "~~")
+
+	case atom.Code:
+		code := collapseWhitespace(textContent(n))
+		if strings.TrimSpace(code) == "" {
+			return code
+		}
+		return "`" + strings.ReplaceAll(code, "`", "'") + "`"
+
+	case atom.A:
+		text := strings.TrimSpace(r.inlineChildren(n))
+		href := r.resolve(attr(n, "href"))
+		if href == "" || strings.HasPrefix(strings.ToLower(href), "javascript:") {
+			return r.inlineChildren(n)
+		}
+		if text == "" {
+			return ""
+		}
+		return "[" + text + "](" + href + ")"
+
+	case atom.Img:
+		src := attr(n, "src")
+		if src == "" || strings.HasPrefix(src, "data:") {
+			// Lazy-loaded images often keep the real URL in a data attribute
+			for _, name := range lazyImageAttributes {
+				if value := attr(n, name); value != "" {
+					src = value
+					break
+				}
+			}
+		}
+		src = r.resolve(src)
+		if src == "" || strings.HasPrefix(src, "data:") {
+			return ""
+		}
+		alt := strings.ReplaceAll(collapseWhitespace(attr(n, "alt")), "]", "")
+		return "![" + strings.TrimSpace(alt) + "](" + src + ")"
+
+	default:
+		return r.inlineChildren(n)
+	}
+}
+
+// resolve converts a possibly relative URL to an absolute one
+func (r *markdownRenderer) resolve(raw string) string {
+	raw = strings.TrimSpace(raw)
+	if raw == "" || r.base == nil {
+		return strings.ReplaceAll(raw, " ", "%20")
+	}
+	ref, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+	return strings.ReplaceAll(r.base.ResolveReference(ref).String(), " ", "%20")
+}
+
+// wrapInline wraps text in a markdown delimiter, keeping surrounding spaces outside it
+func wrapInline(text, delimiter string) string {
+	trimmed := strings.TrimSpace(text)
+	if trimmed == "" {
+		return text
+	}
+	leading := text[:len(text)-len(strings.TrimLeft(text, " "))]
+	trailing := text[len(strings.TrimRight(text, " ")):]
+	return leading + delimiter + trimmed + delimiter + trailing
+}
+
+// prefixLines prefixes every line of text, using emptyPrefix for blank lines
+func prefixLines(text, prefix, emptyPrefix string) string {
+	lines := strings.Split(text, SingleNewline)
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			lines[i] = emptyPrefix
+		} else {
+			lines[i] = prefix + line
+		}
+	}
+	return strings.Join(lines, SingleNewline)
+}
+
+// collapseWhitespace replaces runs of whitespace with a single space
+func collapseWhitespace(text string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range text {
+		switch r {
+		case ' ', '\t', '\n', '\r', '\f', '\u00a0':
+			if !space {
+				b.WriteByte(' ')
+				space = true
+			}
+		default:
+			b.WriteRune(r)
+			space = false
+		}
+	}
+	return b.String()
+}
+
+// textContent returns the raw text of a node and its descendants
+func textContent(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var b strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		b.WriteString(textContent(c))
+	}
+	return b.String()
+}
+
+// attr returns the value of an attribute, or an empty string
+func attr(n *html.Node, name string) string {
+	for _, a := range n.Attr {
+		if a.Key == name {
+			return a.Val
+		}
+	}
+	return ""
+}
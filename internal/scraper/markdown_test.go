@@ -0,0 +1,57 @@
+package scraper
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestConvertHTMLToMarkdown(t *testing.T) {
+	html := `
+		<h2>Release  notes</h2>
+		<p>The <strong>new</strong> version ships <em>today</em>, see <a href="/changelog">the changelog</a>.</p>
+		<ul>
+			<li>Faster parsing</li>
+			<li>Nested
+				<ol><li>first</li><li>second</li></ol>
+			</li>
+		</ul>
+		<blockquote><p>Quoted line</p></blockquote>
+		<figure><img src="img/hero.jpg" alt="Hero"><figcaption>A caption</figcaption></figure>
+		<script>alert("x")</script>
+		<pre><code>go test ./...</code></pre>
+	`
+
+	got := ConvertHTMLToMarkdown(html, "https://example.com/blog/post")
+
+	want := strings.Join([]string{
+		"## Release notes",
+		"The **new** version ships _today_, see [the changelog](https://example.com/changelog).",
+		"- Faster parsing\n- Nested\n  1. first\n  2. second",
+		"> Quoted line",
+		"![Hero](https://example.com/blog/img/hero.jpg)",
+		"_A caption_",
+		"```\ngo test ./...\n```",
+	}, DoubleNewline)
+
+	if got != want {
+		t.Fatalf("unexpected markdown:\n--- got ---\n%s\n--- want ---\n%s", got, want)
+	}
+}
+
+func TestExtractArticleWithOptionsMarkdown(t *testing.T) {
+	html := `<html><head><title>Story</title></head><body><article>
+		<h1>Story</h1>
+		<p>` + strings.Repeat("Markdown output keeps the article structure intact. ", 10) + `</p>
+		<p>Read <a href="related">the related piece</a> for more context on the subject at hand.</p>
+	</article></body></html>`
+
+	ae := NewArticleExtractor()
+	result := ae.ExtractArticleWithOptions(html, "https://example.com/news/story", MarkdownExtractionOptions())
+
+	if !strings.Contains(result.Content, "[the related piece](https://example.com/news/related)") {
+		t.Fatalf("expected absolute markdown link, got:\n%s", result.Content)
+	}
+	if strings.Contains(result.Content, "<p>") {
+		t.Fatalf("markdown output must not contain HTML tags, got:\n%s", result.Content)
+	}
+}
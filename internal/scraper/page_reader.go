@@ -0,0 +1,199 @@
+package scraper
+
+import (
+	"bytes"
+	"context"
+	"io"
+)
+
+// Why a fetched page was not read to its end, reported in response metadata and debug traces
+const (
+	TruncatedSizeLimit    = "size_limit"    // Cut at the hard size limit, possibly before the end of the article
+	TruncatedAfterContent = "after_content" // Stopped past the size limit once the article and structured data were read
+)
+
+const pageReadChunk = 32 << 10 // Bytes read at a time past the size limit
+
+type pageSizeKey struct{}
+
+// withPageSizeLimit returns a copy of ctx whose HTTP fetches read at most maxBytes of a page;
+// zero keeps the configured limit
+func withPageSizeLimit(ctx context.Context, maxBytes int) context.Context {
+	if maxBytes <= 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, pageSizeKey{}, maxBytes)
+}
+
+// sizeLimits returns how much of a page fetched with ctx is read before reading may stop once
+// the article is in, and how much is read at most
+func (h *HTTPClient) sizeLimits(ctx context.Context) (soft, hard int) {
+	soft = h.settings.Scrape.SizeLimitBytes
+	hard = h.settings.Scrape.MaxSizeLimitBytes
+	if hard < soft {
+		hard = soft
+	}
+	if maxBytes, _ := ctx.Value(pageSizeKey{}).(int); maxBytes > 0 && maxBytes < hard {
+		hard = maxBytes
+	}
+	return min(soft, hard), hard
+}
+
+// readPage reads a page body, returning why it stopped short of the end, if it did
+// Up to soft bytes are read as is. Past that, reading goes on until the article container has
+// closed and structured data has been seen, or the body has closed, so long pages keep the end
+// of their article and the JSON-LD that often follows it; nothing beyond hard is read
+func readPage(r io.Reader, soft, hard int) ([]byte, string, error) {
+	r = io.LimitReader(r, int64(hard)+1)
+	body, err := io.ReadAll(io.LimitReader(r, int64(soft)))
+	if err != nil || len(body) < soft {
+		return body, "", err
+	}
+
+	var scan pageScan
+	scan.feed(body)
+	chunk := make([]byte, pageReadChunk)
+	for !scan.complete() {
+		n, err := r.Read(chunk)
+		body = append(body, chunk[:n]...)
+		if len(body) > hard {
+			return body[:hard], TruncatedSizeLimit, nil
+		}
+		if err == io.EOF {
+			return body, "", nil
+		}
+		if err != nil {
+			return nil, "", err
+		}
+		scan.feed(body)
+	}
+
+	// The page only counts as truncated when something that matters was left unread
+	if scan.bodyClosed {
+		return body, "", nil
+	}
+	if _, err := io.ReadFull(r, chunk[:1]); err != nil {
+		return body, "", nil
+	}
+	return body, TruncatedAfterContent, nil
+}
+
+// pageScan follows the markup of a page as it is read, tracking the parts that must be read
+// before reading may stop early
+type pageScan struct {
+	offset       int    // Bytes of the page scanned so far
+	skipUntil    []byte // End of the comment, script or style being skipped, if any
+	containers   int    // <article> and <main> elements open
+	sawContainer bool
+	inJSONLD     bool // Inside a <script type="application/ld+json">
+	sawJSONLD    bool
+	bodyClosed   bool
+}
+
+var (
+	commentStart = []byte("<!--")
+	commentEnd   = []byte("-->")
+	scriptEnd    = []byte("</script")
+	styleEnd     = []byte("</style")
+	jsonLDType   = []byte("application/ld+json")
+)
+
+// complete reports whether the article container and structured data have been read, or the
+// page has closed its body and has neither left to offer
+func (p *pageScan) complete() bool {
+	if p.containers > 0 || p.inJSONLD {
+		return false
+	}
+	return p.bodyClosed || p.sawContainer && p.sawJSONLD
+}
+
+// feed scans the part of page not seen yet; page must extend what was fed before
+// A tag or skip marker cut by the end of page is scanned again on the next feed
+func (p *pageScan) feed(page []byte) {
+	for p.offset < len(page) {
+		rest := page[p.offset:]
+		if p.skipUntil != nil {
+			end := indexFold(rest, p.skipUntil)
+			if end < 0 {
+				if keep := len(p.skipUntil) - 1; len(rest) > keep {
+					p.offset += len(rest) - keep
+				}
+				return
+			}
+			// A script or style ends with a whole end tag, scanned like any other tag
+			p.offset += end
+			if bytes.Equal(p.skipUntil, commentEnd) {
+				p.offset += len(commentEnd)
+			}
+			p.skipUntil = nil
+			continue
+		}
+
+		lt := bytes.IndexByte(rest, '<')
+		if lt < 0 {
+			p.offset = len(page)
+			return
+		}
+		rest = rest[lt:]
+		if len(rest) < len(commentStart) {
+			p.offset += lt
+			return
+		}
+		if bytes.HasPrefix(rest, commentStart) {
+			p.offset += lt + len(commentStart)
+			p.skipUntil = commentEnd
+			continue
+		}
+		gt := bytes.IndexByte(rest, '>')
+		if gt < 0 {
+			p.offset += lt
+			return
+		}
+		p.tag(rest[1:gt])
+		p.offset += lt + gt + 1
+	}
+}
+
+// tag updates the scan with a tag, given without its angle brackets
+func (p *pageScan) tag(tag []byte) {
+	closing := bytes.HasPrefix(tag, []byte("/"))
+	name := bytes.TrimPrefix(tag, []byte("/"))
+	if i := bytes.IndexAny(name, " \t\r\n\f/"); i >= 0 {
+		name = name[:i]
+	}
+
+	switch {
+	case bytes.EqualFold(name, []byte("article")), bytes.EqualFold(name, []byte("main")):
+		if !closing {
+			p.containers++
+			p.sawContainer = true
+		} else if p.containers > 0 {
+			p.containers--
+		}
+	case closing && bytes.EqualFold(name, []byte("body")):
+		p.bodyClosed = true
+	case closing && bytes.EqualFold(name, []byte("script")):
+		p.inJSONLD = false
+	case !closing && bytes.EqualFold(name, []byte("script")):
+		if bytes.HasSuffix(tag, []byte("/")) {
+			return
+		}
+		p.skipUntil = scriptEnd
+		if indexFold(tag, jsonLDType) >= 0 {
+			p.inJSONLD = true
+			p.sawJSONLD = true
+		}
+	case !closing && bytes.EqualFold(name, []byte("style")):
+		p.skipUntil = styleEnd
+	}
+}
+
+// indexFold returns the index of the first ASCII case-insensitive match of sep in s, or -1
+func indexFold(s, sep []byte) int {
+	for i := 0; i+len(sep) <= len(s); i++ {
+		if s[i]|0x20 == sep[0]|0x20 && bytes.EqualFold(s[i:i+len(sep)], sep) {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,119 @@
+package scraper
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/config"
+)
+
+func TestReadPage(t *testing.T) {
+	filler := strings.Repeat("<p>Filler paragraph.</p>\n", 40) // 1000 bytes
+	jsonLD := `<script type="application/ld+json">{"headline": "A <main> headline"}</script>`
+	const tail = "<footer>Footer</footer></body></html>"
+	comments := strings.Repeat("<p>Reader comment.</p>\n", 2000) // More than one read past the limit
+
+	tests := []struct {
+		name      string
+		page      string
+		soft      int
+		hard      int
+		want      string // What reading must have reached; reading one byte at a time stops right after it
+		truncated string
+	}{
+		{"under the limit", "<html><body>" + filler + "</body></html>", 2000, 4000, "</html>", ""},
+		{"article and JSON-LD past the limit", "<html><body><article>" + filler + "</article>" + jsonLD + "<aside>" + comments + "</aside>" + tail,
+			500, 100000, jsonLD, TruncatedAfterContent},
+		{"JSON-LD seen before the limit", `<html><head><script type="APPLICATION/LD+JSON">{}</script></head><body><article>` + filler + "</article>" + comments + tail,
+			500, 100000, "</article>", TruncatedAfterContent},
+		// Without JSON-LD there is no telling whether some follows until the body closes
+		{"no structured data", "<html><body><article>" + filler + "</article>" + comments + tail, 500, 100000, "</body>", ""},
+		{"hard limit", "<html><body><article>" + filler + filler + "</article></body></html>", 500, 1500, "", TruncatedSizeLimit},
+		{"container in a comment or script", "<html><body><!-- <article> --><script>var a = '<article>';</script><main>" + filler + "</main>" +
+			jsonLD + comments + tail, 500, 100000, jsonLD, TruncatedAfterContent},
+		{"complete at the end of the page", "<html><body><main>" + filler + "</main>" + jsonLD, 500, 10000, jsonLD, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// One byte at a time splits every tag and marker across reads
+			readers := map[string]io.Reader{
+				"whole":    strings.NewReader(tt.page),
+				"one byte": iotest.OneByteReader(strings.NewReader(tt.page)),
+			}
+			for name, r := range readers {
+				body, truncated, err := readPage(r, tt.soft, tt.hard)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if truncated != tt.truncated {
+					t.Errorf("%s: truncated = %q, want %q", name, truncated, tt.truncated)
+				}
+				if !strings.HasPrefix(tt.page, string(body)) || !strings.Contains(string(body), tt.want) {
+					t.Errorf("%s: read %d bytes, want a prefix of the page reaching %q", name, len(body), tt.want)
+				}
+				if name == "one byte" && tt.truncated == TruncatedAfterContent && !strings.HasSuffix(string(body), tt.want) {
+					t.Errorf("one byte: read past %q", tt.want)
+				}
+				if tt.truncated == TruncatedSizeLimit && len(body) != tt.hard {
+					t.Errorf("%s: read %d bytes, want the hard limit %d", name, len(body), tt.hard)
+				}
+			}
+		})
+	}
+}
+
+func TestScrapeReportsTruncation(t *testing.T) {
+	paragraph := "<p>The council approved the new budget after a long debate about transport and housing.</p>"
+	page := "<html><head><title>Budget approved</title></head><body><article><h1>Budget approved</h1>" +
+		strings.Repeat(paragraph, 60) + "<p>Final paragraph of the article.</p></article>" +
+		`<script type="application/ld+json">{"@type": "NewsArticle", "headline": "Budget approved", "image": "https://example.com/council-hall.jpg"}</script>` +
+		"<section class=\"comments\">" + strings.Repeat(paragraph, 1000) + "</section></body></html>"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(page))
+	}))
+	defer server.Close()
+
+	cfg := config.BaseScrapeConfig()
+	cfg.SizeLimitBytes = 2000
+	cfg.MaxSizeLimitBytes = 64 << 10
+	s := NewScraperWithFetchers(NewArticleExtractor(), NewHTTPClientWithConfig(nil, cfg))
+	s.SetLogger(discardLogger())
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	options := DefaultExtractionOptions()
+	options.Debug = true
+	result, err := s.ScrapeSmartWithOptions(ctx, server.URL+"/budget", options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(result.Content, "Final paragraph of the article") {
+		t.Error("the end of the article was lost past the size limit")
+	}
+	if len(result.Images) == 0 || result.Images[0].URL != "https://example.com/council-hall.jpg" {
+		t.Errorf("images %v: want the JSON-LD image read past the size limit", result.Images)
+	}
+	if result.Metadata.Truncated != TruncatedAfterContent || result.Debug.Phases[0].Truncated != TruncatedAfterContent {
+		t.Errorf("truncated = %q, debug %q; want %q", result.Metadata.Truncated, result.Debug.Phases[0].Truncated, TruncatedAfterContent)
+	}
+
+	// A request's own limit cuts the page short and says so
+	options.MaxBytes = 3000
+	result, err = s.ScrapeSmartWithOptions(ctx, server.URL+"/budget", options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Metadata.Truncated != TruncatedSizeLimit || result.Debug.Phases[0].URLs[0].Truncated != TruncatedSizeLimit {
+		t.Errorf("with maxBytes: truncated = %q, want %q", result.Metadata.Truncated, TruncatedSizeLimit)
+	}
+	if strings.Contains(result.Content, "Final paragraph of the article") {
+		t.Error("with maxBytes: content past the limit was read")
+	}
+}
@@ -0,0 +1,149 @@
+package scraper
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/config"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// HostScheduler spaces out and bounds the fetches made to each site, so concurrent scrapes of
+// the same site queue behind each other instead of all hitting it at once
+// Sites are registrable domains: www.example.com, amp.example.com and m.example.com share limits
+type HostScheduler struct {
+	limits func() config.Politeness
+
+	mu        sync.Mutex
+	sites     map[string]*siteSlot
+	lastPrune time.Time
+}
+
+// siteSlot is the state kept for one site
+type siteSlot struct {
+	active  int
+	next    time.Time     // Earliest start of the next fetch
+	changed chan struct{} // Closed, then replaced, when a fetch finishes
+}
+
+// sitePruneInterval is how often idle sites are forgotten
+const sitePruneInterval = time.Minute
+
+// NewHostScheduler creates a scheduler applying the limits returned by limits, which is called
+// for every fetch; a nil limits applies those each fetch is scheduled with
+func NewHostScheduler(limits func() config.Politeness) *HostScheduler {
+	return &HostScheduler{limits: limits, sites: make(map[string]*siteSlot)}
+}
+
+// defaultScheduler is shared by every scraper of the process, so sites keep their limits when the
+// configuration is reloaded and scrapers replaced, each applying its own settings
+var defaultScheduler = NewHostScheduler(nil)
+
+// Wait blocks until a fetch of targetURL may start, returning how long it waited and a function
+// to call once the fetch is done; it fails only when ctx is done first
+// limits apply unless the scheduler was created with its own
+func (s *HostScheduler) Wait(ctx context.Context, targetURL string, limits config.Politeness) (func(), time.Duration, error) {
+	site := siteOf(targetURL)
+	start := time.Now()
+	for {
+		if s.limits != nil {
+			limits = s.limits()
+		}
+
+		s.mu.Lock()
+		now := time.Now()
+		s.pruneLocked(now)
+		slot, ok := s.sites[site]
+		if !ok {
+			slot = &siteSlot{changed: make(chan struct{})}
+			s.sites[site] = slot
+		}
+
+		var wait time.Duration // Zero waits for a fetch to finish
+		switch {
+		case limits.MaxConcurrentPerHost > 0 && slot.active >= limits.MaxConcurrentPerHost:
+		case now.Before(slot.next):
+			wait = slot.next.Sub(now)
+		default:
+			slot.active++
+			slot.next = now.Add(limits.MinInterval())
+			s.mu.Unlock()
+			return s.releaser(slot), time.Since(start), nil
+		}
+		changed := slot.changed
+		s.mu.Unlock()
+
+		if !sleepUntilChanged(ctx, wait, changed) {
+			return nil, time.Since(start), ctx.Err()
+		}
+	}
+}
+
+// sleepUntilChanged waits for d, or until changed is closed when d is zero
+// It returns false if ctx is done first
+func sleepUntilChanged(ctx context.Context, d time.Duration, changed <-chan struct{}) bool {
+	var timeout <-chan time.Time
+	if d > 0 {
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+		timeout = timer.C
+		changed = nil
+	}
+	select {
+	case <-ctx.Done():
+		return false
+	case <-changed:
+	case <-timeout:
+	}
+	return true
+}
+
+// releaser returns a function ending a fetch from slot, waking the fetches queued behind it
+func (s *HostScheduler) releaser(slot *siteSlot) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			slot.active--
+			close(slot.changed)
+			slot.changed = make(chan struct{})
+		})
+	}
+}
+
+// pruneLocked forgets sites with no fetch in flight that could be fetched again right away
+// Fetches waiting on a site keep it: they wait either for a fetch in flight or for next
+func (s *HostScheduler) pruneLocked(now time.Time) {
+	if now.Sub(s.lastPrune) < sitePruneInterval {
+		return
+	}
+	s.lastPrune = now
+	for site, slot := range s.sites {
+		if slot.active == 0 && !now.Before(slot.next) {
+			delete(s.sites, site)
+		}
+	}
+}
+
+// siteOf returns the registrable domain of targetURL, such as example.com for
+// https://www.example.com/a, falling back to the host for IP addresses and unknown suffixes
+func siteOf(targetURL string) string {
+	u, err := url.Parse(targetURL)
+	if err != nil {
+		return targetURL
+	}
+	host := strings.ToLower(u.Hostname())
+	if net.ParseIP(host) != nil {
+		return host
+	}
+	if site, err := publicsuffix.EffectiveTLDPlusOne(host); err == nil {
+		return site
+	}
+	return host
+}
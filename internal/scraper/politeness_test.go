@@ -0,0 +1,150 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/config"
+)
+
+// A fetch that may start right away does so even with a cancelled context, while one that has
+// to wait fails at once, so these tests need no timing thresholds
+func cancelledContext() context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	return ctx
+}
+
+func TestHostSchedulerSpacesFetchesToTheSameSite(t *testing.T) {
+	s := NewHostScheduler(func() config.Politeness {
+		return config.Politeness{MinIntervalMs: int(time.Hour / time.Millisecond), MaxConcurrentPerHost: 4}
+	})
+
+	release, _, err := s.Wait(cancelledContext(), "https://www.example.com/a", config.Politeness{})
+	if err != nil {
+		t.Fatalf("first fetch: %v", err)
+	}
+	release()
+
+	// Other sites are not held back, while amp. shares the site of www.
+	other, _, err := s.Wait(cancelledContext(), "https://example.org/", config.Politeness{})
+	if err != nil {
+		t.Fatalf("other site: %v", err)
+	}
+	other()
+	if _, _, err := s.Wait(cancelledContext(), "https://amp.example.com/a", config.Politeness{}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("second fetch of the site: err %v, want it to wait for the interval", err)
+	}
+}
+
+func TestHostSchedulerStartsAfterTheInterval(t *testing.T) {
+	const interval = 50 * time.Millisecond
+	s := NewHostScheduler(func() config.Politeness {
+		return config.Politeness{MinIntervalMs: int(interval / time.Millisecond)}
+	})
+
+	first := time.Now()
+	release, _, err := s.Wait(context.Background(), "https://example.com/a", config.Politeness{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	release()
+	release, waited, err := s.Wait(context.Background(), "https://example.com/b", config.Politeness{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	release()
+	if since := time.Since(first); since < interval || waited <= 0 {
+		t.Errorf("second fetch started %v after the first, having waited %v; want at least %v", since, waited, interval)
+	}
+}
+
+func TestHostSchedulerBoundsConcurrentFetches(t *testing.T) {
+	s := NewHostScheduler(func() config.Politeness {
+		return config.Politeness{MaxConcurrentPerHost: 1}
+	})
+	release, _, err := s.Wait(context.Background(), "https://example.com/a", config.Politeness{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := s.Wait(cancelledContext(), "https://example.com/b", config.Politeness{}); err == nil {
+		t.Fatal("second fetch started while the first was in flight")
+	}
+
+	var released atomic.Bool
+	done := make(chan func())
+	go func() {
+		next, _, err := s.Wait(context.Background(), "https://example.com/c", config.Politeness{})
+		if err != nil {
+			t.Error(err)
+		} else if !released.Load() {
+			t.Error("queued fetch started before the first was released")
+		}
+		done <- next
+	}()
+	time.Sleep(10 * time.Millisecond) // Usually enough for the fetch to queue; the test holds either way
+	released.Store(true)
+	release()
+	release() // Releasing twice must not free a second slot
+
+	var next func()
+	select {
+	case next = <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("queued fetch not woken by release")
+	}
+	if next == nil {
+		return
+	}
+	if _, _, err := s.Wait(cancelledContext(), "https://example.com/d", config.Politeness{}); err == nil {
+		t.Error("a double release freed a second slot")
+	}
+	next()
+}
+
+func TestFailedScrapeReportsQueueWait(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	s := NewScraperWithFetchers(NewArticleExtractor(), NewHTTPClientWithConfig(nil, config.BaseScrapeConfig()))
+	s.SetLogger(discardLogger())
+	s.SetScheduler(NewHostScheduler(func() config.Politeness {
+		return config.Politeness{MinIntervalMs: 50}
+	}))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if _, err := s.ScrapeSmartWithOptions(ctx, server.URL+"/first", DefaultExtractionOptions()); err == nil {
+		t.Fatal("scrape of a missing page succeeded")
+	}
+	result, err := s.ScrapeSmartWithOptions(ctx, server.URL+"/second", DefaultExtractionOptions())
+	if err == nil {
+		t.Fatal("scrape of a missing page succeeded")
+	}
+	if result.Metadata.QueueWaitMs <= 0 {
+		t.Errorf("queueWaitMs = %d, want the time spent waiting for the site", result.Metadata.QueueWaitMs)
+	}
+}
+
+func TestSiteOf(t *testing.T) {
+	tests := map[string]string{
+		"https://www.example.com/a":    "example.com",
+		"https://amp.example.co.uk/a":  "example.co.uk",
+		"http://127.0.0.1:8080/a":      "127.0.0.1",
+		"https://News.Example.com:443": "example.com",
+		"http://localhost/a":           "localhost",
+	}
+	for in, want := range tests {
+		if got := siteOf(in); got != want {
+			t.Errorf("siteOf(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/models"
+)
+
+// ErrNotModified is returned by Revalidate when the page has not changed
+var ErrNotModified = errors.New("page not modified")
+
+// Validators are the ETag and Last-Modified headers of a response, sent back in a
+// conditional request to learn whether the page changed
+type Validators struct {
+	ETag         string
+	LastModified string
+}
+
+// IsZero reports whether there is nothing to revalidate with
+func (v Validators) IsZero() bool {
+	return v.ETag == "" && v.LastModified == ""
+}
+
+// setRequestHeaders makes req conditional on the page having changed
+func (v Validators) setRequestHeaders(req *http.Request) {
+	if v.ETag != "" {
+		req.Header.Set("If-None-Match", v.ETag)
+	}
+	if v.LastModified != "" {
+		req.Header.Set("If-Modified-Since", v.LastModified)
+	}
+}
+
+// validatorsOf returns the validators of resp
+func validatorsOf(resp *http.Response) Validators {
+	return Validators{ETag: resp.Header.Get("ETag"), LastModified: resp.Header.Get("Last-Modified")}
+}
+
+// Revalidator is implemented by fetchers that can check whether a page changed since it was fetched
+type Revalidator interface {
+	// Revalidate fetches targetURL only if it changed since the response v came from,
+	// returning ErrNotModified when it did not
+	Revalidate(ctx context.Context, targetURL string, v Validators) (FetchResult, error)
+}
+
+// Revalidate implements Revalidator with a conditional GET, so an unchanged page costs a 304
+func (h *HTTPClient) Revalidate(ctx context.Context, targetURL string, v Validators) (FetchResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, adjustTimeoutForBudget(h.settings.Timeouts.HTTP(), calculateRemainingTime(ctx), 1.0))
+	defer cancel()
+
+	ctx, pages := withPageRecorder(ctx)
+	html, err := h.fetchHTML(ctx, targetURL, 0, v)
+	if err != nil {
+		return FetchResult{}, err
+	}
+	if h.LooksLikeCFBlock(html) {
+		return FetchResult{}, &models.CloudflareBlockError{Domain: hostname(targetURL), Err: errors.New("challenge page served")}
+	}
+	page := pages.get(targetURL)
+	return FetchResult{HTML: html, FinalURL: targetURL, Validators: page.Validators, Encoding: page.Encoding, Truncated: page.Truncated}, nil
+}
@@ -0,0 +1,383 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/config"
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/models"
+)
+
+const (
+	robotsTTL           = 24 * time.Hour   // How long a robots.txt is used, the most RFC 9309 allows
+	robotsRetryInterval = time.Minute      // How long an unreachable robots.txt disallows its host
+	robotsFetchTimeout  = 10 * time.Second // For fetching a robots.txt, whatever the budget of the scrape
+	robotsMaxBytes      = 500 << 10        // Rules beyond this are ignored
+	robotsPruneInterval = 10 * time.Minute // How often expired robots.txt files are forgotten
+)
+
+type robotsKey struct{}
+
+// WithRobotsCompliance returns a copy of ctx whose fetches honor robots.txt, even when
+// compliance is not enabled in the configuration
+func WithRobotsCompliance(ctx context.Context) context.Context {
+	return context.WithValue(ctx, robotsKey{}, true)
+}
+
+// robotsRequired reports whether fetches made with ctx under settings must honor robots.txt
+func robotsRequired(ctx context.Context, settings *config.Settings) bool {
+	required, _ := ctx.Value(robotsKey{}).(bool)
+	return required || settings.Robots.Enabled
+}
+
+// robotsCache fetches and keeps the robots.txt of each origin, and spaces out fetches from
+// origins that set a Crawl-delay
+type robotsCache struct {
+	client *http.Client
+
+	mu        sync.Mutex
+	files     map[string]*robotsEntry // By origin, such as https://example.com
+	next      map[string]time.Time    // Earliest start of the next fetch from an origin with a Crawl-delay
+	lastPrune time.Time
+}
+
+// robotsEntry is the robots.txt of one origin
+type robotsEntry struct {
+	ready   chan struct{} // Closed once file is set
+	file    *robotsFile
+	expires time.Time
+}
+
+// sharedRobots is used by both the HTTP and browser fetchers, so each robots.txt is fetched once
+var sharedRobots = newRobotsCache()
+
+func newRobotsCache() *robotsCache {
+	return &robotsCache{
+		client: &http.Client{
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				// RFC 9309 asks crawlers to follow at least five redirects
+				if len(via) >= 5 {
+					return http.ErrUseLastResponse
+				}
+				return nil
+			},
+		},
+		files: make(map[string]*robotsEntry),
+		next:  make(map[string]time.Time),
+	}
+}
+
+// Admit returns a *models.RobotsDisallowedError when robots.txt does not allow targetURL to be
+// fetched, and otherwise waits for the Crawl-delay since the previous fetch from its origin
+// It does nothing unless compliance is required for ctx or by settings, whose robots product
+// token is matched and whose user agent fetches robots.txt
+func (c *robotsCache) Admit(ctx context.Context, settings *config.Settings, targetURL string) error {
+	origin, delay, err := c.check(ctx, settings, targetURL)
+	if err != nil || delay == 0 {
+		return err
+	}
+	return c.waitCrawlDelay(ctx, origin, delay)
+}
+
+// Allowed is Admit without the Crawl-delay, for results served without fetching
+func (c *robotsCache) Allowed(ctx context.Context, settings *config.Settings, targetURL string) error {
+	_, _, err := c.check(ctx, settings, targetURL)
+	return err
+}
+
+// check applies the robots.txt of targetURL's origin, returning the origin and its Crawl-delay
+func (c *robotsCache) check(ctx context.Context, settings *config.Settings, targetURL string) (string, time.Duration, error) {
+	if !robotsRequired(ctx, settings) {
+		return "", 0, nil
+	}
+	u, err := url.Parse(targetURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Path == "/robots.txt" {
+		return "", 0, nil
+	}
+
+	origin := u.Scheme + "://" + u.Host
+	file, err := c.file(ctx, origin, settings.Scrape.UserAgent)
+	if err != nil {
+		return "", 0, err
+	}
+	token := settings.Robots.UserAgent
+	if file.unreachable != "" {
+		return "", 0, &models.RobotsDisallowedError{URL: targetURL, UserAgent: token, Reason: file.unreachable}
+	}
+	rules, delay := file.policy(token)
+	if !robotsAllowed(rules, robotsPath(u)) {
+		return "", 0, &models.RobotsDisallowedError{URL: targetURL, UserAgent: token}
+	}
+	return origin, delay, nil
+}
+
+// file returns the robots.txt of origin, fetching it as userAgent when it is not cached or has expired
+// Concurrent callers share one fetch, which is not canceled with ctx
+func (c *robotsCache) file(ctx context.Context, origin, userAgent string) (*robotsFile, error) {
+	c.mu.Lock()
+	now := time.Now()
+	c.pruneLocked(now)
+	entry, ok := c.files[origin]
+	if !ok || (!entry.expires.IsZero() && now.After(entry.expires)) {
+		entry = &robotsEntry{ready: make(chan struct{})}
+		c.files[origin] = entry
+		go c.fetch(context.WithoutCancel(ctx), origin, userAgent, entry)
+	}
+	c.mu.Unlock()
+
+	select {
+	case <-entry.ready:
+		return entry.file, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// fetch downloads the robots.txt of origin into entry
+func (c *robotsCache) fetch(ctx context.Context, origin, userAgent string, entry *robotsEntry) {
+	ctx, cancel := context.WithTimeout(ctx, robotsFetchTimeout)
+	defer cancel()
+
+	file, ttl := c.download(ctx, origin, userAgent)
+	c.mu.Lock()
+	entry.file = file
+	entry.expires = time.Now().Add(ttl)
+	c.mu.Unlock()
+	close(entry.ready)
+}
+
+// download fetches and parses the robots.txt of origin, returning how long it may be used
+// As RFC 9309 requires, a missing file allows everything and an unreachable one disallows everything
+func (c *robotsCache) download(ctx context.Context, origin, userAgent string) (*robotsFile, time.Duration) {
+	req, err := http.NewRequestWithContext(ctx, "GET", origin+"/robots.txt", nil)
+	if err != nil {
+		return &robotsFile{unreachable: err.Error()}, robotsRetryInterval
+	}
+	req.Header.Set("User-Agent", userAgent)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return &robotsFile{unreachable: fmt.Sprintf("robots.txt unreachable: %v", err)}, robotsRetryInterval
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return &robotsFile{unreachable: fmt.Sprintf("robots.txt answered HTTP %d", resp.StatusCode)}, robotsRetryInterval
+	case resp.StatusCode >= 300:
+		return &robotsFile{}, robotsTTL
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, robotsMaxBytes))
+	if err != nil {
+		return &robotsFile{unreachable: fmt.Sprintf("reading robots.txt: %v", err)}, robotsRetryInterval
+	}
+	return parseRobots(string(body)), robotsTTL
+}
+
+// waitCrawlDelay waits until a fetch from origin may start, delay after the previous one
+// It fails at once when ctx would expire first
+func (c *robotsCache) waitCrawlDelay(ctx context.Context, origin string, delay time.Duration) error {
+	c.mu.Lock()
+	now := time.Now()
+	start := c.next[origin]
+	if start.Before(now) {
+		start = now
+	}
+	if deadline, ok := ctx.Deadline(); ok && start.After(deadline) {
+		c.mu.Unlock()
+		return &models.TimeoutError{
+			Operation: "waiting for robots.txt Crawl-delay",
+			Timeout:   start.Sub(now).Round(time.Millisecond).String(),
+			Err:       context.DeadlineExceeded,
+		}
+	}
+	c.next[origin] = start.Add(delay)
+	c.mu.Unlock()
+
+	if wait := start.Sub(now); wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+	return nil
+}
+
+// pruneLocked forgets expired robots.txt files and Crawl-delays that have passed
+func (c *robotsCache) pruneLocked(now time.Time) {
+	if now.Sub(c.lastPrune) < robotsPruneInterval {
+		return
+	}
+	c.lastPrune = now
+	for origin, entry := range c.files {
+		if !entry.expires.IsZero() && now.After(entry.expires) {
+			delete(c.files, origin)
+		}
+	}
+	for origin, next := range c.next {
+		if now.After(next) {
+			delete(c.next, origin)
+		}
+	}
+}
+
+// robotsFile is a parsed robots.txt
+type robotsFile struct {
+	groups      []*robotsGroup
+	unreachable string // Why every URL is disallowed, when the file could not be fetched
+}
+
+// robotsGroup is a set of rules addressed to one or more user agents
+type robotsGroup struct {
+	agents     []string // Lowercase product tokens, or "*"
+	rules      []robotsRule
+	crawlDelay time.Duration
+}
+
+// robotsRule is an Allow or Disallow line
+type robotsRule struct {
+	allow   bool
+	pattern string // Path prefix, where * matches any characters and a final $ the end of the path
+}
+
+// parseRobots parses a robots.txt, ignoring lines it does not understand
+// Consecutive User-agent lines start a group that the following rules belong to
+func parseRobots(body string) *robotsFile {
+	file := &robotsFile{}
+	var group *robotsGroup
+	inAgents := false // Whether the previous record was a User-agent line
+	for _, line := range strings.Split(strings.TrimPrefix(body, "\ufeff"), "\n") {
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		field, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+
+		switch strings.ToLower(strings.TrimSpace(field)) {
+		case "user-agent":
+			if !inAgents {
+				group = &robotsGroup{}
+				file.groups = append(file.groups, group)
+			}
+			group.agents = append(group.agents, agentToken(value))
+			inAgents = true
+		case "allow", "disallow":
+			inAgents = false
+			// An empty Disallow allows everything, which is also what no rule does
+			if group != nil && value != "" {
+				group.rules = append(group.rules, robotsRule{allow: strings.EqualFold(strings.TrimSpace(field), "allow"), pattern: value})
+			}
+		case "crawl-delay":
+			inAgents = false
+			if seconds, err := strconv.ParseFloat(value, 64); group != nil && err == nil && seconds > 0 {
+				group.crawlDelay = time.Duration(seconds * float64(time.Second))
+			}
+		}
+	}
+	return file
+}
+
+// agentToken returns the product token of a User-agent value, such as googlebot for Googlebot/2.1
+func agentToken(value string) string {
+	if i := strings.IndexAny(value, "/ "); i >= 0 {
+		value = value[:i]
+	}
+	return strings.ToLower(value)
+}
+
+// policy returns the rules and Crawl-delay of the groups addressed to token, or of the groups
+// addressed to * when there are none
+func (f *robotsFile) policy(token string) ([]robotsRule, time.Duration) {
+	token = strings.ToLower(token)
+	for _, agent := range []string{token, "*"} {
+		var rules []robotsRule
+		var delay time.Duration
+		matched := false
+		for _, group := range f.groups {
+			for _, a := range group.agents {
+				if a == agent {
+					matched = true
+					rules = append(rules, group.rules...)
+					if group.crawlDelay > delay {
+						delay = group.crawlDelay
+					}
+					break
+				}
+			}
+		}
+		if matched {
+			return rules, delay
+		}
+	}
+	return nil, 0
+}
+
+// robotsPath returns the part of u that rules are matched against
+func robotsPath(u *url.URL) string {
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+	return path
+}
+
+// robotsAllowed applies the most specific rule matching path, Allow winning ties
+// Paths no rule matches are allowed
+func robotsAllowed(rules []robotsRule, path string) bool {
+	allowed, longest := true, -1
+	for _, rule := range rules {
+		n := len(rule.pattern)
+		if n < longest || !matchRobotsPattern(rule.pattern, path) {
+			continue
+		}
+		if n > longest {
+			allowed = rule.allow
+		} else {
+			allowed = allowed || rule.allow
+		}
+		longest = n
+	}
+	return allowed
+}
+
+// matchRobotsPattern reports whether path starts with pattern, where * matches any
+// characters and a final $ requires the pattern to match the whole path
+func matchRobotsPattern(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	parts := strings.Split(strings.TrimSuffix(pattern, "$"), "*")
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	rest := path[len(parts[0]):]
+	if len(parts) == 1 {
+		return !anchored || rest == ""
+	}
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(rest, part)
+		if i < 0 {
+			return false
+		}
+		rest = rest[i+len(part):]
+	}
+	last := parts[len(parts)-1]
+	if anchored {
+		return strings.HasSuffix(rest, last)
+	}
+	return strings.Contains(rest, last)
+}
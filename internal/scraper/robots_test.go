@@ -0,0 +1,258 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/cache"
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/config"
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/models"
+)
+
+const testRobots = `# Comments and unknown fields are ignored
+Sitemap: https://example.com/sitemap.xml
+
+User-agent: *
+Disallow: /private
+Allow: /private/press
+Disallow: /*.pdf$
+Disallow: /search?
+
+User-agent: Extract-HTML-Scraper/1.0
+User-agent: other-bot
+Disallow: /drafts/
+Crawl-delay: 1.5
+
+User-agent: extract-html-scraper
+Disallow: /tmp
+`
+
+func TestParseRobots(t *testing.T) {
+	file := parseRobots(testRobots)
+
+	tests := []struct {
+		token, path string
+		allowed     bool
+	}{
+		{"somebot", "/", true},
+		{"somebot", "/private/page", false},
+		{"somebot", "/private/press/release", true}, // The longer Allow wins
+		{"somebot", "/files/report.pdf", false},
+		{"somebot", "/files/report.pdf?dl=1", true}, // $ anchors the end
+		{"somebot", "/search?q=go", false},
+		{"somebot", "/search", true},
+		// Groups addressed to the token replace the * group and are combined
+		{"extract-html-scraper", "/private/page", true},
+		{"extract-html-scraper", "/drafts/a", false},
+		{"extract-html-scraper", "/tmp/a", false},
+		{"EXTRACT-HTML-SCRAPER", "/drafts/a", false},
+	}
+	for _, tt := range tests {
+		rules, _ := file.policy(tt.token)
+		if got := robotsAllowed(rules, tt.path); got != tt.allowed {
+			t.Errorf("%s %s: allowed = %v, want %v", tt.token, tt.path, got, tt.allowed)
+		}
+	}
+
+	if _, delay := file.policy("extract-html-scraper"); delay != 1500*time.Millisecond {
+		t.Errorf("crawl delay = %v, want 1.5s", delay)
+	}
+	if _, delay := file.policy("somebot"); delay != 0 {
+		t.Errorf("crawl delay for * = %v, want none", delay)
+	}
+
+	// Ties go to Allow; a robots.txt without rules allows everything
+	tie := parseRobots("User-agent: *\nDisallow: /page\nAllow: /page\n")
+	if rules, _ := tie.policy("somebot"); !robotsAllowed(rules, "/page") {
+		t.Error("equally specific Allow and Disallow: want allowed")
+	}
+	if rules, _ := parseRobots("").policy("somebot"); !robotsAllowed(rules, "/any") {
+		t.Error("empty robots.txt: want allowed")
+	}
+}
+
+func TestScrapeHonorsRobotsWhenRequired(t *testing.T) {
+	var privateFetches atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/robots.txt":
+			w.Write([]byte("User-agent: *\nDisallow: /private\n"))
+			return
+		case "/private":
+			privateFetches.Add(1)
+		}
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(fakeArticleHTML))
+	}))
+	defer server.Close()
+
+	s := NewScraperWithFetchers(NewArticleExtractor(), NewHTTPClient())
+	s.SetLogger(discardLogger())
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	compliant := WithRobotsCompliance(ctx)
+
+	if _, err := s.ScrapeSmartWithOptions(compliant, server.URL+"/article", DefaultExtractionOptions()); err != nil {
+		t.Fatalf("allowed page: %v", err)
+	}
+
+	_, err := s.ScrapeSmartWithOptions(compliant, server.URL+"/private", DefaultExtractionOptions())
+	var disallowed *models.RobotsDisallowedError
+	if !errors.As(err, &disallowed) || models.ErrorCode(err) != models.CodeDisallowedByRobots {
+		t.Fatalf("disallowed page: err = %v, want a RobotsDisallowedError", err)
+	}
+	if n := privateFetches.Load(); n != 0 {
+		t.Fatalf("disallowed page fetched %d times", n)
+	}
+
+	// Compliance is opt-in
+	if _, err := s.ScrapeSmartWithOptions(ctx, server.URL+"/private", DefaultExtractionOptions()); err != nil {
+		t.Fatalf("without compliance: %v", err)
+	}
+}
+
+func TestScraperHonorsRobotsFromItsSettings(t *testing.T) {
+	var robotsAgent atomic.Value
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			robotsAgent.Store(r.UserAgent())
+			w.Write([]byte("User-agent: custombot\nDisallow: /private\n"))
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(fakeArticleHTML))
+	}))
+	defer server.Close()
+
+	settings := config.DefaultSettings()
+	settings.Scrape.UserAgent = "CustomBot/1.0 (+https://example.com/bot)"
+	settings.Robots = config.Robots{Enabled: true, UserAgent: "custombot"}
+	s := NewScraperWithSettings(settings, nil)
+	defer s.Close(context.Background())
+	s.SetLogger(discardLogger())
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := s.ScrapeWithMode(ctx, server.URL+"/private", FetchModeHTTP, DefaultExtractionOptions())
+	var disallowed *models.RobotsDisallowedError
+	if !errors.As(err, &disallowed) || disallowed.UserAgent != "custombot" {
+		t.Fatalf("err = %v, want a RobotsDisallowedError for custombot", err)
+	}
+	if got := robotsAgent.Load(); got != settings.Scrape.UserAgent {
+		t.Errorf("robots.txt fetched as %q, want the scraper's user agent", got)
+	}
+
+	// Another scraper's settings do not leak into this one
+	other := NewScraperWithFetchers(NewArticleExtractor(), NewHTTPClient())
+	other.SetLogger(discardLogger())
+	if _, err := other.ScrapeWithMode(ctx, server.URL+"/private", FetchModeHTTP, DefaultExtractionOptions()); err != nil {
+		t.Fatalf("scraper without compliance: %v", err)
+	}
+}
+
+func TestRedirectsAreAdmitted(t *testing.T) {
+	tests := []struct {
+		name           string
+		client         *http.Client
+		privateFetches int32 // A caller's client follows the redirect before it can be checked
+	}{
+		{"pooled client", nil, 0},
+		{"caller's client", &http.Client{}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var privateFetches atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/robots.txt":
+					w.Write([]byte("User-agent: *\nDisallow: /private\n"))
+					return
+				case "/article":
+					http.Redirect(w, r, "/private", http.StatusFound)
+					return
+				case "/private":
+					privateFetches.Add(1)
+				}
+				w.Header().Set("Content-Type", "text/html")
+				w.Write([]byte(fakeArticleHTML))
+			}))
+			defer server.Close()
+
+			h := NewHTTPClientWithConfig(tt.client, config.DefaultScrapeConfig())
+			ctx, cancel := context.WithTimeout(WithRobotsCompliance(context.Background()), 10*time.Second)
+			defer cancel()
+
+			html, err := h.FetchHTML(ctx, server.URL+"/article", 0)
+			var disallowed *models.RobotsDisallowedError
+			if !errors.As(err, &disallowed) || html != "" {
+				t.Fatalf("err = %v with %d bytes, want a RobotsDisallowedError and no page", err, len(html))
+			}
+			if n := privateFetches.Load(); n != tt.privateFetches {
+				t.Errorf("disallowed page fetched %d times, want %d", n, tt.privateFetches)
+			}
+		})
+	}
+}
+
+func TestCachedResultsDoNotBypassRobots(t *testing.T) {
+	var privateFetches atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/robots.txt":
+			w.Write([]byte("User-agent: *\nDisallow: /private\n"))
+			return
+		case "/article":
+			http.Redirect(w, r, "/private", http.StatusFound)
+			return
+		case "/private":
+			privateFetches.Add(1)
+		}
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(fakeArticleHTML))
+	}))
+	defer server.Close()
+
+	s := NewScraperWithFetchers(NewArticleExtractor(), NewHTTPClient())
+	s.SetLogger(discardLogger())
+	s.SetCache(cache.NewLRU(10), time.Hour)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if _, err := s.ScrapeSmartWithOptions(ctx, server.URL+"/article", DefaultExtractionOptions()); err != nil {
+		t.Fatalf("without compliance: %v", err)
+	}
+
+	// The cached result came from a redirect to a disallowed page
+	_, err := s.ScrapeSmartWithOptions(WithRobotsCompliance(ctx), server.URL+"/article", DefaultExtractionOptions())
+	var disallowed *models.RobotsDisallowedError
+	if !errors.As(err, &disallowed) {
+		t.Fatalf("err = %v, want a RobotsDisallowedError", err)
+	}
+	if n := privateFetches.Load(); n != 1 {
+		t.Fatalf("disallowed page fetched %d times, want only the non-compliant scrape", n)
+	}
+}
+
+func TestUnreachableRobotsDisallowsEverything(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(fakeArticleHTML))
+	}))
+	defer server.Close()
+
+	err := newRobotsCache().Admit(WithRobotsCompliance(context.Background()), config.DefaultSettings(), server.URL+"/article")
+	var disallowed *models.RobotsDisallowedError
+	if !errors.As(err, &disallowed) || !strings.Contains(disallowed.Reason, "503") {
+		t.Fatalf("err = %v, want a RobotsDisallowedError reporting the 503", err)
+	}
+}
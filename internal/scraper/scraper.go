@@ -5,26 +5,144 @@ package scraper
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 	"net/url"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
-	"extract-html-scraper/internal/models"
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/cache"
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/config"
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/logging"
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/metrics"
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/models"
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/tracing"
 )
 
-// Scraper orchestrates the scraping process with HTTP-first, browser-fallback strategy
+// Scraper orchestrates the scraping process, trying an ordered list of fetchers
+// (by default HTTP first, browser fallback) and extracting the article from the first usable page
 type Scraper struct {
-	httpClient    *HTTPClient
-	browserClient *BrowserClient
-	extractor     *ArticleExtractor
+	logSink
+	fetchers  []Fetcher
+	extractor *ArticleExtractor
+	settings  *config.Settings // Robots.txt and politeness settings, and those the scraper was built with
+
+	cache       cache.Store // nil disables caching
+	cacheMaxAge time.Duration
+	flightsMu   sync.Mutex
+	flights     map[string]*flight // Scrapes of uncached pages in progress, by cache key
+
+	scheduler *HostScheduler
 }
 
 func NewScraper() *Scraper {
+	return NewScraperWithFetchers(NewArticleExtractor(), NewHTTPClient(), NewBrowserClient())
+}
+
+// NewScraperWithFetchers creates a scraper that tries fetchers in the given order, with the
+// default robots.txt and politeness settings
+func NewScraperWithFetchers(extractor *ArticleExtractor, fetchers ...Fetcher) *Scraper {
+	settings := config.DefaultSettings()
+	settings.Scrape = config.DefaultScrapeConfig()
+	return NewScraperWithSettingsAndFetchers(settings, extractor, fetchers...)
+}
+
+// NewScraperWithSettingsAndFetchers creates a scraper that tries fetchers in the given order,
+// applying the robots.txt and politeness settings of settings, which must not be modified
+func NewScraperWithSettingsAndFetchers(settings *config.Settings, extractor *ArticleExtractor, fetchers ...Fetcher) *Scraper {
 	return &Scraper{
-		httpClient:    NewHTTPClient(),
-		browserClient: NewBrowserClient(),
-		extractor:     NewArticleExtractor(),
+		fetchers:  fetchers,
+		extractor: extractor,
+		settings:  settings,
+		scheduler: defaultScheduler,
+	}
+}
+
+// NewScraperWithSettings creates a scraper whose fetchers, extractor and politeness use settings,
+// which must not be modified
+// Passing an existing browser reconfigures it and keeps its Chrome instances; nil creates a new one
+func NewScraperWithSettings(settings *config.Settings, browser *BrowserClient) *Scraper {
+	if browser == nil {
+		browser = NewBrowserClientWithSettings(settings, OptimizedBrowserOptions())
+	} else {
+		browser.SetSettings(settings)
+	}
+	return NewScraperWithSettingsAndFetchers(settings, NewArticleExtractorWithSettings(settings), NewHTTPClientWithSettings(nil, settings), browser)
+}
+
+// Settings returns the settings the scraper uses, which must not be modified
+func (s *Scraper) Settings() *config.Settings {
+	return s.settings
+}
+
+// SetScheduler replaces the process-wide scheduler that spaces out fetches to each site
+func (s *Scraper) SetScheduler(scheduler *HostScheduler) {
+	s.scheduler = scheduler
+}
+
+// SetLogger sends the scraper's progress messages to logger instead of slog.Default()
+// Fetchers that have a SetLogger method receive the logger too
+func (s *Scraper) SetLogger(logger *slog.Logger) {
+	s.logger = withRequestIDs(logger)
+	s.extractor.SetLogger(logger)
+	for _, f := range s.fetchers {
+		if l, ok := f.(interface{ SetLogger(*slog.Logger) }); ok {
+			l.SetLogger(logger)
+		}
+	}
+}
+
+// Close releases the resources held by fetchers that have a Close method, such as the
+// browser client's Chrome instances; it returns the first error once every fetcher is closed
+func (s *Scraper) Close(ctx context.Context) error {
+	var firstErr error
+	for _, f := range s.fetchers {
+		if c, ok := f.(interface{ Close(context.Context) error }); ok {
+			if err := c.Close(ctx); err != nil && firstErr == nil {
+				firstErr = fmt.Errorf("closing %s fetcher: %w", f.Name(), err)
+			}
+		}
+	}
+	return firstErr
+}
+
+// fetchersFor returns the fetchers used by a fetch mode
+// FetchModeAuto uses all of them; the other modes select the fetcher with the matching name
+func (s *Scraper) fetchersFor(mode FetchMode) []Fetcher {
+	if mode == FetchModeAuto || mode == "" {
+		return s.fetchers
+	}
+	var selected []Fetcher
+	for _, f := range s.fetchers {
+		if f.Name() == string(mode) {
+			selected = append(selected, f)
+		}
+	}
+	return selected
+}
+
+// FetchMode selects how pages are fetched before extraction
+type FetchMode string
+
+// Supported fetch modes
+const (
+	FetchModeAuto    FetchMode = "auto"    // HTTP first, browser fallback
+	FetchModeHTTP    FetchMode = "http"    // Plain HTTP only
+	FetchModeBrowser FetchMode = "browser" // Headless Chrome only
+)
+
+// ParseFetchMode converts a string to a FetchMode, treating an empty value as auto
+func ParseFetchMode(value string) (FetchMode, error) {
+	switch FetchMode(value) {
+	case "", FetchModeAuto:
+		return FetchModeAuto, nil
+	case FetchModeHTTP, FetchModeBrowser:
+		return FetchMode(value), nil
+	default:
+		return "", fmt.Errorf("unknown fetch mode %q (expected auto, http or browser)", value)
 	}
 }
 
@@ -52,129 +170,275 @@ func adjustTimeoutForBudget(baseTimeout, remainingTime time.Duration, maxPercent
 
 // ScrapeSmart implements the hybrid scraping strategy: HTTP first, browser fallback
 func (s *Scraper) ScrapeSmart(ctx context.Context, targetURL string) (models.ScrapeResponse, error) {
+	return s.ScrapeSmartWithOptions(ctx, targetURL, DefaultExtractionOptions())
+}
+
+// ScrapeSmartWithOptions runs the hybrid scraping strategy using the given extraction options
+func (s *Scraper) ScrapeSmartWithOptions(ctx context.Context, targetURL string, options ExtractionOptions) (models.ScrapeResponse, error) {
+	return s.ScrapeWithMode(ctx, targetURL, FetchModeAuto, options)
+}
+
+// ScrapeWithMode scrapes a URL using the given fetch mode
+// FetchModeAuto is the hybrid strategy; FetchModeHTTP and FetchModeBrowser run a single phase
+func (s *Scraper) ScrapeWithMode(ctx context.Context, targetURL string, mode FetchMode, options ExtractionOptions) (models.ScrapeResponse, error) {
+	ctx, span := tracing.Start(ctx, "scrape", slog.String("url", targetURL), slog.String("mode", string(mode)))
+	var debug *debugRecorder
+	if options.Debug {
+		ctx, debug = withDebug(ctx)
+	}
+	ctx = withPageSizeLimit(ctx, options.MaxBytes)
+	result, err := s.scrapeCached(ctx, targetURL, mode, options.Normalized())
+	result.Debug = debug.result() // Also set on failure so callers can explain the error
+	endSpan(span, err)
+	return result, err
+}
+
+// scrapeWithMode runs the fetchers for mode in turn and extracts the first usable page
+// It also returns the validators of that page, for revalidating a cached copy
+// On failure the response only reports, in its metadata, how long fetches queued for the site
+func (s *Scraper) scrapeWithMode(ctx context.Context, targetURL string, mode FetchMode, options ExtractionOptions) (response models.ScrapeResponse, _ Validators, scrapeErr error) {
 	// Validate URL
 	if _, err := url.Parse(targetURL); err != nil {
-		return models.ScrapeResponse{}, fmt.Errorf("invalid URL: %w", err)
+		return models.ScrapeResponse{}, Validators{}, &models.InvalidURLError{URL: targetURL, Err: err}
+	}
+
+	if err := options.Validate(); err != nil {
+		return models.ScrapeResponse{}, Validators{}, fmt.Errorf("invalid extraction options: %w", err)
+	}
+
+	if _, err := ParseFetchMode(string(mode)); err != nil {
+		return models.ScrapeResponse{}, Validators{}, err
+	}
+
+	fetchers := s.fetchersFor(mode)
+	if len(fetchers) == 0 {
+		return models.ScrapeResponse{}, Validators{}, fmt.Errorf("no fetcher available for fetch mode %q", mode)
 	}
 
-	// Add small random delay to avoid rate limiting (100-500ms)
-	// This helps when multiple requests hit the same domain
-	randomDelay := time.Duration(100+time.Now().UnixNano()%400) * time.Millisecond
-	time.Sleep(randomDelay)
+	// Correlate every log line of this scrape, keeping an ID set by the caller
+	if logging.RequestID(ctx) == "" {
+		ctx = logging.WithRequestID(ctx, logging.NewRequestID())
+	}
 
 	// Calculate remaining time budget from parent context
 	remainingTime := calculateRemainingTime(ctx)
-	fmt.Printf("Remaining time budget: %v\n", remainingTime)
+	s.info(ctx, "starting scrape", "url", targetURL, "mode", mode, "budget", remainingTime)
 
-	// Phase 1: Try HTTP fetching with alternate URLs
-	// Adjust HTTP timeout based on remaining budget (allow 80% max for HTTP phase)
-	httpTimeout := adjustTimeoutForBudget(HTTPTimeout, remainingTime, 0.8)
-	if httpTimeout < 1*time.Second {
-		fmt.Printf("Phase 1: Skipping HTTP fetch - insufficient time budget (%v)\n", remainingTime)
-	} else {
-		fmt.Printf("Phase 1: Starting HTTP fetch for %s (timeout: %v, remaining budget: %v)\n", targetURL, httpTimeout, remainingTime)
-		httpCtx, cancel := context.WithTimeout(ctx, httpTimeout)
+	start := time.Now()
+	var err error
+	var errs []error
+	var queueWait time.Duration
+	defer func() {
+		if scrapeErr != nil {
+			response.Metadata.QueueWaitMs = queueWait.Milliseconds()
+		}
+	}()
+	for i, fetcher := range fetchers {
+		phase := i + 1
 
-		phase1Start := time.Now()
-		html, finalURL, err := s.httpClient.FetchWithAlternatesGroup(httpCtx, targetURL)
-		phase1Duration := time.Since(phase1Start)
+		// Queue behind other fetches of the same site; the budget below only counts what is left after
+		release, waited, waitErr := s.scheduler.Wait(ctx, targetURL, s.settings.Politeness)
+		queueWait += waited
+		metrics.HostQueueWait.Observe(waited.Seconds(), fetcher.Name())
+		if waitErr != nil {
+			s.warn(ctx, "gave up waiting for the site's fetch queue", "phase", phase, "fetcher", fetcher.Name(), "waited", waited)
+			if waitErr == context.DeadlineExceeded {
+				return models.ScrapeResponse{}, Validators{}, &models.TimeoutError{
+					Operation: "waiting for " + fetcher.Name() + " fetch",
+					Timeout:   time.Since(start).Round(time.Millisecond).String(),
+					Err:       waitErr,
+				}
+			}
+			return models.ScrapeResponse{}, Validators{}, fmt.Errorf("scraping failed: parent context expired waiting for %s fetch: %w", fetcher.Name(), waitErr)
+		}
+		if waited > 0 {
+			s.debug(ctx, "waited for the site's fetch queue", "phase", phase, "fetcher", fetcher.Name(), "waited", waited)
+		}
+
+		// Every fetcher but the last may use at most 80% of the remaining budget,
+		// keeping time for the fallbacks after it
+		remainingTime = calculateRemainingTime(ctx)
+		budget := remainingTime
+		if i < len(fetchers)-1 {
+			budget = time.Duration(float64(remainingTime) * 0.8)
+		}
+		if budget < 1*time.Second {
+			release()
+			recordFetch(phase, fetcher.Name(), metrics.OutcomeSkipped, 0)
+			s.warn(ctx, "skipping fetcher, insufficient time budget", "phase", phase, "fetcher", fetcher.Name(), "remaining", remainingTime)
+			err = &models.TimeoutError{
+				Operation: fetcher.Name() + " fetch",
+				Timeout:   time.Since(start).Round(time.Millisecond).String(),
+				Err:       fmt.Errorf("insufficient time budget (remaining: %v)", remainingTime),
+			}
+			errs = append(errs, err)
+			debugFrom(ctx).endPhase(phase, fetcher.Name(), metrics.OutcomeSkipped, 0, FetchResult{}, err)
+			continue
+		}
+
+		s.info(ctx, "starting fetch", "phase", phase, "fetcher", fetcher.Name(), "url", targetURL, "budget", budget)
+		fetchCtx, cancel := context.WithTimeout(ctx, budget)
+		fetchCtx, span := tracing.Start(fetchCtx, "fetch "+fetcher.Name(),
+			slog.Int("phase", phase), slog.String("fetcher", fetcher.Name()), slog.Duration("budget", budget))
+		fetchStart := time.Now()
+		var page FetchResult
+		page, err = fetcher.Fetch(fetchCtx, targetURL)
+		fetchDuration := time.Since(fetchStart)
+		span.SetAttributes(slog.Int("html_bytes", len(page.HTML)))
+		endSpan(span, err)
 		cancel()
+		release()
 
 		if err == nil {
-			// Validate HTML has content before extracting
-			if len(html) == 0 || len(strings.TrimSpace(html)) < 100 {
-				fmt.Printf("Phase 1: HTTP fetch returned empty or minimal HTML (%d bytes), treating as failure\n", len(html))
-				// Treat as failure and continue to Phase 2
-				err = fmt.Errorf("HTTP fetch returned empty or minimal HTML")
-			} else {
-				// Success with HTTP - extract content with multiple strategies
-				remainingAfterPhase1 := calculateRemainingTime(ctx)
-				fmt.Printf("Phase 1: HTTP fetch succeeded for %s (HTML size: %d bytes, consumed: %v, remaining: %v)\n", finalURL, len(html), phase1Duration, remainingAfterPhase1)
-				result := s.extractor.ExtractArticleWithMultipleStrategies(html, finalURL)
-				// Verify extraction found at least title or content
-				if len(result.Content) == 0 && len(result.Title) == 0 {
-					fmt.Printf("Phase 1: All extraction strategies returned empty, treating as failure\n")
-					err = fmt.Errorf("content extraction returned empty results")
-				} else {
-					fmt.Printf("Phase 1: Extraction succeeded (strategy worked, title=%d, content=%d)\n",
-						len(result.Title), len(result.Content))
-					return result, nil
-				}
+			page.Metadata.Fetcher = fetcher.Name()
+			page.Metadata.Duration = fetchDuration
+			var result models.ScrapeResponse
+			result, err = s.extractPage(ctx, phase, targetURL, page, options)
+			if err == nil {
+				result.Metadata.QueueWaitMs = queueWait.Milliseconds()
+				recordFetch(phase, fetcher.Name(), metrics.OutcomeSuccess, fetchDuration)
+				debugFrom(ctx).endPhase(phase, fetcher.Name(), metrics.OutcomeSuccess, fetchDuration, page, nil)
+				return result, page.Validators, nil
+			}
+			recordFetch(phase, fetcher.Name(), metrics.OutcomeExtractFailed, fetchDuration)
+			debugFrom(ctx).endPhase(phase, fetcher.Name(), metrics.OutcomeExtractFailed, fetchDuration, page, err)
+		} else {
+			recordFetch(phase, fetcher.Name(), metrics.OutcomeFetchError, fetchDuration)
+			debugFrom(ctx).endPhase(phase, fetcher.Name(), metrics.OutcomeFetchError, fetchDuration, page, err)
+			if IsCloudflareBlock(err) {
+				metrics.BlockTotal.Inc(hostname(targetURL), fetcher.Name())
 			}
 		}
 
-		remainingAfterPhase1 := calculateRemainingTime(ctx)
-		fmt.Printf("Phase 1: HTTP fetch failed for %s: %v (consumed: %v, remaining: %v)\n", targetURL, err, phase1Duration, remainingAfterPhase1)
+		s.warn(ctx, "fetch failed", "phase", phase, "fetcher", fetcher.Name(), "url", targetURL, "error", err,
+			"duration", fetchDuration, "remaining", calculateRemainingTime(ctx))
+		errs = append(errs, err)
 
-		// Check if parent context expired during Phase 1
+		// robots.txt applies to every fetcher alike
+		var disallowed *models.RobotsDisallowedError
+		if errors.As(err, &disallowed) {
+			return models.ScrapeResponse{}, Validators{}, err
+		}
+
+		// Check if parent context expired during this phase
+		if ctx.Err() == context.DeadlineExceeded {
+			return models.ScrapeResponse{}, Validators{}, &models.TimeoutError{
+				Operation: fetcher.Name() + " phase",
+				Timeout:   time.Since(start).Round(time.Millisecond).String(),
+				Err:       ctx.Err(),
+			}
+		}
 		if ctx.Err() != nil {
-			return models.ScrapeResponse{}, fmt.Errorf("scraping failed: parent context expired during HTTP phase: %w", ctx.Err())
+			return models.ScrapeResponse{}, Validators{}, fmt.Errorf("scraping failed: parent context expired during %s phase: %w", fetcher.Name(), ctx.Err())
 		}
 	}
 
-	// Phase 2: Browser fallback
-	// Recalculate remaining time after Phase 1
-	remainingTime = calculateRemainingTime(ctx)
+	// Combine errors from all phases for better context
+	failed := &models.FetchFailedError{Errs: errs}
 
-	// Adjust browser timeout based on remaining budget (leave 5s buffer for cleanup)
-	buffer := 5 * time.Second
-	maxBrowserTime := remainingTime - buffer
-	if maxBrowserTime < 1*time.Second {
-		return models.ScrapeResponse{}, fmt.Errorf("scraping failed: insufficient time budget for browser phase (remaining: %v)", remainingTime)
+	// Check if the last fetcher was blocked by Cloudflare
+	if IsCloudflareBlock(err) {
+		domain, _ := url.Parse(targetURL)
+		s.warn(ctx, "detected Cloudflare block", "domain", domain.Hostname())
+		return models.ScrapeResponse{
+				Images: []models.Image{},
+			}, Validators{}, &models.CloudflareBlockError{
+				Domain: domain.Hostname(),
+				Err:    failed,
+			}
 	}
 
-	browserTimeout := adjustTimeoutForBudget(BrowserTimeout, maxBrowserTime, 1.0)
+	return models.ScrapeResponse{}, Validators{}, failed
+}
 
-	fmt.Printf("Phase 2: Starting browser scraping for %s (timeout: %v, remaining budget: %v)\n", targetURL, browserTimeout, remainingTime)
-	browserCtx, cancel := context.WithTimeout(ctx, browserTimeout)
-	defer cancel()
+// recordFetch updates the fetch metrics for one fetcher attempt
+func recordFetch(phase int, fetcher, outcome string, duration time.Duration) {
+	p := strconv.Itoa(phase)
+	metrics.FetchTotal.Inc(p, fetcher, outcome)
+	if outcome != metrics.OutcomeSkipped {
+		metrics.FetchDuration.Observe(duration.Seconds(), p, fetcher, outcome)
+	}
+}
 
-	phase2Start := time.Now()
-	html, finalURL, err := s.browserClient.ScrapeWithBrowserOptimized(browserCtx, targetURL, int(browserTimeout.Milliseconds()))
-	phase2Duration := time.Since(phase2Start)
-
-	if err == nil {
-		// Success with browser - extract content
-		remainingAfterPhase2 := calculateRemainingTime(ctx)
-		htmlLength := len(html)
-		textLength := len(strings.TrimSpace(html))
-		fmt.Printf("Phase 2: Browser scraping succeeded for %s (HTML: %d chars, text: %d chars, consumed: %v, remaining: %v)\n",
-			finalURL, htmlLength, textLength, phase2Duration, remainingAfterPhase2)
-		result := s.extractor.ExtractArticleWithMultipleStrategies(html, finalURL)
-		// Let extraction be the final judge - only reject if both title and content are empty
-		if len(result.Content) == 0 && len(result.Title) == 0 {
-			fmt.Printf("Phase 2: All extraction strategies returned empty (title=%d chars, content=%d chars), treating as failure\n",
-				len(result.Title), len(result.Content))
-			err = fmt.Errorf("content extraction returned empty results")
-		} else {
-			fmt.Printf("Phase 2: Extraction successful (title=%d chars, content=%d chars, quality score=%d)\n",
-				len(result.Title), len(result.Content), result.Quality.Score)
-			return result, nil
+// hostname returns the host of targetURL without port, or "" if it cannot be parsed
+func hostname(targetURL string) string {
+	u, err := url.Parse(targetURL)
+	if err != nil {
+		return ""
+	}
+	return u.Hostname()
+}
+
+// extractPage runs the extraction strategies on a fetched page
+// Pages with minimal HTML or no extractable title or content are reported as failures
+func (s *Scraper) extractPage(ctx context.Context, phase int, targetURL string, page FetchResult, options ExtractionOptions) (result models.ScrapeResponse, err error) {
+	ctx, span := tracing.Start(ctx, "extract", slog.Int("phase", phase), slog.String("fetcher", page.Metadata.Fetcher))
+	defer func() { endSpan(span, err) }()
+
+	if len(strings.TrimSpace(page.HTML)) < 100 {
+		return models.ScrapeResponse{}, &models.ContentExtractionError{
+			Step: "fetch",
+			Err:  fmt.Errorf("fetch returned empty or minimal HTML (%d bytes)", len(page.HTML)),
 		}
 	}
 
-	remainingAfterPhase2 := calculateRemainingTime(ctx)
-	fmt.Printf("Phase 2: Browser scraping failed for %s: %v (consumed: %v, remaining: %v)\n", targetURL, err, phase2Duration, remainingAfterPhase2)
+	finalURL := page.FinalURL
+	if finalURL == "" {
+		finalURL = targetURL
+	}
 
-	// Check if parent context expired during Phase 2
-	if ctx.Err() != nil {
-		return models.ScrapeResponse{}, fmt.Errorf("scraping failed: parent context expired during browser phase: %w", ctx.Err())
+	s.info(ctx, "fetch succeeded", "phase", phase, "fetcher", page.Metadata.Fetcher, "url", finalURL,
+		"html_bytes", len(page.HTML), "duration", page.Metadata.Duration)
+	result = s.extractor.ExtractArticleWithMultipleStrategies(ctx, page.HTML, finalURL, options)
+	if len(result.Content) == 0 && len(result.Title) == 0 {
+		return models.ScrapeResponse{}, &models.ContentExtractionError{
+			Step: "extract",
+			Err:  fmt.Errorf("all extraction strategies returned empty results"),
+		}
 	}
 
-	// Check if it's a Cloudflare block
-	if IsCloudflareBlock(err) {
-		domain, _ := url.Parse(targetURL)
-		fmt.Printf("Detected Cloudflare block for domain: %s\n", domain.Hostname())
-		return models.ScrapeResponse{
-				Images: []models.Image{},
-			}, &models.CloudflareBlockError{
-				Domain: domain.Hostname(),
-				Err:    err,
-			}
+	s.info(ctx, "extraction succeeded", "phase", phase, "fetcher", page.Metadata.Fetcher,
+		"title_chars", len(result.Title), "content_chars", len(result.Content), "quality", result.Quality.Score)
+
+	result.Metadata.FinalURL = finalURL
+	result.Metadata.Fetcher = page.Metadata.Fetcher
+	result.Metadata.Encoding = page.Encoding
+	result.Metadata.Truncated = page.Truncated
+	return result, nil
+}
+
+// ExtractFromHTML runs the extraction strategies on caller-supplied HTML, skipping the HTTP and browser phases
+// baseURL is used to resolve relative links and images
+func (s *Scraper) ExtractFromHTML(ctx context.Context, html, baseURL string, options ExtractionOptions) (result models.ScrapeResponse, err error) {
+	ctx, span := tracing.Start(ctx, "extract", slog.Int("html_bytes", len(html)))
+	var debug *debugRecorder
+	if options.Debug {
+		ctx, debug = withDebug(ctx)
+	}
+	defer func() {
+		result.Debug = debug.result()
+		endSpan(span, err)
+	}()
+
+	if _, err := url.Parse(baseURL); err != nil {
+		return models.ScrapeResponse{}, &models.InvalidURLError{URL: baseURL, Err: err}
+	}
+
+	if err := options.Validate(); err != nil {
+		return models.ScrapeResponse{}, fmt.Errorf("invalid extraction options: %w", err)
+	}
+
+	result = s.extractor.ExtractArticleWithMultipleStrategies(ctx, html, baseURL, options.Normalized())
+	if len(result.Content) == 0 && len(result.Title) == 0 {
+		return models.ScrapeResponse{}, &models.ContentExtractionError{
+			Step: "extract",
+			Err:  fmt.Errorf("all extraction strategies returned empty results"),
+		}
 	}
 
-	// Combine errors from both phases for better context
-	return models.ScrapeResponse{}, fmt.Errorf("scraping failed - HTTP phase failed, browser phase also failed: %w", err)
+	s.info(ctx, "extraction from supplied HTML succeeded", "html_bytes", len(html),
+		"title_chars", len(result.Title), "content_chars", len(result.Content), "quality", result.Quality.Score)
+	return result, nil
 }
 
 // ScrapeSmartWithTimeout runs ScrapeSmart with a timeout
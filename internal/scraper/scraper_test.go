@@ -0,0 +1,230 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/metrics"
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/models"
+)
+
+const fakeArticleHTML = `<html><head><title>Fake Article</title></head><body><article>
+<h1>Fake Article</h1>
+<p>This article is served by a fake fetcher so the scraping pipeline can be tested without a network.</p>
+<p>A second paragraph makes sure readability treats the page as a real article with enough text.</p>
+</article></body></html>`
+
+// fakeFetcher returns a canned page or error and records whether it was called
+type fakeFetcher struct {
+	name   string
+	result FetchResult
+	err    error
+	calls  int
+}
+
+func (f *fakeFetcher) Name() string {
+	return f.name
+}
+
+func (f *fakeFetcher) Fetch(ctx context.Context, targetURL string) (FetchResult, error) {
+	f.calls++
+	return f.result, f.err
+}
+
+func TestScrapeSmartFallsBackToNextFetcher(t *testing.T) {
+	first := &fakeFetcher{name: "cache", err: errors.New("cache miss")}
+	second := &fakeFetcher{name: "archive", result: FetchResult{HTML: fakeArticleHTML, FinalURL: "https://example.com/final"}}
+	third := &fakeFetcher{name: "never"}
+
+	s := NewScraperWithFetchers(NewArticleExtractor(), first, second, third)
+	s.SetLogger(discardLogger())
+	failures := metrics.FetchTotal.Value("1", "cache", metrics.OutcomeFetchError)
+	successes := metrics.FetchTotal.Value("2", "archive", metrics.OutcomeSuccess)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	result, err := s.ScrapeSmart(ctx, "https://example.com/article")
+	if err != nil {
+		t.Fatalf("scrape failed: %v", err)
+	}
+	if result.Title != "Fake Article" {
+		t.Errorf("unexpected title %q", result.Title)
+	}
+	if result.Metadata.Fetcher != "archive" || result.Metadata.FinalURL != "https://example.com/final" {
+		t.Errorf("unexpected fetch metadata %+v", result.Metadata)
+	}
+	if first.calls != 1 || second.calls != 1 || third.calls != 0 {
+		t.Errorf("unexpected calls: first=%d second=%d third=%d", first.calls, second.calls, third.calls)
+	}
+	if metrics.FetchTotal.Value("1", "cache", metrics.OutcomeFetchError) != failures+1 ||
+		metrics.FetchTotal.Value("2", "archive", metrics.OutcomeSuccess) != successes+1 {
+		t.Errorf("expected one failed phase 1 and one successful phase 2 fetch to be counted")
+	}
+}
+
+func TestScrapeWithModeSelectsFetcherByName(t *testing.T) {
+	httpFetcher := &fakeFetcher{name: FetcherHTTP, err: errors.New("HTTP 500")}
+	browserFetcher := &fakeFetcher{name: FetcherBrowser, result: FetchResult{HTML: fakeArticleHTML}}
+
+	s := NewScraperWithFetchers(NewArticleExtractor(), httpFetcher, browserFetcher)
+	s.SetLogger(discardLogger())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if _, err := s.ScrapeWithMode(ctx, "https://example.com/article", FetchModeHTTP, DefaultExtractionOptions()); err == nil {
+		t.Fatalf("expected http mode to fail without falling back to the browser")
+	}
+	if browserFetcher.calls != 0 {
+		t.Fatalf("browser fetcher must not run in http mode")
+	}
+
+	result, err := s.ScrapeWithMode(ctx, "https://example.com/article", FetchModeBrowser, DefaultExtractionOptions())
+	if err != nil {
+		t.Fatalf("browser mode failed: %v", err)
+	}
+	if result.Metadata.Fetcher != FetcherBrowser || result.Metadata.FinalURL != "https://example.com/article" {
+		t.Errorf("unexpected fetch metadata %+v", result.Metadata)
+	}
+}
+
+func TestScrapeSmartReportsCloudflareBlock(t *testing.T) {
+	blocked := &fakeFetcher{name: FetcherBrowser, err: &models.HTTPError{StatusCode: 403, URL: "https://blocked.example.com/article", Err: errors.New("Cloudflare challenge: verifying you are human")}}
+	empty := &fakeFetcher{name: FetcherHTTP, result: FetchResult{HTML: "<html></html>"}}
+
+	s := NewScraperWithFetchers(NewArticleExtractor(), empty, blocked)
+	s.SetLogger(discardLogger())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := s.ScrapeSmart(ctx, "https://blocked.example.com/article")
+	var cfErr *models.CloudflareBlockError
+	if !errors.As(err, &cfErr) {
+		t.Fatalf("expected CloudflareBlockError, got %v", err)
+	}
+	if cfErr.Domain != "blocked.example.com" {
+		t.Errorf("unexpected domain %q", cfErr.Domain)
+	}
+	if empty.calls != 1 || !strings.Contains(cfErr.Error(), "verifying you are human") {
+		t.Errorf("expected minimal HTML to fall through to the blocked fetcher, got %v", cfErr)
+	}
+}
+
+func discardLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+func TestDebugTraceExplainsResult(t *testing.T) {
+	first := &fakeFetcher{name: "cache", err: errors.New("cache miss")}
+	second := &fakeFetcher{name: "archive", result: FetchResult{HTML: fakeArticleHTML, FinalURL: "https://example.com/final"}}
+
+	s := NewScraperWithFetchers(NewArticleExtractor(), first, second)
+	s.SetLogger(discardLogger())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	options := DefaultExtractionOptions()
+	result, err := s.ScrapeSmartWithOptions(ctx, "https://example.com/article", options)
+	if err != nil || result.Debug != nil {
+		t.Fatalf("expected no debug trace unless requested, got %+v (err %v)", result.Debug, err)
+	}
+
+	options.Debug = true
+	result, err = s.ScrapeSmartWithOptions(ctx, "https://example.com/article", options)
+	if err != nil {
+		t.Fatalf("scrape failed: %v", err)
+	}
+	if result.Debug == nil || len(result.Debug.Phases) != 2 {
+		t.Fatalf("expected two phases, got %+v", result.Debug)
+	}
+
+	failed, succeeded := result.Debug.Phases[0], result.Debug.Phases[1]
+	if failed.Fetcher != "cache" || failed.Outcome != metrics.OutcomeFetchError || failed.Error != "cache miss" {
+		t.Errorf("unexpected first phase %+v", failed)
+	}
+	if succeeded.Fetcher != "archive" || succeeded.Outcome != metrics.OutcomeSuccess ||
+		succeeded.FinalURL != "https://example.com/final" || succeeded.HTMLBytes != len(fakeArticleHTML) {
+		t.Errorf("unexpected second phase %+v", succeeded)
+	}
+
+	selected := 0
+	for _, strategy := range succeeded.Strategies {
+		if strategy.Selected {
+			selected++
+			if strategy.Strategy != "readability" || strategy.CompositeScore <= strategy.Quality.Score {
+				t.Errorf("unexpected selected strategy %+v", strategy)
+			}
+		}
+	}
+	if selected != 1 {
+		t.Errorf("expected exactly one selected strategy, got %d in %+v", selected, succeeded.Strategies)
+	}
+
+	// Failures carry the trace too
+	second.err = errors.New("archive down")
+	result, err = s.ScrapeSmartWithOptions(ctx, "https://example.com/article", options)
+	if err == nil || result.Debug == nil || len(result.Debug.Phases) != 2 || result.Debug.Phases[1].Error != "archive down" {
+		t.Fatalf("expected failed phases in the trace, got %+v (err %v)", result.Debug, err)
+	}
+}
+
+// relativeArticleHTML links and illustrates its article with URLs relative to the page
+const relativeArticleHTML = `<html><head><title>Relative Article</title></head><body><main><article>
+<h1>Relative Article</h1>
+<img src="/images/hero.jpg" width="800" height="450" alt="Hero">
+<p>This article was supplied as HTML, so its <a href="/about">relative links</a> resolve against the base URL.</p>
+<p>A second paragraph makes sure readability treats the page as a real article with enough text.</p>
+</article></main></body></html>`
+
+func TestExtractFromHTML(t *testing.T) {
+	markdown := DefaultExtractionOptions()
+	markdown.OutputFormat = OutputFormatMarkdown
+	invalid := DefaultExtractionOptions()
+	invalid.OutputFormat = "pdf"
+
+	var invalidURL *models.InvalidURLError
+	var noContent *models.ContentExtractionError
+	tests := []struct {
+		name    string
+		html    string
+		baseURL string
+		options ExtractionOptions
+		wantErr func(error) bool // Nil when the extraction succeeds
+	}{
+		{"resolves relative URLs", relativeArticleHTML, "https://news.test/2024/story", markdown, nil},
+		{"invalid base URL", relativeArticleHTML, "http://[::1", markdown, func(err error) bool { return errors.As(err, &invalidURL) }},
+		{"invalid options", relativeArticleHTML, "https://news.test/2024/story", invalid, func(err error) bool { return strings.Contains(err.Error(), "pdf") }},
+		{"no content", "<html><body><div></div></body></html>", "https://news.test/empty", markdown, func(err error) bool { return errors.As(err, &noContent) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewScraperWithFetchers(NewArticleExtractor())
+			s.SetLogger(discardLogger())
+
+			result, err := s.ExtractFromHTML(context.Background(), tt.html, tt.baseURL, tt.options)
+			if tt.wantErr != nil {
+				if err == nil || !tt.wantErr(err) {
+					t.Fatalf("ExtractFromHTML() error = %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ExtractFromHTML() failed: %v", err)
+			}
+			if !strings.Contains(result.Content, "[relative links](https://news.test/about)") {
+				t.Errorf("content %q does not link to the resolved URL", result.Content)
+			}
+			if len(result.Images) != 1 || result.Images[0].URL != "https://news.test/images/hero.jpg" {
+				t.Errorf("images = %+v, want the resolved hero image", result.Images)
+			}
+		})
+	}
+}
@@ -2,7 +2,11 @@
 package scraper
 
 import (
+	"errors"
+	"net/http"
 	"strings"
+
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/models"
 )
 
 // CleanWhitespace removes excessive whitespace from text content
@@ -21,6 +25,11 @@ func CleanWhitespace(text string) string {
 
 // CleanTextContent removes common noise patterns from text content
 func CleanTextContent(text string) string {
+	return CleanTextContentWithMinLength(text, DefaultExtractionOptions().MinParagraphChars)
+}
+
+// CleanTextContentWithMinLength removes noise, dropping lines shorter than minChars bytes
+func CleanTextContentWithMinLength(text string, minChars int) string {
 	if text == "" {
 		return ""
 	}
@@ -31,8 +40,8 @@ func CleanTextContent(text string) string {
 
 	for _, line := range lines {
 		line = strings.TrimSpace(line)
-		// Keep lines that are longer than 20 characters or are empty (for spacing)
-		if len(line) == 0 || len(line) > 20 {
+		// Keep lines that reach the minimum length or are empty (for spacing)
+		if len(line) == 0 || len(line) >= minChars {
 			cleanedLines = append(cleanedLines, line)
 		}
 	}
@@ -83,11 +92,15 @@ func ContainsAny(s string, substrings []string) bool {
 }
 
 // IsCloudflareBlock checks if the error indicates Cloudflare blocking
+// Errors wrapping a CloudflareBlockError match, as do those wrapping an HTTP 403, which is how
+// site protection refuses a fetch it did not answer with a challenge page
 func IsCloudflareBlock(err error) bool {
-	if err == nil {
-		return false
+	var blocked *models.CloudflareBlockError
+	if errors.As(err, &blocked) {
+		return true
 	}
-	return ContainsAny(err.Error(), CloudflarePatterns)
+	var httpErr *models.HTTPError
+	return errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusForbidden
 }
 
 // BuildStructuredText extracts text content preserving structure from HTML elements
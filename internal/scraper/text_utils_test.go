@@ -0,0 +1,58 @@
+package scraper
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/models"
+)
+
+func TestCleanTextContentKeepsHistoricalLineFilter(t *testing.T) {
+	const twenty = "Exactly twenty bytes"
+	const twentyOne = "Exactly twenty-one bs"
+	if len(twenty) != 20 || len(twentyOne) != 21 {
+		t.Fatal("fixture lengths are off")
+	}
+
+	got := CleanTextContent(twenty + "\n" + twentyOne)
+	if got != twentyOne {
+		t.Errorf("CleanTextContent = %q, want only the 21-byte line", got)
+	}
+
+	if got := CleanTextContentWithMinLength(twenty, 20); got != twenty {
+		t.Errorf("with a minimum of 20: %q, want the 20-byte line kept", got)
+	}
+}
+
+func TestIsCloudflareBlock(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"block", &models.CloudflareBlockError{Domain: "example.com", Err: errors.New("challenge page served")}, true},
+		{"wrapped block", fmt.Errorf("HTTP fetch failed: %w", &models.CloudflareBlockError{Domain: "example.com"}), true},
+		{"HTTP 403", fmt.Errorf("all alternate URLs failed or were blocked: %w", &models.HTTPError{StatusCode: 403}), true},
+		{"HTTP 404", &models.HTTPError{StatusCode: 404, Err: errors.New("Not Found")}, false},
+		{"message naming a block", errors.New("HTTP 403: all alternate URLs failed, cloudflare ray id"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsCloudflareBlock(tt.err); got != tt.want {
+				t.Errorf("IsCloudflareBlock(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLooksLikeCFBlock(t *testing.T) {
+	h := NewHTTPClient()
+	if !h.LooksLikeCFBlock("<title>Attention Required! | Cloudflare</title>") {
+		t.Error("challenge page not detected")
+	}
+	if h.LooksLikeCFBlock("<p>This site is served through Cloudflare's CDN.</p>") {
+		t.Error("article mentioning Cloudflare taken for a challenge page")
+	}
+}
@@ -0,0 +1,26 @@
+package scraper
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/tracing"
+
+	"github.com/chromedp/chromedp"
+)
+
+// endSpan records err, if any, and ends span
+func endSpan(span *tracing.Span, err error) {
+	span.RecordError(err)
+	span.End()
+}
+
+// tracedAction is a chromedp action that runs fn inside a span named name
+func tracedAction(name string, fn func(ctx context.Context) error, attrs ...slog.Attr) chromedp.ActionFunc {
+	return func(ctx context.Context) error {
+		ctx, span := tracing.Start(ctx, name, attrs...)
+		err := fn(ctx)
+		endSpan(span, err)
+		return err
+	}
+}
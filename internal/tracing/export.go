@@ -0,0 +1,104 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Exporter sends finished spans to a backend
+type Exporter = sdktrace.SpanExporter
+
+// Provider batches finished spans and hands them to an exporter in the background
+type Provider struct {
+	provider *sdktrace.TracerProvider
+	tracer   trace.Tracer
+}
+
+// NewProvider creates a provider exporting to exporter
+// Spans are dropped rather than blocking callers when the queue is full
+func NewProvider(exporter Exporter) *Provider {
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(serviceResource()),
+	)
+	return &Provider{provider: provider, tracer: provider.Tracer(instrumentationScope)}
+}
+
+// ForceFlush exports every span ended so far
+func (p *Provider) ForceFlush(ctx context.Context) error {
+	return p.provider.ForceFlush(ctx)
+}
+
+// Shutdown exports the remaining spans and shuts the exporter down
+// Spans ended afterwards are dropped
+func (p *Provider) Shutdown(ctx context.Context) error {
+	return p.provider.Shutdown(ctx)
+}
+
+// instrumentationScope names the library producing the spans, and is the default service name
+const instrumentationScope = "extract-html-scraper"
+
+// serviceResource describes the process reporting spans; OTEL_SERVICE_NAME and
+// OTEL_RESOURCE_ATTRIBUTES override the default service name
+func serviceResource() *resource.Resource {
+	// A malformed OTEL_RESOURCE_ATTRIBUTES is a partial error: res still holds everything else
+	res, _ := resource.New(context.Background(),
+		resource.WithAttributes(attribute.String("service.name", instrumentationScope)),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+	)
+	return res
+}
+
+// FromEnv creates a provider from the standard OpenTelemetry environment variables, or nil when
+// tracing is off. OTEL_TRACES_EXPORTER selects none (default), console (JSON lines on console)
+// or otlp. OTEL_EXPORTER_OTLP_PROTOCOL selects http/protobuf (default) or grpc; the exporters
+// read OTEL_EXPORTER_OTLP_TRACES_ENDPOINT, OTEL_EXPORTER_OTLP_ENDPOINT, OTEL_EXPORTER_OTLP_HEADERS
+// and the other OTEL_EXPORTER_OTLP_* variables themselves.
+func FromEnv(console io.Writer) (*Provider, error) {
+	var exporter Exporter
+	var err error
+	switch name := strings.ToLower(strings.TrimSpace(os.Getenv("OTEL_TRACES_EXPORTER"))); name {
+	case "", "none":
+		return nil, nil
+	case "console":
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(console))
+	case "otlp":
+		exporter, err = otlpExporter()
+	default:
+		return nil, fmt.Errorf("unsupported OTEL_TRACES_EXPORTER %q (use none, console or otlp)", name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return NewProvider(exporter), nil
+}
+
+// otlpExporter creates the OTLP exporter for OTEL_EXPORTER_OTLP_TRACES_PROTOCOL or
+// OTEL_EXPORTER_OTLP_PROTOCOL
+// Creating it does not connect: an unreachable collector only fails exports
+func otlpExporter() (Exporter, error) {
+	protocol := os.Getenv("OTEL_EXPORTER_OTLP_TRACES_PROTOCOL")
+	if protocol == "" {
+		protocol = os.Getenv("OTEL_EXPORTER_OTLP_PROTOCOL")
+	}
+	switch protocol {
+	case "", "http/protobuf":
+		return otlptracehttp.New(context.Background())
+	case "grpc":
+		return otlptracegrpc.New(context.Background())
+	default:
+		return nil, fmt.Errorf("unsupported OTLP protocol %q (use http/protobuf or grpc)", protocol)
+	}
+}
@@ -0,0 +1,171 @@
+// Package tracing records spans for the scraping pipeline with the OpenTelemetry SDK and
+// exports them over OTLP or to a console writer.
+// It is a thin layer over go.opentelemetry.io/otel that keeps the pipeline's conventions:
+// attributes are slog attributes, and spans are nil when tracing is off, so call sites
+// need no OpenTelemetry imports of their own.
+package tracing
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// SpanContext is the part of a span that is propagated to children and across processes
+type SpanContext = trace.SpanContext
+
+// SpanKind describes the relationship of a span to its callers
+type SpanKind = trace.SpanKind
+
+// Span kinds
+const (
+	SpanKindInternal = trace.SpanKindInternal
+	SpanKindServer   = trace.SpanKindServer
+	SpanKindClient   = trace.SpanKindClient
+)
+
+// StatusCode is the status of a finished span
+type StatusCode = codes.Code
+
+// Status codes
+const (
+	StatusUnset = codes.Unset
+	StatusOK    = codes.Ok
+	StatusError = codes.Error
+)
+
+// Span is an operation being timed
+// All methods are safe to call on a nil Span, which is what Start returns when tracing is off
+type Span struct {
+	span trace.Span
+}
+
+// SpanContext returns the span's propagated identity
+func (s *Span) SpanContext() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return s.span.SpanContext()
+}
+
+// SetAttributes adds attributes to the span
+func (s *Span) SetAttributes(attrs ...slog.Attr) {
+	if s == nil {
+		return
+	}
+	s.span.SetAttributes(attributes(attrs)...)
+}
+
+// RecordError records err as an exception event and marks the span as failed; nil is ignored
+func (s *Span) RecordError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.span.RecordError(err)
+	s.span.SetStatus(codes.Error, err.Error())
+}
+
+// SetStatus sets the span status
+func (s *Span) SetStatus(code StatusCode, message string) {
+	if s == nil {
+		return
+	}
+	s.span.SetStatus(code, message)
+}
+
+// End finishes the span and queues it for export; later calls do nothing
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.span.End()
+}
+
+// SpanFromContext returns the recording span carried by ctx, or nil
+func SpanFromContext(ctx context.Context) *Span {
+	span := trace.SpanFromContext(ctx)
+	if !span.IsRecording() {
+		return nil
+	}
+	return &Span{span: span}
+}
+
+// SpanContextFromContext returns the identity of the span carried by ctx, which may be a
+// remote parent set with Extract or WithSpanContext
+func SpanContextFromContext(ctx context.Context) SpanContext {
+	return trace.SpanContextFromContext(ctx)
+}
+
+// WithSpanContext returns a copy of ctx whose new spans become children of sc
+// It carries a parent across process boundaries or into work that outlives a request
+func WithSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	if !sc.IsValid() {
+		return ctx
+	}
+	return trace.ContextWithSpanContext(ctx, sc)
+}
+
+var globalProvider atomic.Pointer[Provider]
+
+// SetProvider installs p as the provider used by Start; nil turns tracing off
+func SetProvider(p *Provider) {
+	globalProvider.Store(p)
+}
+
+// Start begins an internal span named name as a child of the span carried by ctx
+// The returned context carries the new span; call End on the span when the operation finishes
+func Start(ctx context.Context, name string, attrs ...slog.Attr) (context.Context, *Span) {
+	return StartKind(ctx, SpanKindInternal, name, attrs...)
+}
+
+// StartKind is Start with an explicit span kind
+func StartKind(ctx context.Context, kind SpanKind, name string, attrs ...slog.Attr) (context.Context, *Span) {
+	p := globalProvider.Load()
+	if p == nil {
+		return ctx, nil
+	}
+	ctx, span := p.tracer.Start(ctx, name, trace.WithSpanKind(kind), trace.WithAttributes(attributes(attrs)...))
+	return ctx, &Span{span: span}
+}
+
+// propagator reads and writes W3C trace context headers
+var propagator = propagation.TraceContext{}
+
+// Extract returns a copy of ctx carrying the remote parent from a W3C traceparent header
+// Missing or malformed headers leave ctx unchanged
+func Extract(ctx context.Context, header http.Header) context.Context {
+	return propagator.Extract(ctx, propagation.HeaderCarrier(header))
+}
+
+// Inject sets the traceparent header for the span carried by ctx
+func Inject(ctx context.Context, header http.Header) {
+	propagator.Inject(ctx, propagation.HeaderCarrier(header))
+}
+
+// attributes converts slog attributes to OpenTelemetry ones; durations and other non-scalar
+// values are formatted as strings
+func attributes(attrs []slog.Attr) []attribute.KeyValue {
+	out := make([]attribute.KeyValue, 0, len(attrs))
+	for _, a := range attrs {
+		value := a.Value.Resolve()
+		switch value.Kind() {
+		case slog.KindBool:
+			out = append(out, attribute.Bool(a.Key, value.Bool()))
+		case slog.KindInt64:
+			out = append(out, attribute.Int64(a.Key, value.Int64()))
+		case slog.KindUint64:
+			out = append(out, attribute.Int64(a.Key, int64(value.Uint64())))
+		case slog.KindFloat64:
+			out = append(out, attribute.Float64(a.Key, value.Float64()))
+		default:
+			out = append(out, attribute.String(a.Key, value.String()))
+		}
+	}
+	return out
+}
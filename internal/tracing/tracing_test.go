@@ -0,0 +1,221 @@
+package tracing
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	collectortrace "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	otlptrace "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestExtract(t *testing.T) {
+	tests := []struct {
+		name        string
+		traceparent string
+		valid       bool
+		sampled     bool
+	}{
+		{"sampled", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", true, true},
+		{"not sampled", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00", true, false},
+		{"missing", "", false, false},
+		{"zero trace ID", "00-00000000000000000000000000000000-00f067aa0ba902b7-01", false, false},
+		{"zero span ID", "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", false, false},
+		{"uppercase", "00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01", false, false},
+		{"forbidden version", "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", false, false},
+		{"version 00 with extra fields", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", false, false},
+		{"garbage", "not a traceparent", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			if tt.traceparent != "" {
+				header.Set("traceparent", tt.traceparent)
+			}
+			sc := SpanContextFromContext(Extract(context.Background(), header))
+
+			if sc.IsValid() != tt.valid {
+				t.Fatalf("extracted %+v, want valid %v", sc, tt.valid)
+			}
+			if !tt.valid {
+				return
+			}
+			if sc.TraceID().String() != "4bf92f3577b34da6a3ce929d0e0e4736" || sc.SpanID().String() != "00f067aa0ba902b7" {
+				t.Errorf("extracted trace %s span %s", sc.TraceID(), sc.SpanID())
+			}
+			if sc.IsSampled() != tt.sampled || !sc.IsRemote() {
+				t.Errorf("extracted sampled %v remote %v, want sampled %v and remote", sc.IsSampled(), sc.IsRemote(), tt.sampled)
+			}
+
+			out := http.Header{}
+			Inject(WithSpanContext(context.Background(), sc), out)
+			if got := out.Get("traceparent"); got != tt.traceparent {
+				t.Errorf("Inject() = %q, want %q", got, tt.traceparent)
+			}
+		})
+	}
+}
+
+func TestSpansExportToOTLPCollector(t *testing.T) {
+	requests := make(chan *collectortrace.ExportTraceServiceRequest, 1)
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := &collectortrace.ExportTraceServiceRequest{}
+		body, _ := io.ReadAll(r.Body)
+		if err := proto.Unmarshal(body, req); err != nil {
+			t.Errorf("invalid OTLP body: %v", err)
+		}
+		requests <- req
+	}))
+	defer collector.Close()
+
+	t.Setenv("OTEL_TRACES_EXPORTER", "otlp")
+	t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", collector.URL+"/v1/traces")
+	t.Setenv("OTEL_SERVICE_NAME", "test-service")
+	provider, err := FromEnv(io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetProvider(provider)
+	defer SetProvider(nil)
+
+	header := http.Header{}
+	header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	ctx := Extract(context.Background(), header)
+
+	ctx, parent := Start(ctx, "scrape", slog.String("url", "https://example.com"))
+	_, child := Start(ctx, "fetch http", slog.Int("phase", 1))
+	child.RecordError(errors.New("HTTP 403"))
+	child.End()
+	parent.End()
+
+	if err := provider.Shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown failed: %v", err)
+	}
+
+	req := <-requests
+	spans := req.ResourceSpans[0].ScopeSpans[0].Spans
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(spans))
+	}
+	fetch, scrape := spans[0], spans[1]
+	if hex.EncodeToString(scrape.TraceId) != "4bf92f3577b34da6a3ce929d0e0e4736" || hex.EncodeToString(scrape.ParentSpanId) != "00f067aa0ba902b7" {
+		t.Errorf("expected the root span to continue the incoming trace, got %v", scrape)
+	}
+	if hex.EncodeToString(fetch.ParentSpanId) != hex.EncodeToString(scrape.SpanId) || fetch.Status.GetCode() != otlptrace.Status_STATUS_CODE_ERROR {
+		t.Errorf("unexpected child span %v", fetch)
+	}
+	if a := fetch.Attributes[0]; a.Key != "phase" || a.Value.GetIntValue() != 1 {
+		t.Errorf("expected phase attribute as an OTLP int, got %v", fetch.Attributes)
+	}
+	var service string
+	for _, a := range req.ResourceSpans[0].Resource.Attributes {
+		if a.Key == "service.name" {
+			service = a.Value.GetStringValue()
+		}
+	}
+	if service != "test-service" {
+		t.Errorf("service.name = %q, want the one of OTEL_SERVICE_NAME", service)
+	}
+}
+
+// recordingExporter keeps the spans exported to it
+type recordingExporter struct {
+	mu       sync.Mutex
+	names    []string
+	shutdown bool
+}
+
+func (e *recordingExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, s := range spans {
+		e.names = append(e.names, s.Name())
+	}
+	return nil
+}
+
+func (e *recordingExporter) Shutdown(ctx context.Context) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.shutdown = true
+	return nil
+}
+
+func TestShutdownFlushesExporter(t *testing.T) {
+	exporter := &recordingExporter{}
+	provider := NewProvider(exporter)
+	SetProvider(provider)
+	defer SetProvider(nil)
+
+	_, span := Start(context.Background(), "before shutdown")
+	span.End()
+	// Still open at shutdown, so never exported
+	_, late := Start(context.Background(), "after shutdown")
+
+	if err := provider.Shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown failed: %v", err)
+	}
+	late.End()
+
+	exporter.mu.Lock()
+	defer exporter.mu.Unlock()
+	if len(exporter.names) != 1 || exporter.names[0] != "before shutdown" {
+		t.Errorf("exported %v, want only the span ended before shutdown", exporter.names)
+	}
+	if !exporter.shutdown {
+		t.Errorf("the exporter was not shut down")
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	tests := []struct {
+		name     string
+		exporter string
+		protocol string
+		enabled  bool
+		wantErr  bool
+	}{
+		{"off by default", "", "", false, false},
+		{"none", "none", "", false, false},
+		{"console", "console", "", true, false},
+		{"otlp over http", "otlp", "", true, false},
+		{"otlp over grpc", "otlp", "grpc", true, false},
+		{"unsupported protocol", "otlp", "http/json", false, true},
+		{"unsupported exporter", "zipkin", "", false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("OTEL_TRACES_EXPORTER", tt.exporter)
+			t.Setenv("OTEL_EXPORTER_OTLP_PROTOCOL", tt.protocol)
+
+			provider, err := FromEnv(io.Discard)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FromEnv() error = %v, want error %v", err, tt.wantErr)
+			}
+			if (provider != nil) != tt.enabled {
+				t.Fatalf("FromEnv() = %v, want a provider %v", provider, tt.enabled)
+			}
+			if provider != nil {
+				provider.Shutdown(context.Background())
+			}
+		})
+	}
+}
+
+func TestStartWithoutProviderIsNoop(t *testing.T) {
+	ctx, span := Start(context.Background(), "noop")
+	span.SetAttributes(slog.String("k", "v"))
+	span.RecordError(errors.New("ignored"))
+	span.End()
+	if span != nil || SpanFromContext(ctx) != nil {
+		t.Errorf("expected no span without a provider")
+	}
+}
@@ -28,21 +28,27 @@ case "$1" in
     ;;
   set-secret)
     if [ -z "$2" ]; then
-        echo "❌ Usage: $0 set-secret <key1,key2,key3>"
-        echo "Example: $0 set-secret 'abc123,def456'"
+        echo "❌ Usage: $0 set-secret <keys.json | key1,key2,key3>"
+        echo "Example: $0 set-secret keys.json   (hashed keys written by: go run ./cmd/apikey -id NAME -file keys.json)"
         exit 1
     fi
     echo "Setting API keys via Secret Manager..."
+    # A key file holds salted hashes only; a comma-separated list stores the keys in plaintext
+    if [ -f "$2" ]; then
+        SECRET_DATA=$(cat "$2")
+    else
+        SECRET_DATA="$2"
+    fi
     
     # Check if secret exists
     if ! gcloud secrets describe $SECRET_NAME --project=$PROJECT_ID &>/dev/null; then
         echo "Creating new secret: $SECRET_NAME"
-        echo -n "$2" | gcloud secrets create $SECRET_NAME \
+        echo -n "$SECRET_DATA" | gcloud secrets create $SECRET_NAME \
             --data-file=- \
             --project=$PROJECT_ID
     else
         echo "Updating existing secret: $SECRET_NAME"
-        echo -n "$2" | gcloud secrets versions add $SECRET_NAME \
+        echo -n "$SECRET_DATA" | gcloud secrets versions add $SECRET_NAME \
             --data-file=- \
             --project=$PROJECT_ID
     fi
@@ -65,9 +71,10 @@ case "$1" in
         --role="roles/secretmanager.secretAccessor" \
         --project=$PROJECT_ID 2>/dev/null || echo "Note: Service account may need manual secret access configuration"
     
-    # Update Cloud Run service to use secret
+    # Mount the secret as a file; the service re-reads it periodically, so new versions apply without a redeploy
     gcloud run services update $SERVICE_NAME \
-        --set-secrets="SCRAPER_API_KEY_SECRET=${SECRET_NAME}:latest" \
+        --set-secrets="/secrets/${SECRET_NAME}=${SECRET_NAME}:latest" \
+        --update-env-vars="SCRAPER_API_KEY_SECRET=${SECRET_NAME}" \
         --region=$REGION \
         --project=$PROJECT_ID
     
@@ -95,12 +102,12 @@ case "$1" in
     echo ""
     echo "Commands:"
     echo "  set-env <keys>     Set API keys via environment variable (simple, for dev/test)"
-    echo "  set-secret <keys>   Set API keys via Secret Manager (recommended for production)"
+    echo "  set-secret <file|keys> Set API keys via Secret Manager from a hashed key file (recommended for production)"
     echo "  list               Show current API key configuration"
     echo ""
     echo "Examples:"
     echo "  $0 set-env 'key1,key2,key3'"
-    echo "  $0 set-secret 'key1,key2,key3'"
+    echo "  $0 set-secret keys.json"
     echo "  $0 list"
     exit 1
     ;;
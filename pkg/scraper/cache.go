@@ -0,0 +1,125 @@
+package scraper
+
+import (
+	"time"
+
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/cache"
+)
+
+// CacheStore holds scrape results for WithCache
+// Implementations must be safe for concurrent use
+type CacheStore interface {
+	Get(key string) (CacheEntry, bool)
+	Put(entry CacheEntry)
+}
+
+// CacheEntry is a cached scrape result and what is needed to revalidate it
+type CacheEntry struct {
+	Key      string
+	Response Article
+	StoredAt time.Time // When the result was last fetched or revalidated
+
+	// Revalidation uses a conditional request to FetchURL with the validators the server sent
+	FetchURL     string
+	ETag         string
+	LastModified string
+}
+
+// DiskCacheLimits bounds a disk cache; zero fields are unlimited
+type DiskCacheLimits struct {
+	MaxEntries int
+	MaxBytes   int64
+	MaxAge     time.Duration // Results not written for this long are deleted
+}
+
+// NewMemoryCache creates an in-memory cache keeping the maxEntries most recently used results
+func NewMemoryCache(maxEntries int) CacheStore {
+	return builtinCache{cache.NewLRU(maxEntries)}
+}
+
+// NewDiskCache creates a cache keeping one file per result in dir, within DefaultDiskCacheLimits
+func NewDiskCache(dir string) (CacheStore, error) {
+	return NewDiskCacheWithLimits(dir, DefaultDiskCacheLimits())
+}
+
+// NewDiskCacheWithLimits creates a disk cache in dir, deleting the oldest results beyond limits
+func NewDiskCacheWithLimits(dir string, limits DiskCacheLimits) (CacheStore, error) {
+	disk, err := cache.NewDiskWithLimits(dir, cache.DiskLimits{MaxEntries: limits.MaxEntries, MaxBytes: limits.MaxBytes, MaxAge: limits.MaxAge})
+	if err != nil {
+		return nil, err
+	}
+	return builtinCache{disk}, nil
+}
+
+// DefaultDiskCacheLimits returns the limits of NewDiskCache: 10000 results, 256MB, kept a day
+func DefaultDiskCacheLimits() DiskCacheLimits {
+	limits := cache.DefaultDiskLimits()
+	return DiskCacheLimits{MaxEntries: limits.MaxEntries, MaxBytes: limits.MaxBytes, MaxAge: limits.MaxAge}
+}
+
+// NewTieredCache layers caches, fastest first, such as a memory cache in front of a disk cache
+func NewTieredCache(stores ...CacheStore) CacheStore {
+	internal := make([]cache.Store, len(stores))
+	for i, store := range stores {
+		internal[i] = internalStore(store)
+	}
+	return builtinCache{cache.NewTiered(internal...)}
+}
+
+// builtinCache is a store of the pipeline, handed back to the pipeline as is by New
+type builtinCache struct {
+	store cache.Store
+}
+
+func (c builtinCache) Get(key string) (CacheEntry, bool) {
+	entry, ok := c.store.Get(key)
+	return cacheEntryFrom(entry), ok
+}
+
+func (c builtinCache) Put(entry CacheEntry) {
+	c.store.Put(entry.internal())
+}
+
+// storeAdapter runs a caller's CacheStore in the pipeline
+type storeAdapter struct {
+	store CacheStore
+}
+
+// internalStore returns the pipeline store for store, unwrapping the built-in ones
+func internalStore(store CacheStore) cache.Store {
+	if c, ok := store.(builtinCache); ok {
+		return c.store
+	}
+	return storeAdapter{store}
+}
+
+func (a storeAdapter) Get(key string) (cache.Entry, bool) {
+	entry, ok := a.store.Get(key)
+	return entry.internal(), ok
+}
+
+func (a storeAdapter) Put(entry cache.Entry) {
+	a.store.Put(cacheEntryFrom(entry))
+}
+
+func (e CacheEntry) internal() cache.Entry {
+	return cache.Entry{
+		Key:          e.Key,
+		Response:     e.Response,
+		StoredAt:     e.StoredAt,
+		FetchURL:     e.FetchURL,
+		ETag:         e.ETag,
+		LastModified: e.LastModified,
+	}
+}
+
+func cacheEntryFrom(e cache.Entry) CacheEntry {
+	return CacheEntry{
+		Key:          e.Key,
+		Response:     e.Response,
+		StoredAt:     e.StoredAt,
+		FetchURL:     e.FetchURL,
+		ETag:         e.ETag,
+		LastModified: e.LastModified,
+	}
+}
@@ -0,0 +1,183 @@
+package scraper
+
+import (
+	"maps"
+	"slices"
+
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/config"
+	core "github.com/vdelacou/Go-Extract-Article-Content/internal/scraper"
+)
+
+// ScrapeConfig contains HTTP and browser fetching settings
+type ScrapeConfig struct {
+	UserAgent         string
+	TimeoutMs         int
+	SizeLimitBytes    int // Reading a page may stop past this once its article and structured data are in
+	MaxSizeLimitBytes int // Fetched HTML beyond this is truncated; also caps ExtractionOptions.MaxBytes
+	MaxRetries        int
+	ChromeMajor       int // Chrome version advertised by the default user agent
+}
+
+// ImageConfig contains image size and filtering rules
+type ImageConfig struct {
+	MinShortSide   int
+	MinArea        int
+	MinAspect      float64
+	MaxAspect      float64
+	RatioWhitelist []float64
+	RatioTol       float64
+	AdSizes        map[string]bool // Banner sizes such as "728x90" that are never article images
+	BadHintRegex   string          // Images whose URL, class or alt text match are skipped
+}
+
+// Politeness limits how often and how concurrently each site is fetched
+// Sites are registrable domains: www., amp. and m. subdomains share limits
+type Politeness struct {
+	MinIntervalMs        int // Between the starts of two fetches from the same site; 0 disables
+	MaxConcurrentPerHost int // Fetches in flight per site; 0 is unlimited
+}
+
+// BrowserOptions configures the headless Chrome fallback
+type BrowserOptions struct {
+	Optimized    bool
+	BlockImages  bool
+	BlockJS      bool
+	BlockFonts   bool
+	BlockCSS     bool
+	WindowWidth  int
+	WindowHeight int
+	UserAgent    string // Empty uses the one from the scrape configuration
+}
+
+// ExtractionOptions configures a single extraction
+type ExtractionOptions struct {
+	PreserveHTML      bool // Legacy, the same as OutputFormatHTML
+	IncludeMetadata   bool
+	MinTextLength     int
+	MinParagraphChars int
+	RemoveComments    bool
+	OutputFormat      string // OutputFormatText (also when empty), OutputFormatMarkdown or OutputFormatHTML
+	HTMLProfile       string // HTMLProfileStrict, HTMLProfileLinks or HTMLProfileTables; html output only
+	Debug             bool   // Attach a DebugTrace explaining how the result was produced
+	MaxAge            *int   // Seconds a cached result may be old; 0 revalidates, nil uses the WithCache default
+	MaxBytes          int    // Most of a fetched page to read, capped by ScrapeConfig.MaxSizeLimitBytes; 0 uses the configured limits
+}
+
+// Validate reports options the extractor cannot honor, such as an unknown output format
+func (o ExtractionOptions) Validate() error {
+	return o.internal().Validate()
+}
+
+// FetchMode selects how pages are fetched
+type FetchMode string
+
+// Conversions between the types above and the pipeline's own, which they mirror field by field
+// Slices and maps are copied so neither side can change the other's configuration
+
+func (c ScrapeConfig) internal() config.ScrapeConfig {
+	return config.ScrapeConfig{
+		UserAgent:         c.UserAgent,
+		TimeoutMs:         c.TimeoutMs,
+		SizeLimitBytes:    c.SizeLimitBytes,
+		MaxSizeLimitBytes: c.MaxSizeLimitBytes,
+		MaxRetries:        c.MaxRetries,
+		ChromeMajor:       c.ChromeMajor,
+	}
+}
+
+func scrapeConfigFrom(c config.ScrapeConfig) ScrapeConfig {
+	return ScrapeConfig{
+		UserAgent:         c.UserAgent,
+		TimeoutMs:         c.TimeoutMs,
+		SizeLimitBytes:    c.SizeLimitBytes,
+		MaxSizeLimitBytes: c.MaxSizeLimitBytes,
+		MaxRetries:        c.MaxRetries,
+		ChromeMajor:       c.ChromeMajor,
+	}
+}
+
+func (c ImageConfig) internal() config.ImageConfig {
+	return config.ImageConfig{
+		MinShortSide:   c.MinShortSide,
+		MinArea:        c.MinArea,
+		MinAspect:      c.MinAspect,
+		MaxAspect:      c.MaxAspect,
+		RatioWhitelist: slices.Clone(c.RatioWhitelist),
+		RatioTol:       c.RatioTol,
+		AdSizes:        config.AdSizeSet(maps.Clone(c.AdSizes)),
+		BadHintRegex:   c.BadHintRegex,
+	}
+}
+
+func imageConfigFrom(c config.ImageConfig) ImageConfig {
+	return ImageConfig{
+		MinShortSide:   c.MinShortSide,
+		MinArea:        c.MinArea,
+		MinAspect:      c.MinAspect,
+		MaxAspect:      c.MaxAspect,
+		RatioWhitelist: slices.Clone(c.RatioWhitelist),
+		RatioTol:       c.RatioTol,
+		AdSizes:        maps.Clone(map[string]bool(c.AdSizes)),
+		BadHintRegex:   c.BadHintRegex,
+	}
+}
+
+func (p Politeness) internal() config.Politeness {
+	return config.Politeness{MinIntervalMs: p.MinIntervalMs, MaxConcurrentPerHost: p.MaxConcurrentPerHost}
+}
+
+func (o BrowserOptions) internal() core.BrowserOptions {
+	return core.BrowserOptions{
+		Optimized:    o.Optimized,
+		BlockImages:  o.BlockImages,
+		BlockJS:      o.BlockJS,
+		BlockFonts:   o.BlockFonts,
+		BlockCSS:     o.BlockCSS,
+		WindowWidth:  o.WindowWidth,
+		WindowHeight: o.WindowHeight,
+		UserAgent:    o.UserAgent,
+	}
+}
+
+func browserOptionsFrom(o core.BrowserOptions) BrowserOptions {
+	return BrowserOptions{
+		Optimized:    o.Optimized,
+		BlockImages:  o.BlockImages,
+		BlockJS:      o.BlockJS,
+		BlockFonts:   o.BlockFonts,
+		BlockCSS:     o.BlockCSS,
+		WindowWidth:  o.WindowWidth,
+		WindowHeight: o.WindowHeight,
+		UserAgent:    o.UserAgent,
+	}
+}
+
+func (o ExtractionOptions) internal() core.ExtractionOptions {
+	return core.ExtractionOptions{
+		PreserveHTML:      o.PreserveHTML,
+		IncludeMetadata:   o.IncludeMetadata,
+		MinTextLength:     o.MinTextLength,
+		MinParagraphChars: o.MinParagraphChars,
+		RemoveComments:    o.RemoveComments,
+		OutputFormat:      o.OutputFormat,
+		HTMLProfile:       o.HTMLProfile,
+		Debug:             o.Debug,
+		MaxAge:            o.MaxAge,
+		MaxBytes:          o.MaxBytes,
+	}
+}
+
+func extractionOptionsFrom(o core.ExtractionOptions) ExtractionOptions {
+	return ExtractionOptions{
+		PreserveHTML:      o.PreserveHTML,
+		IncludeMetadata:   o.IncludeMetadata,
+		MinTextLength:     o.MinTextLength,
+		MinParagraphChars: o.MinParagraphChars,
+		RemoveComments:    o.RemoveComments,
+		OutputFormat:      o.OutputFormat,
+		HTMLProfile:       o.HTMLProfile,
+		Debug:             o.Debug,
+		MaxAge:            o.MaxAge,
+		MaxBytes:          o.MaxBytes,
+	}
+}
@@ -0,0 +1,129 @@
+package scraper
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/config"
+	core "github.com/vdelacou/Go-Extract-Article-Content/internal/scraper"
+)
+
+// assertAllFieldsSet fails when a field of the struct v is zero, meaning a conversion dropped it
+func assertAllFieldsSet(t *testing.T, v any) {
+	t.Helper()
+	value := reflect.ValueOf(v)
+	for i := 0; i < value.NumField(); i++ {
+		if value.Field(i).IsZero() {
+			t.Errorf("%s.%s was not converted", value.Type(), value.Type().Field(i).Name)
+		}
+	}
+}
+
+func TestConversionsKeepEveryField(t *testing.T) {
+	maxAge := 60
+	browser := BrowserOptions{Optimized: true, BlockImages: true, BlockJS: true, BlockFonts: true, BlockCSS: true, WindowWidth: 800, WindowHeight: 600, UserAgent: "test/1.0"}
+	extraction := ExtractionOptions{
+		PreserveHTML: true, IncludeMetadata: true, MinTextLength: 10, MinParagraphChars: 5, RemoveComments: true,
+		OutputFormat: OutputFormatHTML, HTMLProfile: HTMLProfileTables, Debug: true, MaxAge: &maxAge, MaxBytes: 1024,
+	}
+	result := FetchResult{HTML: "<p>", FinalURL: "https://example.com/", Validators: Validators{ETag: `"1"`, LastModified: "yesterday"}, Encoding: "utf-8", Truncated: TruncatedSizeLimit, Metadata: FetchMetadata{Fetcher: "http", Duration: time.Second}}
+	entry := CacheEntry{Key: "k", Response: Article{Title: "t"}, StoredAt: time.Now(), FetchURL: "https://example.com/", ETag: `"1"`, LastModified: "yesterday"}
+
+	for _, v := range []any{
+		ScrapeConfig{UserAgent: "test/1.0", TimeoutMs: 1, SizeLimitBytes: 2, MaxSizeLimitBytes: 3, MaxRetries: 4, ChromeMajor: 5}.internal(),
+		DefaultImageConfig().internal(),
+		Politeness{MinIntervalMs: 1, MaxConcurrentPerHost: 2}.internal(),
+		browser.internal(),
+		extraction.internal(),
+		result.internal(),
+		fetchResultFrom(result.internal()),
+		entry.internal(),
+		cacheEntryFrom(entry.internal()),
+	} {
+		assertAllFieldsSet(t, v)
+	}
+
+	if got := browserOptionsFrom(browser.internal()); got != browser {
+		t.Errorf("browser options round trip to %+v, want %+v", got, browser)
+	}
+	if got := extractionOptionsFrom(extraction.internal()); !reflect.DeepEqual(got, extraction) {
+		t.Errorf("extraction options round trip to %+v, want %+v", got, extraction)
+	}
+}
+
+func TestDefaultsMatchPipeline(t *testing.T) {
+	if got, want := DefaultScrapeConfig().internal(), config.BaseScrapeConfig(); got != want {
+		t.Errorf("DefaultScrapeConfig = %+v, want %+v", got, want)
+	}
+	if got, want := DefaultImageConfig().internal(), config.DefaultImageConfig(); !reflect.DeepEqual(got, want) {
+		t.Errorf("DefaultImageConfig = %+v, want %+v", got, want)
+	}
+	if got, want := DefaultBrowserOptions().internal(), core.OptimizedBrowserOptions(); got != want {
+		t.Errorf("DefaultBrowserOptions = %+v, want %+v", got, want)
+	}
+	if got, want := DefaultExtractionOptions().internal(), core.DefaultExtractionOptions(); !reflect.DeepEqual(got, want) {
+		t.Errorf("DefaultExtractionOptions = %+v, want %+v", got, want)
+	}
+}
+
+func TestImageConfigIsCopied(t *testing.T) {
+	cfg := DefaultImageConfig()
+	internal := cfg.internal()
+	cfg.AdSizes["1x1"] = true
+	cfg.RatioWhitelist[0] = 42
+
+	if internal.AdSizes["1x1"] || internal.RatioWhitelist[0] == 42 {
+		t.Errorf("changing an ImageConfig after conversion changed the pipeline's copy")
+	}
+}
+
+func TestOptionsReachPipeline(t *testing.T) {
+	scrape := DefaultScrapeConfig()
+	scrape.UserAgent = "options/1.0"
+	image := DefaultImageConfig()
+	image.MinShortSide = 42
+	browser := DefaultBrowserOptions()
+	browser.WindowWidth = 640
+
+	s := newSettings([]Option{
+		WithScrapeConfig(scrape),
+		WithImageConfig(image),
+		WithBrowserOptions(browser),
+		WithPoliteness(Politeness{MinIntervalMs: 1000, MaxConcurrentPerHost: 1}),
+	})
+	cfg := s.pipeline()
+
+	if cfg.Scrape.UserAgent != "options/1.0" {
+		t.Errorf("pipeline user agent = %q, want the one of WithScrapeConfig", cfg.Scrape.UserAgent)
+	}
+	if cfg.Image.MinShortSide != 42 {
+		t.Errorf("pipeline MinShortSide = %d, want the one of WithImageConfig", cfg.Image.MinShortSide)
+	}
+	if cfg.Politeness != (config.Politeness{MinIntervalMs: 1000, MaxConcurrentPerHost: 1}) {
+		t.Errorf("pipeline politeness = %+v, want the one of WithPoliteness", cfg.Politeness)
+	}
+	if got := s.browserOptions.internal().WindowWidth; got != 640 {
+		t.Errorf("browser window width = %d, want the one of WithBrowserOptions", got)
+	}
+
+	// Without WithPoliteness, the scraper keeps the process-wide defaults
+	if got, want := newSettings(nil).pipeline().Politeness, config.DefaultSettings().Politeness; got != want {
+		t.Errorf("default politeness = %+v, want %+v", got, want)
+	}
+}
+
+func TestBuiltinsAreUnwrapped(t *testing.T) {
+	if _, ok := internalFetcher(NewHTTPFetcher()).(*core.HTTPClient); !ok {
+		t.Errorf("NewHTTPFetcher is not handed to the pipeline as its own HTTP client")
+	}
+	if _, ok := NewHTTPFetcher().(Revalidator); !ok {
+		t.Errorf("NewHTTPFetcher is not a Revalidator")
+	}
+	if _, ok := NewBrowserFetcher().(Revalidator); ok {
+		t.Errorf("NewBrowserFetcher is a Revalidator")
+	}
+	if _, ok := internalFetcher(NewBrowserFetcher()).(*core.BrowserClient); !ok {
+		t.Errorf("NewBrowserFetcher is not handed to the pipeline as its own browser client")
+	}
+}
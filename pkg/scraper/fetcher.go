@@ -0,0 +1,162 @@
+package scraper
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	core "github.com/vdelacou/Go-Extract-Article-Content/internal/scraper"
+)
+
+// Fetcher retrieves the HTML for a URL; implement it to add caches, archives or other sources
+// Fetchers with a SetLogger(*slog.Logger) method receive the scraper's logger, and those with a
+// Close(context.Context) error method are closed by Scraper.Close
+type Fetcher interface {
+	// Name identifies the fetcher in logs and Metadata.Fetcher
+	Name() string
+	// Fetch returns the page for targetURL, respecting the deadline on ctx
+	Fetch(ctx context.Context, targetURL string) (FetchResult, error)
+}
+
+// FetchResult is a page returned by a Fetcher
+type FetchResult struct {
+	HTML       string
+	FinalURL   string     // URL the HTML was actually served from, used to resolve relative links
+	Validators Validators // ETag and Last-Modified of FinalURL, when the fetcher can revalidate it
+	Encoding   string     // Character encoding the page was served in, such as "shift_jis", when known
+	Truncated  string     // TruncatedSizeLimit or TruncatedAfterContent when the page was not read to its end
+	Metadata   FetchMetadata
+}
+
+// FetchMetadata describes how a page was fetched
+type FetchMetadata struct {
+	Fetcher  string        // Set by the scraper to the name of the fetcher that produced the page
+	Duration time.Duration // Set by the scraper to the time spent in Fetch
+}
+
+// Validators are the ETag and Last-Modified of a fetched page, used to revalidate cached results
+type Validators struct {
+	ETag         string
+	LastModified string
+}
+
+// Revalidator is implemented by fetchers that can revalidate cached results
+type Revalidator interface {
+	// Revalidate fetches targetURL only if it changed since the response v came from,
+	// returning ErrNotModified when it did not
+	Revalidate(ctx context.Context, targetURL string, v Validators) (FetchResult, error)
+}
+
+// ErrNotModified is returned by a Revalidator when the page has not changed
+var ErrNotModified = core.ErrNotModified
+
+// builtinFetcher is a Fetcher of the pipeline, handed back to the pipeline as is by New
+type builtinFetcher struct {
+	fetcher core.Fetcher
+}
+
+// builtinRevalidator is a builtinFetcher that can revalidate cached results
+type builtinRevalidator struct {
+	builtinFetcher
+}
+
+// wrapFetcher exposes a pipeline fetcher as a Fetcher, keeping it a Revalidator when it is one
+func wrapFetcher(f core.Fetcher) Fetcher {
+	if _, ok := f.(core.Revalidator); ok {
+		return builtinRevalidator{builtinFetcher{f}}
+	}
+	return builtinFetcher{f}
+}
+
+func (f builtinFetcher) Name() string {
+	return f.fetcher.Name()
+}
+
+func (f builtinFetcher) Fetch(ctx context.Context, targetURL string) (FetchResult, error) {
+	result, err := f.fetcher.Fetch(ctx, targetURL)
+	return fetchResultFrom(result), err
+}
+
+func (f builtinRevalidator) Revalidate(ctx context.Context, targetURL string, v Validators) (FetchResult, error) {
+	result, err := f.fetcher.(core.Revalidator).Revalidate(ctx, targetURL, v.internal())
+	return fetchResultFrom(result), err
+}
+
+// fetcherAdapter runs a caller's Fetcher in the pipeline
+type fetcherAdapter struct {
+	fetcher Fetcher
+}
+
+// revalidatorAdapter is a fetcherAdapter for a Fetcher that is also a Revalidator
+type revalidatorAdapter struct {
+	fetcherAdapter
+}
+
+// internalFetcher returns the pipeline fetcher for f, unwrapping the built-in ones
+func internalFetcher(f Fetcher) core.Fetcher {
+	switch f := f.(type) {
+	case builtinFetcher:
+		return f.fetcher
+	case builtinRevalidator:
+		return f.fetcher
+	}
+	if _, ok := f.(Revalidator); ok {
+		return revalidatorAdapter{fetcherAdapter{f}}
+	}
+	return fetcherAdapter{f}
+}
+
+func (a fetcherAdapter) Name() string {
+	return a.fetcher.Name()
+}
+
+func (a fetcherAdapter) Fetch(ctx context.Context, targetURL string) (core.FetchResult, error) {
+	result, err := a.fetcher.Fetch(ctx, targetURL)
+	return result.internal(), err
+}
+
+// SetLogger passes the scraper's logger on to fetchers that take one
+func (a fetcherAdapter) SetLogger(logger *slog.Logger) {
+	if l, ok := a.fetcher.(interface{ SetLogger(*slog.Logger) }); ok {
+		l.SetLogger(logger)
+	}
+}
+
+// Close closes fetchers that hold resources
+func (a fetcherAdapter) Close(ctx context.Context) error {
+	if c, ok := a.fetcher.(interface{ Close(context.Context) error }); ok {
+		return c.Close(ctx)
+	}
+	return nil
+}
+
+func (a revalidatorAdapter) Revalidate(ctx context.Context, targetURL string, v core.Validators) (core.FetchResult, error) {
+	result, err := a.fetcher.(Revalidator).Revalidate(ctx, targetURL, Validators{ETag: v.ETag, LastModified: v.LastModified})
+	return result.internal(), err
+}
+
+func (r FetchResult) internal() core.FetchResult {
+	return core.FetchResult{
+		HTML:       r.HTML,
+		FinalURL:   r.FinalURL,
+		Validators: r.Validators.internal(),
+		Encoding:   r.Encoding,
+		Truncated:  r.Truncated,
+		Metadata:   core.FetchMetadata{Fetcher: r.Metadata.Fetcher, Duration: r.Metadata.Duration},
+	}
+}
+
+func fetchResultFrom(r core.FetchResult) FetchResult {
+	return FetchResult{
+		HTML:       r.HTML,
+		FinalURL:   r.FinalURL,
+		Validators: Validators{ETag: r.Validators.ETag, LastModified: r.Validators.LastModified},
+		Encoding:   r.Encoding,
+		Truncated:  r.Truncated,
+		Metadata:   FetchMetadata{Fetcher: r.Metadata.Fetcher, Duration: r.Metadata.Duration},
+	}
+}
+
+func (v Validators) internal() core.Validators {
+	return core.Validators{ETag: v.ETag, LastModified: v.LastModified}
+}
@@ -0,0 +1,363 @@
+// Package scraper is the public, embeddable API of the article extractor.
+// It exposes the same HTTP-first, browser-fallback pipeline used by the Cloud Run
+// service, configured with functional options instead of environment variables.
+//
+//	s := scraper.New(scraper.WithHTTPClient(client), scraper.WithLogger(logger))
+//	article, err := s.ScrapeSmart(ctx, "https://example.com/article")
+//
+// # Stability
+//
+// New, the With options, the functions and the types of this package keep their signatures
+// and fields until the module is tagged v1, except that fields may be added. Scrapers, options
+// and fetchers are converted to the pipeline's internal types when they are built, so changes
+// to the pipeline do not leak into this API. Article and the error types are the response
+// models of the HTTP API and change only with it.
+package scraper
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/config"
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/logging"
+	"github.com/vdelacou/Go-Extract-Article-Content/internal/models"
+	core "github.com/vdelacou/Go-Extract-Article-Content/internal/scraper"
+)
+
+// Scraper fetches pages and extracts articles from them
+type Scraper struct {
+	scraper *core.Scraper
+}
+
+// ScrapeSmart fetches targetURL, over HTTP first with a browser fallback, and extracts its
+// article with the default extraction options
+func (s *Scraper) ScrapeSmart(ctx context.Context, targetURL string) (Article, error) {
+	return s.scraper.ScrapeSmart(ctx, targetURL)
+}
+
+// ScrapeSmartWithOptions is ScrapeSmart with the given extraction options
+func (s *Scraper) ScrapeSmartWithOptions(ctx context.Context, targetURL string, options ExtractionOptions) (Article, error) {
+	return s.scraper.ScrapeSmartWithOptions(ctx, targetURL, options.internal())
+}
+
+// ScrapeWithMode fetches targetURL with the fetchers mode selects and extracts its article
+func (s *Scraper) ScrapeWithMode(ctx context.Context, targetURL string, mode FetchMode, options ExtractionOptions) (Article, error) {
+	return s.scraper.ScrapeWithMode(ctx, targetURL, core.FetchMode(mode), options.internal())
+}
+
+// ExtractFromHTML extracts the article from html you already have, resolving relative links
+// and images against baseURL
+func (s *Scraper) ExtractFromHTML(ctx context.Context, html, baseURL string, options ExtractionOptions) (Article, error) {
+	return s.scraper.ExtractFromHTML(ctx, html, baseURL, options.internal())
+}
+
+// Close shuts down the Chrome instances still running and closes fetchers that have a Close
+// method; browser scrapes started afterwards fail with ErrBrowserClosed
+func (s *Scraper) Close(ctx context.Context) error {
+	return s.scraper.Close(ctx)
+}
+
+// Result and error types; the response models of the HTTP API (see Stability)
+type (
+	// Article is the extraction result
+	Article = models.ScrapeResponse
+	// Image is an image selected for an article
+	Image = models.Image
+	// Quality contains content quality metrics for an article
+	Quality = models.Quality
+	// CloudflareBlockError is returned when a site blocks the scrape
+	CloudflareBlockError = models.CloudflareBlockError
+	// ContentExtractionError is returned when no article content could be extracted
+	ContentExtractionError = models.ContentExtractionError
+	// HTTPError is returned when the target site answers with an error status
+	HTTPError = models.HTTPError
+	// TimeoutError is returned when a fetch or the whole scrape runs out of time
+	TimeoutError = models.TimeoutError
+	// NonHTMLError is returned when the target URL does not serve HTML
+	NonHTMLError = models.NonHTMLError
+	// InvalidURLError is returned for URLs that cannot be parsed
+	InvalidURLError = models.InvalidURLError
+	// FetchFailedError is returned when every fetcher failed; it wraps each fetcher's error
+	FetchFailedError = models.FetchFailedError
+	// RobotsDisallowedError is returned when robots.txt does not allow the page to be fetched
+	RobotsDisallowedError = models.RobotsDisallowedError
+)
+
+// ErrorCode classifies an error returned by the scraper into a stable code such as
+// "upstream_not_found", "timeout" or "blocked", as reported by the HTTP API
+func ErrorCode(err error) string {
+	return models.ErrorCode(err)
+}
+
+// UpstreamStatus returns the HTTP status the target site answered with, or 0 when unknown
+func UpstreamStatus(err error) int {
+	return models.UpstreamStatus(err)
+}
+
+// ErrBrowserClosed is returned by browser scrapes started after Scraper.Close
+var ErrBrowserClosed = core.ErrBrowserClosed
+
+// Fetch modes; FetchModeHTTP and FetchModeBrowser select the fetcher with that name
+const (
+	FetchModeAuto    FetchMode = "auto"    // HTTP first, browser fallback
+	FetchModeHTTP    FetchMode = "http"    // Plain HTTP only
+	FetchModeBrowser FetchMode = "browser" // Headless Chrome only
+)
+
+// Output formats for ExtractionOptions.OutputFormat
+const (
+	OutputFormatText     = core.OutputFormatText
+	OutputFormatMarkdown = core.OutputFormatMarkdown
+	OutputFormatHTML     = core.OutputFormatHTML
+)
+
+// HTML sanitization profiles for ExtractionOptions.HTMLProfile
+const (
+	HTMLProfileStrict = core.HTMLProfileStrict
+	HTMLProfileLinks  = core.HTMLProfileLinks
+	HTMLProfileTables = core.HTMLProfileTables
+)
+
+// Values of Metadata.Truncated and FetchResult.Truncated for pages not read to their end
+const (
+	TruncatedSizeLimit    = core.TruncatedSizeLimit
+	TruncatedAfterContent = core.TruncatedAfterContent
+)
+
+// DefaultScrapeConfig returns the built-in fetching configuration (environment variables are ignored)
+func DefaultScrapeConfig() ScrapeConfig {
+	return scrapeConfigFrom(config.BaseScrapeConfig())
+}
+
+// DefaultImageConfig returns the built-in image filtering rules
+func DefaultImageConfig() ImageConfig {
+	return imageConfigFrom(config.DefaultImageConfig())
+}
+
+// DefaultBrowserOptions returns the browser options used for the Chrome fallback
+func DefaultBrowserOptions() BrowserOptions {
+	return browserOptionsFrom(core.OptimizedBrowserOptions())
+}
+
+// DefaultExtractionOptions returns the default extraction options (plain text output)
+func DefaultExtractionOptions() ExtractionOptions {
+	return extractionOptionsFrom(core.DefaultExtractionOptions())
+}
+
+// WithRequestID returns a copy of ctx carrying id; log lines of scrapes run with it include request_id=id
+// Scrapes run without one get a random ID
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return logging.WithRequestID(ctx, id)
+}
+
+// WithRobotsCompliance returns a copy of ctx whose scrapes only fetch pages allowed by robots.txt
+// for the extract-html-scraper user agent, honoring its Crawl-delay; disallowed pages fail
+// with a *RobotsDisallowedError
+func WithRobotsCompliance(ctx context.Context) context.Context {
+	return core.WithRobotsCompliance(ctx)
+}
+
+// RequestID returns the request ID carried by ctx, or "" if there is none
+func RequestID(ctx context.Context) string {
+	return logging.RequestID(ctx)
+}
+
+// Option configures a Scraper or extractor created by this package
+type Option func(*settings)
+
+type settings struct {
+	httpClient     *http.Client
+	scrapeConfig   ScrapeConfig
+	imageConfig    ImageConfig
+	browserOptions BrowserOptions
+	fetchers       []Fetcher
+	logger         *slog.Logger
+	cache          CacheStore
+	cacheMaxAge    time.Duration
+	politeness     *Politeness
+}
+
+// WithHTTPClient uses client for the HTTP phase instead of the built-in pooled client
+// The client's own timeout and redirect policy apply
+func WithHTTPClient(client *http.Client) Option {
+	return func(s *settings) {
+		s.httpClient = client
+	}
+}
+
+// WithScrapeConfig sets the user agent, timeouts, size limit and retries used for fetching
+func WithScrapeConfig(cfg ScrapeConfig) Option {
+	return func(s *settings) {
+		s.scrapeConfig = cfg
+	}
+}
+
+// WithImageConfig sets the image size and filtering rules
+func WithImageConfig(cfg ImageConfig) Option {
+	return func(s *settings) {
+		s.imageConfig = cfg
+	}
+}
+
+// WithBrowserOptions sets the options used for the headless Chrome fallback
+// An empty UserAgent uses the one from the scrape configuration
+func WithBrowserOptions(opts BrowserOptions) Option {
+	return func(s *settings) {
+		s.browserOptions = opts
+	}
+}
+
+// WithFetchers replaces the default fetchers (HTTP, then browser) with fetchers, tried in order
+// Use NewHTTPFetcher and NewBrowserFetcher to keep the built-in ones alongside your own
+func WithFetchers(fetchers ...Fetcher) Option {
+	return func(s *settings) {
+		s.fetchers = fetchers
+	}
+}
+
+// WithLogger sends progress messages to logger; by default nothing is logged
+func WithLogger(logger *slog.Logger) Option {
+	return func(s *settings) {
+		s.logger = logger
+	}
+}
+
+// WithCache serves results from store while they are younger than maxAge, or than
+// ExtractionOptions.MaxAge when set, and revalidates older ones with a conditional request
+func WithCache(store CacheStore, maxAge time.Duration) Option {
+	return func(s *settings) {
+		s.cache = store
+		s.cacheMaxAge = maxAge
+	}
+}
+
+// WithPoliteness gives the scraper its own per-site limits instead of sharing the process-wide
+// ones, which default to 250ms between fetches of a site and 2 fetches of it at a time
+func WithPoliteness(p Politeness) Option {
+	return func(s *settings) {
+		s.politeness = &p
+	}
+}
+
+// newSettings applies opts on top of the defaults
+func newSettings(opts []Option) settings {
+	s := settings{
+		scrapeConfig:   DefaultScrapeConfig(),
+		imageConfig:    DefaultImageConfig(),
+		browserOptions: DefaultBrowserOptions(),
+		logger:         slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	for _, opt := range opts {
+		opt(&s)
+	}
+	return s
+}
+
+// pipeline returns the settings of the internal pipeline for s, with the built-in timeouts,
+// content selectors and robots.txt settings
+func (s settings) pipeline() *config.Settings {
+	cfg := config.DefaultSettings()
+	cfg.Scrape = s.scrapeConfig.internal()
+	cfg.Image = s.imageConfig.internal()
+	if s.politeness != nil {
+		cfg.Politeness = s.politeness.internal()
+	}
+	return cfg
+}
+
+// New creates a Scraper configured by opts
+func New(opts ...Option) *Scraper {
+	s := newSettings(opts)
+	cfg := s.pipeline()
+
+	var fetchers []core.Fetcher
+	if s.fetchers == nil {
+		fetchers = []core.Fetcher{newHTTPFetcher(s, cfg), newBrowserFetcher(s, cfg)}
+	}
+	for _, f := range s.fetchers {
+		fetchers = append(fetchers, internalFetcher(f))
+	}
+
+	scraper := core.NewScraperWithSettingsAndFetchers(cfg, newArticleExtractor(s, cfg), fetchers...)
+	scraper.SetLogger(s.logger)
+	if s.cache != nil {
+		scraper.SetCache(internalStore(s.cache), s.cacheMaxAge)
+	}
+	if s.politeness != nil {
+		// A scheduler of its own, applying the scraper's limits to the sites only it fetches
+		scraper.SetScheduler(core.NewHostScheduler(nil))
+	}
+	return &Scraper{scraper}
+}
+
+// NewHTTPFetcher creates the built-in plain HTTP fetcher
+// WithHTTPClient and WithScrapeConfig apply
+func NewHTTPFetcher(opts ...Option) Fetcher {
+	s := newSettings(opts)
+	return wrapFetcher(newHTTPFetcher(s, s.pipeline()))
+}
+
+// NewBrowserFetcher creates the built-in headless Chrome fetcher
+// WithScrapeConfig, WithBrowserOptions and WithLogger apply
+func NewBrowserFetcher(opts ...Option) Fetcher {
+	s := newSettings(opts)
+	return wrapFetcher(newBrowserFetcher(s, s.pipeline()))
+}
+
+func newHTTPFetcher(s settings, cfg *config.Settings) core.Fetcher {
+	return core.NewHTTPClientWithSettings(s.httpClient, cfg)
+}
+
+func newBrowserFetcher(s settings, cfg *config.Settings) core.Fetcher {
+	browser := core.NewBrowserClientWithSettings(cfg, s.browserOptions.internal())
+	browser.SetLogger(s.logger)
+	return browser
+}
+
+// ArticleExtractor extracts articles from HTML without fetching
+type ArticleExtractor struct {
+	extractor *core.ArticleExtractor
+}
+
+// NewArticleExtractor creates an extractor for HTML you already have
+// Only WithImageConfig and WithLogger apply
+func NewArticleExtractor(opts ...Option) *ArticleExtractor {
+	s := newSettings(opts)
+	return &ArticleExtractor{newArticleExtractor(s, s.pipeline())}
+}
+
+func newArticleExtractor(s settings, cfg *config.Settings) *core.ArticleExtractor {
+	extractor := core.NewArticleExtractorWithSettings(cfg)
+	extractor.SetLogger(s.logger)
+	return extractor
+}
+
+// ExtractArticle extracts the article from html with the default extraction options,
+// resolving relative links and images against baseURL
+func (e *ArticleExtractor) ExtractArticle(html, baseURL string) Article {
+	return e.extractor.ExtractArticle(html, baseURL)
+}
+
+// ExtractArticleWithOptions is ExtractArticle with the given extraction options
+func (e *ArticleExtractor) ExtractArticleWithOptions(html, baseURL string, options ExtractionOptions) Article {
+	return e.extractor.ExtractArticleWithOptions(html, baseURL, options.internal())
+}
+
+// ImageExtractor selects and scores article images from HTML
+type ImageExtractor struct {
+	extractor *core.ImageExtractor
+}
+
+// NewImageExtractor creates an image extractor; only WithImageConfig applies
+func NewImageExtractor(opts ...Option) *ImageExtractor {
+	return &ImageExtractor{core.NewImageExtractorWithConfig(newSettings(opts).imageConfig.internal())}
+}
+
+// ExtractImagesFromHTML returns the Open Graph and JSON-LD images of html and those within its
+// article content, with URLs resolved against baseURL
+func (e *ImageExtractor) ExtractImagesFromHTML(html, baseURL string) []Image {
+	return e.extractor.ExtractImagesFromHTML(html, baseURL)
+}
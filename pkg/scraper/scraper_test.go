@@ -0,0 +1,242 @@
+package scraper_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/vdelacou/Go-Extract-Article-Content/pkg/scraper"
+)
+
+const articleHTML = `<html><head><title>Library Article</title></head><body><main><article>
+<h1>Library Article</h1>
+<p>Embedding the scraper as a library runs exactly the same pipeline as the service does.</p>
+<p>Functional options replace environment variables so each caller can configure its own instance.</p>
+<p>This paragraph only exists to give readability enough text to consider this a real article body.</p>
+<img src="/photo.jpg" width="970" height="250" alt="A wide photo">
+</article></main></body></html>`
+
+// countingTransport counts the requests sent through it
+type countingTransport struct {
+	requests atomic.Int32
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.requests.Add(1)
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// pageFetcher serves articleHTML for every URL, with validators so cached results can be revalidated
+type pageFetcher struct {
+	fetches     atomic.Int32
+	revalidated atomic.Int32
+	fetchedAtMu sync.Mutex
+	fetchedAt   []time.Time
+}
+
+func (f *pageFetcher) Name() string {
+	return "pages"
+}
+
+func (f *pageFetcher) Fetch(ctx context.Context, targetURL string) (scraper.FetchResult, error) {
+	f.fetches.Add(1)
+	f.fetchedAtMu.Lock()
+	f.fetchedAt = append(f.fetchedAt, time.Now())
+	f.fetchedAtMu.Unlock()
+	return scraper.FetchResult{HTML: articleHTML, FinalURL: targetURL, Validators: scraper.Validators{ETag: `"v1"`}}, nil
+}
+
+// revalidatingFetcher is a pageFetcher that is also a Revalidator, whose page never changes
+type revalidatingFetcher struct {
+	*pageFetcher
+}
+
+func (f revalidatingFetcher) Revalidate(ctx context.Context, targetURL string, v scraper.Validators) (scraper.FetchResult, error) {
+	if v.ETag != `"v1"` {
+		return scraper.FetchResult{}, fmt.Errorf("revalidating with ETag %q, want the one the page was served with", v.ETag)
+	}
+	f.revalidated.Add(1)
+	return scraper.FetchResult{}, scraper.ErrNotModified
+}
+
+// mapCache is a CacheStore implemented outside the package
+type mapCache struct {
+	mu      sync.Mutex
+	entries map[string]scraper.CacheEntry
+}
+
+func (c *mapCache) Get(key string) (scraper.CacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	return entry, ok
+}
+
+func (c *mapCache) Put(entry scraper.CacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[entry.Key] = entry
+}
+
+func newTestContext(t *testing.T) context.Context {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	t.Cleanup(cancel)
+	return ctx
+}
+
+func TestNewUsesProvidedHTTPClientAndLogger(t *testing.T) {
+	var userAgent atomic.Value
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgent.Store(r.Header.Get("User-Agent"))
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(articleHTML))
+	}))
+	defer server.Close()
+
+	cfg := scraper.DefaultScrapeConfig()
+	cfg.UserAgent = "library-test/1.0"
+
+	transport := &countingTransport{}
+	var logs bytes.Buffer
+	s := scraper.New(
+		scraper.WithHTTPClient(&http.Client{Transport: transport}),
+		scraper.WithScrapeConfig(cfg),
+		scraper.WithLogger(slog.New(slog.NewTextHandler(&logs, nil))),
+	)
+
+	ctx := scraper.WithRequestID(newTestContext(t), "req-123")
+	article, err := s.ScrapeWithMode(ctx, server.URL+"/article", scraper.FetchModeHTTP, scraper.DefaultExtractionOptions())
+	if err != nil {
+		t.Fatalf("scrape failed: %v", err)
+	}
+	if article.Title != "Library Article" {
+		t.Errorf("unexpected title %q", article.Title)
+	}
+	if transport.requests.Load() == 0 {
+		t.Errorf("expected the page to be fetched through the provided HTTP client")
+	}
+	if got := userAgent.Load(); got != "library-test/1.0" {
+		t.Errorf("expected configured user agent, got %v", got)
+	}
+	if !strings.Contains(logs.String(), "fetcher=http") {
+		t.Errorf("expected progress messages on the provided logger, got %q", logs.String())
+	}
+	for _, line := range strings.Split(strings.TrimSpace(logs.String()), "\n") {
+		if !strings.Contains(line, "request_id=req-123") {
+			t.Errorf("expected every log line to carry the request ID, got %q", line)
+		}
+	}
+}
+
+func TestWithFetchers(t *testing.T) {
+	fetcher := &pageFetcher{}
+	s := scraper.New(scraper.WithFetchers(fetcher))
+
+	article, err := s.ScrapeSmart(newTestContext(t), "https://fetchers.test/article")
+	if err != nil {
+		t.Fatalf("scrape failed: %v", err)
+	}
+	if article.Metadata.Fetcher != "pages" {
+		t.Errorf("Metadata.Fetcher = %q, want the provided fetcher", article.Metadata.Fetcher)
+	}
+	if n := fetcher.fetches.Load(); n != 1 {
+		t.Errorf("provided fetcher called %d times, want 1", n)
+	}
+}
+
+func TestWithCache(t *testing.T) {
+	tests := []struct {
+		name  string
+		store scraper.CacheStore
+	}{
+		{"built-in memory cache", scraper.NewMemoryCache(10)},
+		{"caller's store", &mapCache{entries: make(map[string]scraper.CacheEntry)}},
+		{"tiered caller's store", scraper.NewTieredCache(scraper.NewMemoryCache(10), &mapCache{entries: make(map[string]scraper.CacheEntry)})},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fetcher := &pageFetcher{}
+			s := scraper.New(scraper.WithFetchers(revalidatingFetcher{fetcher}), scraper.WithCache(tt.store, time.Hour))
+			ctx := newTestContext(t)
+			targetURL := "https://cache.test/" + strings.ReplaceAll(tt.name, " ", "-")
+
+			if _, err := s.ScrapeSmart(ctx, targetURL); err != nil {
+				t.Fatalf("first scrape failed: %v", err)
+			}
+			article, err := s.ScrapeSmart(ctx, targetURL)
+			if err != nil {
+				t.Fatalf("second scrape failed: %v", err)
+			}
+			if article.Metadata.Cache != "hit" || fetcher.fetches.Load() != 1 {
+				t.Errorf("second scrape: cache %q after %d fetches, want a hit after 1", article.Metadata.Cache, fetcher.fetches.Load())
+			}
+
+			// A stale result is revalidated through the fetcher that produced it
+			options := scraper.DefaultExtractionOptions()
+			options.MaxAge = new(int)
+			article, err = s.ScrapeSmartWithOptions(ctx, targetURL, options)
+			if err != nil {
+				t.Fatalf("revalidated scrape failed: %v", err)
+			}
+			if article.Metadata.Cache != "revalidated" || fetcher.revalidated.Load() != 1 {
+				t.Errorf("stale scrape: cache %q after %d revalidations, want revalidated after 1", article.Metadata.Cache, fetcher.revalidated.Load())
+			}
+		})
+	}
+}
+
+func TestWithPoliteness(t *testing.T) {
+	fetcher := &pageFetcher{}
+	s := scraper.New(scraper.WithFetchers(fetcher), scraper.WithPoliteness(scraper.Politeness{MinIntervalMs: 400, MaxConcurrentPerHost: 1}))
+	ctx := newTestContext(t)
+
+	for _, path := range []string{"/first", "/second"} {
+		if _, err := s.ScrapeSmart(ctx, "https://polite.test"+path); err != nil {
+			t.Fatalf("scrape of %s failed: %v", path, err)
+		}
+	}
+	// The process-wide default is 250ms
+	if gap := fetcher.fetchedAt[1].Sub(fetcher.fetchedAt[0]); gap < 400*time.Millisecond {
+		t.Errorf("fetches of the same site %v apart, want at least the configured 400ms", gap)
+	}
+}
+
+func TestWithImageConfig(t *testing.T) {
+	// 970x250 is an ad size by default
+	noAdSizes := scraper.DefaultImageConfig()
+	noAdSizes.AdSizes = nil
+
+	tests := []struct {
+		name   string
+		opts   []scraper.Option
+		images int
+	}{
+		{"default rules drop ad-sized images", nil, 0},
+		{"without ad sizes they are kept", []scraper.Option{scraper.WithImageConfig(noAdSizes)}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := scraper.New(tt.opts...)
+			article, err := s.ExtractFromHTML(newTestContext(t), articleHTML, "https://images.test/article", scraper.DefaultExtractionOptions())
+			if err != nil {
+				t.Fatalf("extraction failed: %v", err)
+			}
+			if len(article.Images) != tt.images {
+				t.Errorf("%d images, want %d: %+v", len(article.Images), tt.images, article.Images)
+			}
+
+			images := scraper.NewImageExtractor(tt.opts...).ExtractImagesFromHTML(articleHTML, "https://images.test/article")
+			if len(images) != tt.images {
+				t.Errorf("image extractor found %d images, want %d", len(images), tt.images)
+			}
+		})
+	}
+}
@@ -16,8 +16,9 @@ TOKEN=$(gcloud auth print-identity-token)
 
 # Make authenticated request
 curl -X GET \
-  "$SERVICE_URL?url=$TEST_URL&key=$API_KEY" \
+  "$SERVICE_URL?url=$TEST_URL" \
   -H "Authorization: Bearer $TOKEN" \
+  -H "X-API-Key: $API_KEY" \
   -H "Content-Type: application/json"
 
 echo ""
@@ -17,4 +17,4 @@ echo "Test URL: $TEST_URL"
 echo ""
 
 # Test the service
-curl -v "$SERVICE_URL?url=$TEST_URL&key=$API_KEY"
+curl -v -H "X-API-Key: $API_KEY" "$SERVICE_URL?url=$TEST_URL"